@@ -0,0 +1,15 @@
+// Package all registers every bank of this module, import it for its side effects
+package all
+
+import (
+	_ "github.com/JHeimbach/csvtomt940/banks/camt"
+	_ "github.com/JHeimbach/csvtomt940/banks/camtcsv"
+	_ "github.com/JHeimbach/csvtomt940/banks/comdirect"
+	_ "github.com/JHeimbach/csvtomt940/banks/dkb"
+	_ "github.com/JHeimbach/csvtomt940/banks/generic"
+	_ "github.com/JHeimbach/csvtomt940/banks/ing"
+	_ "github.com/JHeimbach/csvtomt940/banks/n26"
+	_ "github.com/JHeimbach/csvtomt940/banks/paypal"
+	_ "github.com/JHeimbach/csvtomt940/banks/revolut"
+	_ "github.com/JHeimbach/csvtomt940/banks/wise"
+)
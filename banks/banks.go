@@ -0,0 +1,97 @@
+package banks
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+// Options are the settings from the command line, every bank uses only the options it needs
+type Options struct {
+	HasCategory bool
+	Iban        string
+	StartSaldo  int64
+	// Lenient logs inconsistencies in the csv as warnings instead of failing
+	Lenient bool
+	// FixOrder sorts transactions of the same day so that every saldo is the previous saldo plus the amount
+	FixOrder bool
+	// Profile is the path of the profile file for the generic bank
+	Profile string
+	// ReferenceTemplate is the template of the statement reference, it replaces references that a bank takes from the export
+	ReferenceTemplate string
+}
+
+// Factory creates a new mt940.Bank with the given options
+type Factory func(opts Options) (mt940.Bank, error)
+
+// Detector returns the confidence between 0 and 1 that peek is the beginning of a csv export of the bank
+type Detector func(peek []byte) float64
+
+type registration struct {
+	factory Factory
+	detect  Detector
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]registration)
+)
+
+// Register makes a bank available under the given name, it is meant to be called from the init function of the bank package
+// Register panics if it is called twice with the same name or if factory or detect is nil
+func Register(name string, factory Factory, detect Detector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil || detect == nil {
+		panic("banks: Register factory or detector is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("banks: Register called twice for " + name)
+	}
+	registry[name] = registration{factory: factory, detect: detect}
+}
+
+// New creates the bank registered with name
+func New(name string, opts Options) (mt940.Bank, error) {
+	registryMu.RLock()
+	r, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("bank \"%s\" not supported", name)
+	}
+	return r.factory(opts)
+}
+
+// Detect asks every registered bank for its confidence and returns the name of the best match,
+// if no bank recognizes peek it returns an empty name and confidence 0
+func Detect(peek []byte) (string, float64) {
+	var best string
+	var bestConfidence float64
+	for _, name := range Names() {
+		registryMu.RLock()
+		detect := registry[name].detect
+		registryMu.RUnlock()
+
+		if confidence := detect(peek); confidence > bestConfidence {
+			best, bestConfidence = name, confidence
+		}
+	}
+	return best, bestConfidence
+}
+
+// Names returns the sorted names of all registered banks
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,77 @@
+package banks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+type mockBank struct{}
+
+func (m *mockBank) Parse(ctx context.Context, r io.Reader) (*mt940.BankData, error) {
+	return &mt940.BankData{}, nil
+}
+
+func TestRegistry(t *testing.T) {
+	factory := func(opts Options) (mt940.Bank, error) {
+		return &mockBank{}, nil
+	}
+	Register("test-a", factory, func(peek []byte) float64 {
+		if bytes.HasPrefix(peek, []byte("A")) {
+			return 1
+		}
+		return 0.1
+	})
+	Register("test-b", factory, func(peek []byte) float64 {
+		if bytes.HasPrefix(peek, []byte("B")) {
+			return 1
+		}
+		return 0
+	})
+
+	tests := []struct {
+		name           string
+		peek           string
+		wantName       string
+		wantConfidence float64
+	}{
+		{name: "detects a", peek: "A;B;C", wantName: "test-a", wantConfidence: 1},
+		{name: "detects b", peek: "B;C;D", wantName: "test-b", wantConfidence: 1},
+		{name: "low confidence", peek: "C;D;E", wantName: "test-a", wantConfidence: 0.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotName, gotConfidence := Detect([]byte(tt.peek))
+			if gotName != tt.wantName || gotConfidence != tt.wantConfidence {
+				t.Errorf("Detect() = %s, %v, want %s, %v", gotName, gotConfidence, tt.wantName, tt.wantConfidence)
+			}
+		})
+	}
+
+	if _, err := New("test-a", Options{}); err != nil {
+		t.Errorf("New() error = %v", err)
+	}
+	if _, err := New("unknown", Options{}); err == nil {
+		t.Errorf("New() expected error for unknown bank")
+	}
+}
+
+func TestRegister_PanicsOnDuplicate(t *testing.T) {
+	factory := func(opts Options) (mt940.Bank, error) {
+		return &mockBank{}, nil
+	}
+	detect := func(peek []byte) float64 {
+		return 0
+	}
+	Register("test-dup", factory, detect)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register() did not panic on duplicate name")
+		}
+	}()
+	Register("test-dup", factory, detect)
+}
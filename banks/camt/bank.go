@@ -0,0 +1,285 @@
+// Package camt converts the ISO 20022 bank to customer statements camt.053 and account reports camt.052
+package camt
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/iban"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// errNoEntries is returned for statements without booked entries and without balances, they are skipped
+var errNoEntries = errors.New("statement has neither booked entries nor balances")
+
+type Camt struct {
+	// Lenient logs balances that do not match the entries as warnings instead of failing the conversion
+	Lenient bool
+	// ReferenceTemplate generates the statement reference, without it the Id of the statement is the reference
+	ReferenceTemplate string
+	logger            *log.Logger
+}
+
+func init() {
+	banks.Register("camt", func(opts banks.Options) (mt940.Bank, error) {
+		c := New()
+		c.Lenient = opts.Lenient
+		c.ReferenceTemplate = opts.ReferenceTemplate
+		return c, nil
+	}, Detect)
+}
+
+// Detect returns the confidence that peek is the beginning of a camt.053 or camt.052 document
+func Detect(peek []byte) float64 {
+	switch {
+	case bytes.Contains(peek, []byte("urn:iso:std:iso:20022:tech:xsd:camt.053.")),
+		bytes.Contains(peek, []byte("urn:iso:std:iso:20022:tech:xsd:camt.052.")):
+		return 1
+	case bytes.Contains(peek, []byte("BkToCstmrStmt>")), bytes.Contains(peek, []byte("BkToCstmrAcctRpt>")):
+		return 0.9
+	}
+	return 0
+}
+
+func New() *Camt {
+	logger := log.New(os.Stdout, "[CAMT] ", log.Lmsgprefix)
+
+	return &Camt{
+		logger: logger,
+	}
+}
+
+// Parse returns the first statement of the camt document
+func (c *Camt) Parse(ctx context.Context, r io.Reader) (*mt940.BankData, error) {
+	all, err := c.ParseAll(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return all[0], nil
+}
+
+// ParseAll reads the camt.053 or camt.052 document from r and returns a BankData for every statement (Stmt) or report (Rpt),
+// statements without booked entries are kept with their opening and closing balance, statements without balances are skipped
+func (c *Camt) ParseAll(ctx context.Context, r io.Reader) ([]*mt940.BankData, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+	var doc document
+	err := decoder.Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("could not read camt document: %w", err)
+	}
+
+	statements := append(doc.Statements, doc.Reports...)
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("no statement found, the document must contain BkToCstmrStmt or BkToCstmrAcctRpt")
+	}
+
+	result := make([]*mt940.BankData, 0, len(statements))
+	for i := range statements {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := c.convert(&statements[i])
+		if errors.Is(err, errNoEntries) {
+			c.logger.Printf("skipped statement %s without booked entries and balances", statements[i].ID)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not convert statement %s: %w", statements[i].ID, err)
+		}
+		result = append(result, data)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no booked entries or balances found in camt document")
+	}
+	return result, nil
+}
+
+// charsetReader decodes documents that are not UTF-8 encoded, e.g. <?xml version="1.0" encoding="ISO-8859-1"?>
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %s: %w", label, err)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// convert creates the BankData of the statement with its opening and closing balance, the saldo of every transaction
+// is calculated from the opening balance or backwards from the closing balance
+func (c *Camt) convert(s *statement) (*mt940.BankData, error) {
+	accountIBAN := iban.Normalize(strings.TrimSpace(s.Account.IBAN))
+	if accountIBAN == "" {
+		return nil, fmt.Errorf("account has no iban")
+	}
+	bankNumber, accountNumber, err := iban.Split(accountIBAN)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []*mt940.StatementLine
+	pending := 0
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		if status := e.Status.value(); status != "" && status != "BOOK" {
+			pending++
+			continue
+		}
+		entryLines, err := e.statementLines()
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		lines = append(lines, entryLines...)
+	}
+	if pending > 0 {
+		c.logger.Printf("skipped %d entries of statement %s that are not booked", pending, s.ID)
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Date().Before(lines[j].Date())
+	})
+
+	opening, closing, err := s.balances()
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 && opening == nil && closing == nil {
+		return nil, errNoEntries
+	}
+	opening, closing, err = c.calculateSaldo(s.ID, lines, opening, closing)
+	if err != nil {
+		return nil, err
+	}
+
+	ta := make([]mt940.Transaction, len(lines))
+	for i, l := range lines {
+		ta[i] = l
+	}
+	data := &mt940.BankData{
+		IBAN:              accountIBAN,
+		BankNumber:        bankNumber,
+		AccountNumber:     accountNumber,
+		ReferenceTemplate: c.ReferenceTemplate,
+		StatementNumber:   s.number(),
+		Opening:           opening,
+		Closing:           closing,
+		Transactions:      ta,
+	}
+	if c.ReferenceTemplate == "" {
+		data.Reference = mt940.SanitizeReference(s.ID)
+	}
+	return data, nil
+}
+
+// number returns the legal or the electronic sequence number of the statement, numbers that do not fit into :28C: are ignored
+func (s *statement) number() int {
+	for _, v := range []string{s.LegalSequenceNo, s.ElectronicSequenceNo} {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > 0 && n <= 99999 {
+			return n
+		}
+	}
+	return 0
+}
+
+// balances returns the opening (OPBD or PRCD) and the closing (CLBD or the last ITBD) balance of the statement,
+// balances that are missing are nil
+func (s *statement) balances() (opening, closing *mt940.Balance, err error) {
+	for _, b := range s.Balances {
+		code := strings.TrimSpace(b.Code)
+		if code != "OPBD" && code != "PRCD" && code != "CLBD" && code != "ITBD" {
+			continue
+		}
+		m, err := parseAmount(b.Amount, b.CdtDbtInd)
+		if err != nil {
+			return nil, nil, &mt940.ParseError{Column: "Bal/" + code, Value: b.Amount.Value, Err: err}
+		}
+		date, err := time.Parse("2006-01-02", b.Date.value())
+		if err != nil {
+			return nil, nil, &mt940.ParseError{Column: "Bal/" + code + "/Dt", Value: b.Date.value(), Err: err}
+		}
+		balance := &mt940.Balance{Date: date, Amount: m}
+		switch code {
+		case "OPBD", "PRCD":
+			if opening == nil {
+				opening = balance
+			}
+		case "CLBD":
+			closing = balance
+		case "ITBD":
+			if !hasBalance(s.Balances, "CLBD") {
+				closing = balance
+			}
+		}
+	}
+	return opening, closing, nil
+}
+
+// hasBalance returns true if one of the balances has the code
+func hasBalance(balances []balance, code string) bool {
+	for _, b := range balances {
+		if strings.TrimSpace(b.Code) == code {
+			return true
+		}
+	}
+	return false
+}
+
+// calculateSaldo sets the saldo of every line, it starts with the opening balance if it is known
+// and calculates backwards from the closing balance otherwise. It returns the balances of the statement,
+// a missing balance of a statement without lines is the other balance and a closing balance that does not match
+// the lines is dropped in lenient mode.
+func (c *Camt) calculateSaldo(id string, lines []*mt940.StatementLine, opening, closing *mt940.Balance) (*mt940.Balance, *mt940.Balance, error) {
+	if opening == nil {
+		if closing == nil {
+			return nil, nil, fmt.Errorf("statement has neither an opening (OPBD) nor a closing (CLBD) balance")
+		}
+		saldo := closing.Amount
+		for i := len(lines) - 1; i >= 0; i-- {
+			lines[i].Balance = saldo
+			var err error
+			saldo, err = saldo.Subtract(lines[i].Amount())
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not subtract amount from saldo: %w", err)
+			}
+		}
+		if len(lines) == 0 {
+			opening = closing
+		}
+		return opening, closing, nil
+	}
+
+	saldo := opening.Amount
+	for _, l := range lines {
+		var err error
+		saldo, err = saldo.Add(l.Amount())
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not add amount to saldo: %w", err)
+		}
+		l.Balance = saldo
+	}
+	if closing == nil {
+		if len(lines) == 0 {
+			closing = opening
+		}
+		return opening, closing, nil
+	}
+	if ok, _ := saldo.Equals(closing.Amount); !ok {
+		msg := fmt.Sprintf("closing balance %s does not match opening balance plus entries %s", closing.Amount.Display(), saldo.Display())
+		if !c.Lenient {
+			return nil, nil, errors.New(msg)
+		}
+		c.logger.Printf("WARNING: statement %s: %s", id, msg)
+		closing = &mt940.Balance{Date: closing.Date, Amount: saldo}
+	}
+	return opening, closing, nil
+}
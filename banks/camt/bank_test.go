@@ -0,0 +1,262 @@
+package camt
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/blz"
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+// TestMain uses the excerpt of the bank code file because the embedded file is only complete after go generate
+func TestMain(m *testing.M) {
+	d, err := blz.Load("../../blz/testdata/blz.txt")
+	if err != nil {
+		panic(err)
+	}
+	blz.SetDefault(d)
+	os.Exit(m.Run())
+}
+
+const testCamt053 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>MSG-1</MsgId><CreDtTm>2020-01-10T08:00:00</CreDtTm></GrpHdr>
+    <Stmt>
+      <Id>STMT-2020-01</Id>
+      <ElctrncSeqNb>12</ElctrncSeqNb>
+      <Acct><Id><IBAN>DE89370400440532013000</IBAN></Id><Ccy>EUR</Ccy></Acct>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>PRCD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">1173.74</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2020-01-05</Dt></Dt>
+      </Bal>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">1178.32</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2020-01-09</Dt></Dt>
+      </Bal>
+      <Ntry>
+        <Amt Ccy="EUR">1.62</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts>
+        <BookgDt><Dt>2020-01-09</Dt></BookgDt><ValDt><Dt>2020-01-08</Dt></ValDt>
+        <AcctSvcrRef>REF-2</AcctSvcrRef>
+        <BkTxCd><Prtry><Cd>NTRF+105+931</Cd><Issr>DK</Issr></Prtry></BkTxCd>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>E-456</EndToEndId><MndtId>M-123</MndtId></Refs>
+          <RltdPties>
+            <Dbtr><Nm>Test Tester</Nm></Dbtr>
+            <Cdtr><Nm>Yabox</Nm><Id><PrvtId><Othr><Id>DE98ZZZ09999999999</Id></Othr></PrvtId></Id></Cdtr>
+            <CdtrAcct><Id><IBAN>DE02120300000000202051</IBAN></Id></CdtrAcct>
+          </RltdPties>
+          <RltdAgts><CdtrAgt><FinInstnId><BIC>BYLADEM1001</BIC></FinInstnId></CdtrAgt></RltdAgts>
+          <RmtInf><Ustrd>Reactive full-range local area network</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+        <AddtlNtryInf>SEPA Basislastschrift</AddtlNtryInf>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">6.20</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts>
+        <BookgDt><Dt>2020-01-06</Dt></BookgDt><ValDt><Dt>2020-01-06</Dt></ValDt>
+        <AcctSvcrRef>REF-1</AcctSvcrRef>
+        <BkTxCd><Domn><Cd>PMNT</Cd><Fmly><Cd>RCDT</Cd><SubFmlyCd>ESCT</SubFmlyCd></Fmly></Domn></BkTxCd>
+        <NtryDtls>
+          <TxDtls>
+            <Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs>
+            <AmtDtls><TxAmt><Amt Ccy="EUR">5.00</Amt></TxAmt></AmtDtls>
+            <RltdPties><Dbtr><Nm>Jörg Müller</Nm></Dbtr><DbtrAcct><Id><IBAN>DE02500105170137075030</IBAN></Id></DbtrAcct></RltdPties>
+            <RmtInf><Ustrd>First part</Ustrd></RmtInf>
+          </TxDtls>
+          <TxDtls>
+            <AmtDtls><TxAmt><Amt Ccy="EUR">1.20</Amt></TxAmt></AmtDtls>
+            <RltdPties><Dbtr><Nm>Yabox</Nm></Dbtr></RltdPties>
+            <RmtInf><Strd><CdtrRefInf><Ref>RF18539007547034</Ref></CdtrRefInf></Strd></RmtInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">9.99</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>PDNG</Sts>
+        <BookgDt><Dt>2020-01-10</Dt></BookgDt>
+      </Ntry>
+    </Stmt>
+    <Stmt>
+      <Id>STMT-2020-02</Id>
+      <ElctrncSeqNb>13</ElctrncSeqNb>
+      <Acct><Id><IBAN>DE89370400440532013000</IBAN></Id><Ccy>EUR</Ccy></Acct>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">1178.32</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2020-01-10</Dt></Dt>
+      </Bal>
+    </Stmt>
+    <Stmt>
+      <Id>STMT-EMPTY</Id>
+      <Acct><Id><IBAN>DE89370400440532013000</IBAN></Id></Acct>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>
+`
+
+const testCamt052 = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.052.001.08">
+  <BkToCstmrAcctRpt>
+    <Rpt>
+      <Id>RPT-1</Id>
+      <Acct><Id><IBAN>DE89370400440532013000</IBAN></Id></Acct>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>ITBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">100.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Dt><DtTm>2020-01-10T12:00:00</DtTm></Dt>
+      </Bal>
+      <Ntry>
+        <Amt Ccy="EUR">10.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><RvslInd>true</RvslInd><Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><DtTm>2020-01-10T09:00:00</DtTm></BookgDt>
+        <NtryDtls><TxDtls>
+          <RltdPties><Dbtr><Pty><Nm>Gr` + "\xfc" + `n GmbH</Nm></Pty></Dbtr></RltdPties>
+          <RltdAgts><DbtrAgt><FinInstnId><BICFI>INGDDEFFXXX</BICFI></FinInstnId></DbtrAgt></RltdAgts>
+        </TxDtls></NtryDtls>
+      </Ntry>
+    </Rpt>
+  </BkToCstmrAcctRpt>
+</Document>
+`
+
+func TestCamt_ParseAll(t *testing.T) {
+	var logs strings.Builder
+	c := New()
+	c.logger = log.New(&logs, "", 0)
+
+	got, err := c.ParseAll(context.Background(), strings.NewReader(testCamt053))
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ParseAll() got %d statements, want 2 without the one without balances", len(got))
+	}
+	for _, skipped := range []string{"skipped 1 entries of statement STMT-2020-01", "skipped statement STMT-EMPTY"} {
+		if !strings.Contains(logs.String(), skipped) {
+			t.Errorf("ParseAll() logs = %q, want %q", logs.String(), skipped)
+		}
+	}
+
+	data := got[0]
+	if data.IBAN != "DE89370400440532013000" || data.StatementNumber != 12 || data.Reference != "STMT-2020-01" {
+		t.Errorf("ParseAll() iban = %s, number = %d, reference = %s", data.IBAN, data.StatementNumber, data.Reference)
+	}
+	var buf bytes.Buffer
+	if err := data.ConvertToMT940(&buf); err != nil {
+		t.Fatalf("ConvertToMT940() error = %v", err)
+	}
+	want := ":20:STMT-2020-01\r\n" +
+		":25:37040044/0532013000\r\n" +
+		":28C:12\r\n" +
+		":60F:C200105EUR1173,74\r\n" +
+		":61:2001060106C5,00NTRFNONREF//REF-1\r\n" +
+		":86:166?20SVWZ+First part?30INGDDEFFXXX?31DE02500105170137075030?32Jo\r\n" +
+		"erg Mueller\r\n" +
+		":61:2001060106C1,20NTRFNONREF//REF-1\r\n" +
+		":86:166?20SVWZ+RF18539007547034?32Yabox\r\n" +
+		":61:2001080109D1,62NDDTE-456//REF-2\r\n" +
+		":86:105?00SEPA Basislastschrift?20EREF+E-456?21MREF+M-123?22CRED+DE98\r\n" +
+		"ZZZ09999999999?23SVWZ+Reactive full-range lo?24cal area network?3\r\n" +
+		"0BYLADEM1001?31DE02120300000000202051?32Yabox\r\n" +
+		":62F:C200109EUR1178,32\r\n"
+	if buf.String() != want {
+		t.Errorf("ConvertToMT940() =\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := got[1].ConvertToMT940(&buf); err != nil {
+		t.Fatalf("ConvertToMT940() of statement without entries error = %v", err)
+	}
+	want = ":20:STMT-2020-02\r\n" +
+		":25:37040044/0532013000\r\n" +
+		":28C:13\r\n" +
+		":60F:C200110EUR1178,32\r\n" +
+		":62F:C200110EUR1178,32\r\n"
+	if buf.String() != want {
+		t.Errorf("ConvertToMT940() =\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestCamt_ParseReferenceTemplate(t *testing.T) {
+	c := New()
+	c.logger = log.New(&strings.Builder{}, "", 0)
+	c.ReferenceTemplate = "{account}-{counter}"
+	got, err := c.Parse(context.Background(), strings.NewReader(testCamt053))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	reference, err := got.StatementReference()
+	if err != nil || reference != "0532013000-12" {
+		t.Errorf("StatementReference() = %s, %v, want the reference of the template", reference, err)
+	}
+}
+
+func TestCamt_ParseReport(t *testing.T) {
+	c := New()
+	c.logger = log.New(&strings.Builder{}, "", 0)
+	got, err := c.Parse(context.Background(), strings.NewReader(testCamt052))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	line := got.Transactions[0].(*mt940.StatementLine)
+	if !line.Sales.Reversal || line.Saldo().Amount() != -10000 || line.Amount().Amount() != 1000 {
+		t.Errorf("Parse() line = %+v, saldo %d", line.Sales, line.Saldo().Amount())
+	}
+	if d := line.Details.String(); d != "999?30INGDDEFFXXX?32Gruen GmbH" {
+		t.Errorf("Parse() details = %q", d)
+	}
+}
+
+func TestCamt_ParseErrors(t *testing.T) {
+	mismatch := strings.Replace(testCamt053, "1178.32", "1178.33", 1)
+	tests := []struct {
+		name    string
+		lenient bool
+		doc     string
+		wantErr string
+	}{
+		{name: "no xml", doc: "Buchungstag;Betrag", wantErr: "could not read camt document"},
+		{name: "no statement", doc: `<Document><BkToCstmrDbtCdtNtfctn/></Document>`, wantErr: "no statement found"},
+		{name: "no iban", doc: strings.ReplaceAll(testCamt053, "<IBAN>DE89370400440532013000</IBAN>", "<Othr><Id>1234</Id></Othr>"), wantErr: "account has no iban"},
+		{name: "invalid date", doc: strings.Replace(testCamt053, "<Dt>2020-01-09</Dt></BookgDt>", "<Dt>09.01.2020</Dt></BookgDt>", 1), wantErr: "entry 1: could not parse BookgDt"},
+		{name: "closing balance", doc: mismatch, wantErr: "closing balance €1,178.33 does not match opening balance plus entries €1,178.32"},
+		{name: "lenient closing balance", lenient: true, doc: mismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			c.Lenient = tt.lenient
+			c.logger = log.New(&strings.Builder{}, "", 0)
+			_, err := c.Parse(context.Background(), strings.NewReader(tt.doc))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		peek string
+		want float64
+	}{
+		{name: "camt.053", peek: testCamt053, want: 1},
+		{name: "camt.052", peek: testCamt052, want: 1},
+		{name: "without namespace", peek: "<Document><BkToCstmrStmt>", want: 0.9},
+		{name: "csv", peek: `"Buchungstag";"Betrag"`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.peek)); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package camt
+
+import (
+	"strings"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+// document is the part of a camt.053 (BkToCstmrStmt) or camt.052 (BkToCstmrAcctRpt) document that is converted,
+// the elements are matched by their local name so that every version of the schema can be read
+type document struct {
+	Statements []statement `xml:"BkToCstmrStmt>Stmt"`
+	Reports    []statement `xml:"BkToCstmrAcctRpt>Rpt"`
+}
+
+// statement is a Stmt of camt.053 or a Rpt of camt.052
+type statement struct {
+	ID                   string    `xml:"Id"`
+	ElectronicSequenceNo string    `xml:"ElctrncSeqNb"`
+	LegalSequenceNo      string    `xml:"LglSeqNb"`
+	Account              account   `xml:"Acct"`
+	Balances             []balance `xml:"Bal"`
+	Entries              []entry   `xml:"Ntry"`
+}
+
+type account struct {
+	IBAN     string `xml:"Id>IBAN"`
+	Other    string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+}
+
+type balance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    amount     `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Date      dateChoice `xml:"Dt"`
+}
+
+type amount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+// dateChoice is a date or a date time, only the date is used
+type dateChoice struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+// value returns the date part of the choice
+func (d dateChoice) value() string {
+	if d.Date != "" {
+		return strings.TrimSpace(d.Date)
+	}
+	v := strings.TrimSpace(d.DateTime)
+	if len(v) > len("2006-01-02") {
+		v = v[:len("2006-01-02")]
+	}
+	return v
+}
+
+type entry struct {
+	Amount              amount               `xml:"Amt"`
+	CdtDbtInd           string               `xml:"CdtDbtInd"`
+	Reversal            bool                 `xml:"RvslInd"`
+	Status              status               `xml:"Sts"`
+	BookingDate         dateChoice           `xml:"BookgDt"`
+	ValueDate           dateChoice           `xml:"ValDt"`
+	Reference           string               `xml:"AcctSvcrRef"`
+	BankTransactionCode bankTransactionCode  `xml:"BkTxCd"`
+	Details             []transactionDetails `xml:"NtryDtls>TxDtls"`
+	AdditionalInfo      string               `xml:"AddtlNtryInf"`
+}
+
+// status is the text of camt.053.001.02 and the code of later versions
+type status struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+// value returns the status, e.g. BOOK or PDNG
+func (s status) value() string {
+	if s.Code != "" {
+		return strings.TrimSpace(s.Code)
+	}
+	return strings.TrimSpace(s.Text)
+}
+
+type bankTransactionCode struct {
+	Domain      string `xml:"Domn>Cd"`
+	Family      string `xml:"Domn>Fmly>Cd"`
+	SubFamily   string `xml:"Domn>Fmly>SubFmlyCd"`
+	Proprietary string `xml:"Prtry>Cd"`
+}
+
+// gvc returns the gvc code of the transaction code, german banks send it in the proprietary code, e.g. NTRF+166+931,
+// unknown codes return 999
+func (c bankTransactionCode) gvc() string {
+	if parts := strings.Split(c.Proprietary, "+"); len(parts) > 1 && len(parts[1]) == 3 && isDigits(parts[1]) {
+		return parts[1]
+	}
+	return mt940.GVCForTransactionCode(mt940.BankTransactionCode{Domain: c.Domain, Family: c.Family, SubFamily: c.SubFamily})
+}
+
+// empty returns true if no transaction code is set
+func (c bankTransactionCode) empty() bool {
+	return c.Domain == "" && c.Proprietary == ""
+}
+
+type transactionDetails struct {
+	EndToEndID string `xml:"Refs>EndToEndId"`
+	MandateID  string `xml:"Refs>MndtId"`
+	Reference  string `xml:"Refs>AcctSvcrRef"`
+	// Amount is the amount of camt.053.001.08, AmountDetails the one of camt.053.001.02
+	Amount              amount              `xml:"Amt"`
+	AmountDetails       amount              `xml:"AmtDtls>TxAmt>Amt"`
+	CdtDbtInd           string              `xml:"CdtDbtInd"`
+	BankTransactionCode bankTransactionCode `xml:"BkTxCd"`
+	Parties             relatedParties      `xml:"RltdPties"`
+	Agents              relatedAgents       `xml:"RltdAgts"`
+	Unstructured        []string            `xml:"RmtInf>Ustrd"`
+	StructuredReference []string            `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	AdditionalInfo      string              `xml:"AddtlTxInf"`
+}
+
+// amount returns the amount of the transaction details of any version
+func (d *transactionDetails) amount() amount {
+	if d.Amount.Value != "" {
+		return d.Amount
+	}
+	return d.AmountDetails
+}
+
+// relatedParties contains the names of camt.053.001.02 (Dbtr>Nm) and of later versions (Dbtr>Pty>Nm)
+type relatedParties struct {
+	DebtorName         string `xml:"Dbtr>Nm"`
+	DebtorPartyName    string `xml:"Dbtr>Pty>Nm"`
+	DebtorIBAN         string `xml:"DbtrAcct>Id>IBAN"`
+	CreditorName       string `xml:"Cdtr>Nm"`
+	CreditorPartyName  string `xml:"Cdtr>Pty>Nm"`
+	CreditorID         string `xml:"Cdtr>Id>PrvtId>Othr>Id"`
+	CreditorPartyID    string `xml:"Cdtr>Pty>Id>PrvtId>Othr>Id"`
+	CreditorIBAN       string `xml:"CdtrAcct>Id>IBAN"`
+	UltimateDebtorName string `xml:"UltmtDbtr>Nm"`
+}
+
+type relatedAgents struct {
+	DebtorBIC     string `xml:"DbtrAgt>FinInstnId>BIC"`
+	DebtorBICFI   string `xml:"DbtrAgt>FinInstnId>BICFI"`
+	CreditorBIC   string `xml:"CdtrAgt>FinInstnId>BIC"`
+	CreditorBICFI string `xml:"CdtrAgt>FinInstnId>BICFI"`
+}
+
+// isDigits returns true if s only contains the digits 0 to 9
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,177 @@
+package camt
+
+import (
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+// maxPurposeParts limits the subfields ?20 to ?29 so that the :86: line does not exceed 390 characters
+const maxPurposeParts = 8
+
+// notProvided is sent as end to end reference if the payer did not set one
+const notProvided = "NOTPROVIDED"
+
+// parseAmount parses the amount of the camt document, debit amounts are returned as negative amounts
+func parseAmount(a amount, cdtDbtInd string) (*money.Money, error) {
+	m, err := converter.ParseAmount(strings.TrimSpace(a.Value), ".", "", strings.ToUpper(a.Currency))
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(cdtDbtInd) == "DBIT" {
+		return m.Negative(), nil
+	}
+	return m.Absolute(), nil
+}
+
+// statementLines converts the entry into statement lines without saldo, an entry with several transaction details
+// that all have their own amount (e.g. a batch booking) is split into one line per transaction
+func (e *entry) statementLines() ([]*mt940.StatementLine, error) {
+	bookingDate, err := time.Parse("2006-01-02", e.BookingDate.value())
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "BookgDt", Value: e.BookingDate.value(), Err: err}
+	}
+	valueDate := bookingDate
+	if v := e.ValueDate.value(); v != "" {
+		valueDate, err = time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, &mt940.ParseError{Column: "ValDt", Value: v, Err: err}
+		}
+	}
+	total, err := parseAmount(e.Amount, e.CdtDbtInd)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "Amt", Value: e.Amount.Value, Err: err}
+	}
+
+	amounts, err := e.detailAmounts(total)
+	if err != nil {
+		return nil, err
+	}
+	if amounts == nil {
+		// the whole entry is one transaction, it gets the first transaction details if there are any
+		var details *transactionDetails
+		if len(e.Details) > 0 {
+			details = &e.Details[0]
+		}
+		return []*mt940.StatementLine{e.statementLine(bookingDate, valueDate, total, details)}, nil
+	}
+
+	lines := make([]*mt940.StatementLine, 0, len(e.Details))
+	for i := range e.Details {
+		lines = append(lines, e.statementLine(bookingDate, valueDate, amounts[i], &e.Details[i]))
+	}
+	return lines, nil
+}
+
+// detailAmounts returns the amounts of the transaction details if there is more than one and their sum is the amount
+// of the entry, otherwise nil is returned
+func (e *entry) detailAmounts(total *money.Money) ([]*money.Money, error) {
+	if len(e.Details) < 2 {
+		return nil, nil
+	}
+	amounts := make([]*money.Money, 0, len(e.Details))
+	sum := money.New(0, total.Currency().Code)
+	for _, d := range e.Details {
+		a := d.amount()
+		if a.Value == "" {
+			return nil, nil
+		}
+		indicator := d.CdtDbtInd
+		if indicator == "" {
+			indicator = e.CdtDbtInd
+		}
+		m, err := parseAmount(a, indicator)
+		if err != nil {
+			return nil, &mt940.ParseError{Column: "TxDtls/Amt", Value: a.Value, Err: err}
+		}
+		sum, err = sum.Add(m)
+		if err != nil {
+			// details in another currency than the entry
+			return nil, nil
+		}
+		amounts = append(amounts, m)
+	}
+	if ok, _ := sum.Equals(total); !ok {
+		return nil, nil
+	}
+	return amounts, nil
+}
+
+// statementLine creates the statement line of the entry with the optional transaction details
+func (e *entry) statementLine(bookingDate, valueDate time.Time, amount *money.Money, d *transactionDetails) *mt940.StatementLine {
+	code := e.BankTransactionCode
+	reference := e.Reference
+	bookingText := e.AdditionalInfo
+	if d != nil {
+		if !d.BankTransactionCode.empty() {
+			code = d.BankTransactionCode
+		}
+		if d.Reference != "" {
+			reference = d.Reference
+		}
+		if bookingText == "" {
+			bookingText = d.AdditionalInfo
+		}
+	}
+	var references converter.SEPAReferences
+	if d != nil {
+		references = d.references()
+	}
+	gvc := code.gvc()
+	line := &mt940.StatementLine{
+		Sales: mt940.NewSalesLine(valueDate, bookingDate, amount, gvc, references.EndToEnd),
+		Details: mt940.Details{
+			GVC:         gvc,
+			BookingText: strings.Join(converter.SplitSubfields(bookingText, 1), ""),
+		},
+	}
+	// the reversal indicator of the entry marks reversals that have no reversal gvc code
+	line.Sales.Reversal = line.Sales.Reversal || e.Reversal
+	line.Sales.BankReference = mt940.SanitizeReference(reference)
+	if d == nil {
+		return line
+	}
+
+	// the counterparty is the debtor of a credit and the creditor of a debit
+	p, a := d.Parties, d.Agents
+	name, iban, bic := first(p.CreditorName, p.CreditorPartyName), p.CreditorIBAN, first(a.CreditorBIC, a.CreditorBICFI)
+	if !amount.IsNegative() {
+		name, iban, bic = first(p.UltimateDebtorName, p.DebtorName, p.DebtorPartyName), p.DebtorIBAN, first(a.DebtorBIC, a.DebtorBICFI)
+	}
+	line.SetReferences(references, maxPurposeParts)
+	line.SetCounterparty(mt940.Counterparty{Name: name, Account: mt940.NewCounterpartyAccount(strings.TrimSpace(iban), bic)})
+	return line
+}
+
+// references returns the sepa references and the remittance information of the transaction details
+func (d *transactionDetails) references() converter.SEPAReferences {
+	endToEnd := strings.TrimSpace(d.EndToEndID)
+	if endToEnd == notProvided {
+		endToEnd = ""
+	}
+
+	remittance := make([]string, 0, len(d.Unstructured)+len(d.StructuredReference))
+	for _, s := range append(d.Unstructured, d.StructuredReference...) {
+		if s = strings.TrimSpace(s); s != "" {
+			remittance = append(remittance, s)
+		}
+	}
+	return converter.ParseSEPA(strings.Join(remittance, " ")).Merge(converter.SEPAReferences{
+		EndToEnd: endToEnd,
+		Mandate:  d.MandateID,
+		Creditor: first(d.Parties.CreditorID, d.Parties.CreditorPartyID),
+	})
+}
+
+// first returns the first value that is not empty
+func first(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+// Package camtcsv converts the CSV-CAMT export of the german savings banks and cooperative banks
+package camtcsv
+
+import (
+	"bytes"
+	_ "embed"
+
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/banks/generic"
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+// profile describes the CSV-CAMT export for the generic parser
+//
+//go:embed camt-csv.yaml
+var profile []byte
+
+// header is the beginning of the header line of the CSV-CAMT export
+const header = `"Auftragskonto";"Buchungstag";"Valutadatum";"Buchungstext";"Verwendungszweck";`
+
+func init() {
+	banks.Register("camt-csv", func(opts banks.Options) (mt940.Bank, error) {
+		g, err := New(opts.StartSaldo)
+		if err != nil {
+			return nil, err
+		}
+		g.Lenient = opts.Lenient
+		g.FixOrder = opts.FixOrder
+		return g, nil
+	}, Detect)
+}
+
+// Detect returns the confidence that peek is the beginning of a CSV-CAMT export
+func Detect(peek []byte) float64 {
+	peek = bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf"))
+	if bytes.HasPrefix(peek, []byte(header)) {
+		return 1
+	}
+	return 0
+}
+
+// New returns the generic parser with the embedded CSV-CAMT profile, the iban is read from the Auftragskonto column
+// and pending transactions are skipped, the export contains no saldo so it is calculated from startSaldo in cents
+func New(startSaldo int64) (*generic.Generic, error) {
+	p, err := generic.ParseProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	return generic.New(p, "", startSaldo), nil
+}
@@ -0,0 +1,81 @@
+package camtcsv
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const testCsv = `"Auftragskonto";"Buchungstag";"Valutadatum";"Buchungstext";"Verwendungszweck";"Glaeubiger ID";"Mandatsreferenz";"Kundenreferenz (End-to-End)";"Sammlerreferenz";"Lastschrift Ursprungsbetrag";"Auslagenersatz Ruecklastschrift";"Beguenstigter/Zahlungspflichtiger";"Kontonummer/IBAN";"BIC (SWIFT-Code)";"Betrag";"Waehrung";"Info"
+"DE89370400440532013000";"10.01.20";"10.01.20";"ONLINE-UEBERWEISUNG";"Pending";"";"";"";"";"";"";"Yabox";"DE02120300000000202051";"BYLADEM1001";"-5,00";"EUR";"Umsatz vorgemerkt"
+"DE89370400440532013000";"09.01.20";"09.01.20";"FOLGELASTSCHRIFT";"Reactive full-range local area network";"DE98ZZZ09999999999";"M-123";"E-456";"";"";"";"Yabox";"DE02120300000000202051";"BYLADEM1001";"-1,62";"EUR";"Umsatz gebucht"
+"DE89370400440532013000";"06.01.20";"06.01.20";"GUTSCHR. UEBERWEISUNG";"Grass-roots systemic pricing structure";"";"";"NOTPROVIDED";"";"";"";"Jörg Müller";"DE02500105170137075030";"INGDDEFFXXX";"16,20";"EUR";"Umsatz gebucht"
+`
+
+func TestCamtCsv_Parse(t *testing.T) {
+	input, err := charmap.Windows1252.NewEncoder().String(testCsv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(117374)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	got, err := g.Parse(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got.IBAN != "DE89370400440532013000" || got.BankNumber != "37040044" || got.AccountNumber != "0532013000" {
+		t.Errorf("Parse() iban = %s, bank = %s, account = %s", got.IBAN, got.BankNumber, got.AccountNumber)
+	}
+	if len(got.Transactions) != 2 {
+		t.Fatalf("Parse() got %d transactions, want 2 without the pending one", len(got.Transactions))
+	}
+
+	tests := []struct {
+		saldo   int64
+		details string
+	}{
+		{
+			saldo: 118994,
+			details: "051?00GUTSCHR. UEBERWEISUNG?20EREF+NOTPROVIDED?21SVWZ+Grass-roots systemic p?22ricing structure" +
+				"?30INGDDEFFXXX?31DE02500105170137075030?32Joerg Mueller",
+		},
+		{
+			saldo: 118832,
+			details: "005?00FOLGELASTSCHRIFT?20EREF+E-456?21MREF+M-123?22CRED+DE98ZZZ09999999999" +
+				"?23SVWZ+Reactive full-range lo?24cal area network?30BYLADEM1001?31DE02120300000000202051?32Yabox",
+		},
+	}
+	for i, tt := range tests {
+		line := got.Transactions[i].(*mt940.StatementLine)
+		if line.Saldo().Amount() != tt.saldo {
+			t.Errorf("Parse() saldo %d = %d, want %d", i, line.Saldo().Amount(), tt.saldo)
+		}
+		if d := line.Details.String(); d != tt.details {
+			t.Errorf("Parse() details %d =\n%q\nwant\n%q", i, d, tt.details)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		peek string
+		want float64
+	}{
+		{name: "camt csv", peek: testCsv, want: 1},
+		{name: "camt csv with bom", peek: "\xef\xbb\xbf" + testCsv, want: 1},
+		{name: "other csv", peek: `"Buchungstag";"Valutadatum";"Betrag"`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.peek)); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
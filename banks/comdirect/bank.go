@@ -0,0 +1,266 @@
+package comdirect
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/iban"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// visaAccountNumber is written to :25: of the visa statement, the export contains no account number of the card
+const visaAccountNumber = "VISA"
+
+// kinds of the sections in the export
+const (
+	sectionGirokonto = "Girokonto"
+	sectionVisa      = "Visa"
+)
+
+type Comdirect struct {
+	Iban string
+	// Lenient logs a saldo that does not match the new balance as warning instead of failing the conversion
+	Lenient bool
+	logger  *log.Logger
+}
+
+func init() {
+	banks.Register("comdirect", func(opts banks.Options) (mt940.Bank, error) {
+		if opts.Iban == "" {
+			return nil, errors.New("parser for comdirect needs iban provided, use -iban")
+		}
+		c := New(opts.Iban)
+		c.Lenient = opts.Lenient
+		return c, nil
+	}, Detect)
+}
+
+// Detect returns the confidence that peek is the beginning of a comdirect csv export,
+// comdirect exports start with the title of the first section, e.g. "Umsätze Girokonto"
+func Detect(peek []byte) float64 {
+	peek = bytes.TrimLeft(bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf")), ";\r\n")
+	switch {
+	case bytes.HasPrefix(peek, []byte("\"Ums\xe4tze ")), bytes.HasPrefix(peek, []byte(`"Umsätze `)):
+		return 1
+	case bytes.Contains(peek, []byte(`"Neuer Kontostand";`)):
+		return 0.8
+	}
+	return 0
+}
+
+func New(iban string) *Comdirect {
+	logger := log.New(os.Stdout, "[COMDIRECT] ", log.Lmsgprefix)
+
+	return &Comdirect{
+		Iban:   iban,
+		logger: logger,
+	}
+}
+
+// section is a part of the export with the transactions of one account
+type section struct {
+	kind           string
+	header         map[string]int
+	openingBalance *money.Money
+	closingBalance *money.Money
+	transactions   []mt940.Transaction
+	pending        int
+}
+
+// Parse returns the Girokonto of the comdirect csv export
+func (c *Comdirect) Parse(ctx context.Context, r io.Reader) (*mt940.BankData, error) {
+	all, err := c.ParseAll(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	for _, data := range all {
+		if data.AccountNumber != visaAccountNumber {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("no transactions of Girokonto found in csv")
+}
+
+// ParseAll reads the comdirect csv export from r and returns a BankData for the Girokonto and for the Visa card,
+// the Depot and other sections are skipped
+func (c *Comdirect) ParseAll(ctx context.Context, r io.Reader) ([]*mt940.BankData, error) {
+	accountIBAN := iban.Normalize(c.Iban)
+	bankNumber, accountNumber, err := iban.Split(accountIBAN)
+	if err != nil {
+		return nil, err
+	}
+
+	// comdirect encodes in windows-1252
+	cr := csv.NewReader(charmap.Windows1252.NewDecoder().Reader(r))
+	cr.Comma = ';'
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	var sections []*section
+	var current *section
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read data from csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		switch first := strings.TrimSpace(fields[0]); {
+		case strings.HasPrefix(first, "Umsätze "):
+			current = &section{kind: sectionKind(first)}
+			if current.kind != "" {
+				sections = append(sections, current)
+			}
+		case current == nil || current.kind == "" || first == "":
+			// lines outside of a supported section
+		case first == "Neuer Kontostand" || first == "Alter Kontostand":
+			balance, err := parseBalance(fields)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse %s in line %d: %w", first, line, err)
+			}
+			if first == "Neuer Kontostand" {
+				current.closingBalance = balance
+			} else {
+				current.openingBalance = balance
+			}
+		case first == "Buchungstag":
+			current.header = make(map[string]int, len(fields))
+			for i, name := range fields {
+				current.header[strings.TrimSpace(name)] = i
+			}
+		case current.header == nil:
+		case first == "offen":
+			current.pending++
+		default:
+			t, err := newTransaction(fields, current.header, current.kind)
+			if err != nil {
+				var pErr *mt940.ParseError
+				if errors.As(err, &pErr) {
+					pErr.Line = line
+					return nil, pErr
+				}
+				return nil, fmt.Errorf("could not convert entry to struct in line %d: %w", line, err)
+			}
+			current.transactions = append(current.transactions, t)
+		}
+	}
+
+	var result []*mt940.BankData
+	for _, s := range sections {
+		if s.pending > 0 {
+			c.logger.Printf("skipped %d pending transactions of %s", s.pending, s.kind)
+		}
+		if len(s.transactions) == 0 {
+			continue
+		}
+		err := c.calculateSaldo(s)
+		if err != nil {
+			return nil, fmt.Errorf("could not calculate saldo of %s: %w", s.kind, err)
+		}
+
+		data := &mt940.BankData{
+			BankNumber:   bankNumber,
+			Transactions: s.transactions,
+		}
+		if s.kind == sectionGirokonto {
+			data.IBAN = accountIBAN
+			data.AccountNumber = accountNumber
+		} else {
+			data.AccountNumber = visaAccountNumber
+		}
+		result = append(result, data)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no transactions of Girokonto or Visa found in csv")
+	}
+	return result, nil
+}
+
+// sectionKind returns the kind of the section for its title, or an empty string for unsupported sections like the Depot
+func sectionKind(title string) string {
+	switch {
+	case strings.Contains(title, "Girokonto"):
+		return sectionGirokonto
+	case strings.Contains(title, "Visa"):
+		return sectionVisa
+	}
+	return ""
+}
+
+// parseBalance parses the balance of a "Neuer Kontostand" or "Alter Kontostand" line, e.g. "1.188,32 EUR"
+func parseBalance(fields []string) (*money.Money, error) {
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("balance is missing")
+	}
+	value := strings.TrimSpace(fields[1])
+	currency := "EUR"
+	if i := strings.LastIndex(value, " "); i >= 0 {
+		value, currency = value[:i], value[i+1:]
+	}
+	return converter.ParseAmount(value, ",", ".", currency)
+}
+
+// calculateSaldo reverses the transactions of the section into ascending order and sets their saldo,
+// it starts with the old balance if it is known and calculates backwards from the new balance otherwise
+func (c *Comdirect) calculateSaldo(s *section) error {
+	ta := s.transactions
+	for i, j := 0, len(ta)-1; i < j; i, j = i+1, j-1 {
+		ta[i], ta[j] = ta[j], ta[i]
+	}
+
+	if s.openingBalance == nil {
+		if s.closingBalance == nil {
+			return fmt.Errorf("export contains neither the old nor the new balance")
+		}
+		saldo := s.closingBalance
+		for i := len(ta) - 1; i >= 0; i-- {
+			line := ta[i].(*mt940.StatementLine)
+			line.Balance = saldo
+			var err error
+			saldo, err = saldo.Subtract(line.Amount())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	saldo := s.openingBalance
+	for _, t := range ta {
+		line := t.(*mt940.StatementLine)
+		var err error
+		saldo, err = saldo.Add(line.Amount())
+		if err != nil {
+			return err
+		}
+		line.Balance = saldo
+	}
+	if s.closingBalance != nil {
+		if ok, _ := saldo.Equals(s.closingBalance); !ok {
+			msg := fmt.Sprintf("saldo %s of %s does not match the new balance %s, pending transactions may be missing",
+				saldo.Display(), s.kind, s.closingBalance.Display())
+			if !c.Lenient {
+				return errors.New(msg)
+			}
+			c.logger.Printf("WARNING: %s", msg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package comdirect
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const testCsv = `;
+"Umsätze Girokonto";"Zeitraum: 30 Tage";
+"Neuer Kontostand";"1.188,32 EUR";
+
+"Buchungstag";"Wertstellung (Valuta)";"Vorgang";"Buchungstext";"Umsatz in EUR";
+"offen";"--";"Lastschrift / Belastung";"Auftraggeber: Pending Buchungstext: Pending";"-5,00";
+"09.01.2020";"09.01.2020";"Lastschrift / Belastung";"Auftraggeber: Yabox Buchungstext: Reactive full-range local area network Ref. 3H2C21S2A1B2C3D4/1";"-1,62";
+"06.01.2020";"06.01.2020";"Übertrag / Überweisung";"Auftraggeber: Yabox Kto/IBAN: DE02500105170137075030 BLZ/BIC: INGDDEFFXXX Buchungstext: Grass-roots systemic pricing structure Ref. 1A2B3C";"16,20";
+
+"Alter Kontostand";"1.173,74 EUR";
+;
+"Umsätze Visa-Karte (Kreditkarte)";"Zeitraum: 30 Tage";
+"Neuer Kontostand";"-12,34 EUR";
+
+"Buchungstag";"Umsatztag";"Vorgang";"Referenz";"Buchungstext";"Umsatz in EUR";
+"09.01.2020";"08.01.2020";"Visa-Umsatz";"12345678901234567";"AMAZON.DE";"-12,34";
+
+"Alter Kontostand";"0,00 EUR";
+;
+"Umsätze Depot";"Zeitraum: 30 Tage";
+
+"Buchungstag";"Geschäftstag";"Stück / Nom.";"Bezeichnung";"WKN";"Währung";"Ausführungskurs";"Umsatz in EUR";
+"08.01.2020";"06.01.2020";"10";"ETF";"A0RPWH";"EUR";"50,00";"-500,00";
+`
+
+// windows1252 encodes the test csv like the comdirect export
+func windows1252(t *testing.T, s string) string {
+	t.Helper()
+	encoded, err := charmap.Windows1252.NewEncoder().String(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestComdirect_ParseAll(t *testing.T) {
+	got, err := mt940.ParseAll(context.Background(), New("DE89 3704 0044 0532 0130 00"), strings.NewReader(windows1252(t, testCsv)))
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ParseAll() got %d accounts, want 2", len(got))
+	}
+
+	giro, visa := got[0], got[1]
+	if giro.IBAN != "DE89370400440532013000" || giro.BankNumber != "37040044" || giro.AccountNumber != "0532013000" {
+		t.Errorf("ParseAll() giro iban = %s, bank = %s, account = %s", giro.IBAN, giro.BankNumber, giro.AccountNumber)
+	}
+	if len(giro.Transactions) != 2 {
+		t.Fatalf("ParseAll() got %d giro transactions, want 2", len(giro.Transactions))
+	}
+	credit := giro.Transactions[0].(*mt940.StatementLine)
+	wantCredit := mt940.Details{
+		GVC:           "051",
+		BookingText:   "UEbertrag / UEberweisung",
+		Purpose:       []string{"SVWZ+Grass-roots systemic p", "ricing structure"},
+		BankCode:      "INGDDEFFXXX",
+		AccountNumber: "DE02500105170137075030",
+		Name:          []string{"Yabox"},
+	}
+	if !reflect.DeepEqual(credit.Details, wantCredit) {
+		t.Errorf("ParseAll() credit details = %+v, want %+v", credit.Details, wantCredit)
+	}
+	if credit.Saldo().Amount() != 118994 || credit.Sales.BankReference != "1A2B3C" {
+		t.Errorf("ParseAll() credit saldo = %d, reference = %s", credit.Saldo().Amount(), credit.Sales.BankReference)
+	}
+	debit := giro.Transactions[1].(*mt940.StatementLine)
+	if debit.Saldo().Amount() != 118832 || debit.Details.GVC != "005" || debit.Sales.BankReference != "3H2C21S2A1B2C3D4" {
+		t.Errorf("ParseAll() debit saldo = %d, gvc = %s, reference = %s", debit.Saldo().Amount(), debit.Details.GVC, debit.Sales.BankReference)
+	}
+
+	if visa.IBAN != "" || visa.BankNumber != "37040044" || visa.AccountNumber != visaAccountNumber {
+		t.Errorf("ParseAll() visa iban = %s, bank = %s, account = %s", visa.IBAN, visa.BankNumber, visa.AccountNumber)
+	}
+	if len(visa.Transactions) != 1 {
+		t.Fatalf("ParseAll() got %d visa transactions, want 1", len(visa.Transactions))
+	}
+	card := visa.Transactions[0].(*mt940.StatementLine)
+	if card.Saldo().Amount() != -1234 || card.Sales.ValueDate.Day() != 8 || card.Details.GVC != "004" ||
+		!reflect.DeepEqual(card.Details.Name, []string{"AMAZON.DE"}) || card.Sales.BankReference != "1234567890123456" {
+		t.Errorf("ParseAll() visa transaction = %+v", card)
+	}
+}
+
+func TestComdirect_Parse(t *testing.T) {
+	got, err := New("DE89370400440532013000").Parse(context.Background(), strings.NewReader(windows1252(t, testCsv)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got.IBAN != "DE89370400440532013000" || len(got.Transactions) != 2 {
+		t.Errorf("Parse() = %s with %d transactions, want the girokonto", got.IBAN, len(got.Transactions))
+	}
+
+	// the visa section comes first and the girokonto has no transactions
+	girokonto, visa := testCsv[:strings.Index(testCsv, `"Umsätze Visa`)], testCsv[strings.Index(testCsv, `"Umsätze Visa`):]
+	girokonto = girokonto[:strings.Index(girokonto, `"Buchungstag"`)]
+	_, err = New("DE89370400440532013000").Parse(context.Background(), strings.NewReader(windows1252(t, visa+girokonto)))
+	if err == nil || err.Error() != "no transactions of Girokonto found in csv" {
+		t.Errorf("Parse() error = %v, want missing girokonto", err)
+	}
+}
+
+func TestComdirect_ParseLenient(t *testing.T) {
+	c := New("DE89370400440532013000")
+	c.Lenient = true
+	input := strings.Replace(testCsv, `"1.188,32 EUR"`, `"1.183,32 EUR"`, 1)
+	if _, err := c.ParseAll(context.Background(), strings.NewReader(windows1252(t, input))); err != nil {
+		t.Errorf("ParseAll() error = %v, want a warning only", err)
+	}
+}
+
+func TestComdirect_ParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "invalid amount",
+			input:   strings.Replace(testCsv, `"16,20"`, `"16x20"`, 1),
+			wantErr: `line 8: could not parse amount from "16x20": invalid number`,
+		},
+		{
+			name:    "invalid date",
+			input:   strings.Replace(testCsv, `"09.01.2020";"08.01.2020"`, `"2020-01-09";"08.01.2020"`, 1),
+			wantErr: `line 16: could not parse date from "2020-01-09"`,
+		},
+		{
+			name:    "saldo does not match new balance",
+			input:   strings.Replace(testCsv, `"1.188,32 EUR"`, `"1.183,32 EUR"`, 1),
+			wantErr: "could not calculate saldo of Girokonto: saldo €1,188.32 of Girokonto does not match the new balance €1,183.32",
+		},
+		{
+			name:    "no supported section",
+			input:   testCsv[strings.Index(testCsv, `"Umsätze Depot"`):],
+			wantErr: "no transactions of Girokonto or Visa found in csv",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New("DE89370400440532013000").ParseAll(context.Background(), strings.NewReader(windows1252(t, tt.input)))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseAll() error = %v, want %s", err, tt.wantErr)
+			}
+			var pErr *mt940.ParseError
+			if strings.HasPrefix(tt.wantErr, "line") && !errors.As(err, &pErr) {
+				t.Errorf("ParseAll() error = %v, want *mt940.ParseError", err)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		peek string
+		want float64
+	}{
+		{name: "windows-1252 export", peek: ";\r\n\"Ums\xe4tze Girokonto\";\"Zeitraum: 30 Tage\";", want: 1},
+		{name: "utf-8 export", peek: testCsv, want: 1},
+		{name: "balance line", peek: "\"Neuer Kontostand\";\"1.188,32 EUR\";", want: 0.8},
+		{name: "other csv", peek: `"Datum","Empfänger","Kontonummer"`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.peek)); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package comdirect
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+// column names of the comdirect csv export, the Girokonto has a value date and the Visa card a transaction date
+const (
+	columnDate            = "Buchungstag"
+	columnValueDate       = "Wertstellung (Valuta)"
+	columnTransactionDate = "Umsatztag"
+	columnType            = "Vorgang"
+	columnReference       = "Referenz"
+	columnText            = "Buchungstext"
+	columnAmount          = "Umsatz in EUR"
+)
+
+// maxPurposeParts limits the subfields ?20 to ?29 so that the :86: line does not exceed 390 characters
+const maxPurposeParts = 8
+
+// gvcCodes returns the GVC Code for the given Vorgang, note this list is not complete,
+// unknown values and transfers get the code of a transfer or a credit depending on the sign of the amount
+var gvcCodes = map[string]string{
+	"Lastschrift / Belastung": "005",
+	"Lastschrift":             "005",
+	"Dauerauftrag":            "008",
+	"Kartenverfügung":         "083",
+	"Bar":                     "083",
+	"Auszahlung GAA":          "083",
+	"Visa-Umsatz":             "004",
+	"Visa-Kartenabrechnung":   "004",
+	"Kontoabschluss":          "805",
+	"Abschluss":               "805",
+	"Zinsen / Dividende":      "805",
+	"Entgelte":                "808",
+	"Gutschrift":              "051",
+}
+
+// markers of the combined Buchungstext of the Girokonto,
+// e.g. "Auftraggeber: Yabox Buchungstext: Reactive full-range local area network Ref. 3H2C21S2A1B2C3D4/1"
+var textMarkers = []string{"Auftraggeber:", "Empfänger:", "Kto/IBAN:", "BLZ/BIC:", "Buchungstext:", "Ref."}
+
+// bookingText is the content of the combined Buchungstext
+type bookingText struct {
+	payee     string
+	iban      string
+	bic       string
+	purpose   string
+	reference string
+}
+
+// parseBookingText splits the combined Buchungstext at its markers, a text without markers is the purpose
+func parseBookingText(s string) bookingText {
+	type position struct {
+		marker string
+		index  int
+	}
+	var positions []position
+	for _, m := range textMarkers {
+		if i := strings.Index(s, m); i >= 0 {
+			positions = append(positions, position{marker: m, index: i})
+		}
+	}
+	if len(positions) == 0 {
+		return bookingText{purpose: strings.TrimSpace(s)}
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].index < positions[j].index
+	})
+
+	var b bookingText
+	for i, p := range positions {
+		end := len(s)
+		if i+1 < len(positions) {
+			end = positions[i+1].index
+		}
+		value := strings.TrimSpace(s[p.index+len(p.marker) : end])
+		switch p.marker {
+		case "Auftraggeber:", "Empfänger:":
+			b.payee = value
+		case "Kto/IBAN:":
+			b.iban = strings.ReplaceAll(value, " ", "")
+		case "BLZ/BIC:":
+			b.bic = strings.ReplaceAll(value, " ", "")
+		case "Buchungstext:":
+			b.purpose = value
+		case "Ref.":
+			b.reference = value
+		}
+	}
+	// text before the first marker belongs to the purpose
+	if prefix := strings.TrimSpace(s[:positions[0].index]); prefix != "" {
+		b.purpose = strings.TrimSpace(prefix + " " + b.purpose)
+	}
+	return b
+}
+
+// newTransaction converts a row of a Girokonto or Visa section into a StatementLine without saldo
+func newTransaction(fields []string, header map[string]int, kind string) (*mt940.StatementLine, error) {
+	value := func(column string) string {
+		i, ok := header[column]
+		if !ok || i >= len(fields) {
+			return ""
+		}
+		return strings.TrimSpace(fields[i])
+	}
+	if _, ok := header[columnAmount]; !ok || len(fields) <= header[columnAmount] {
+		return nil, &mt940.ParseError{
+			Column: "entry",
+			Value:  strings.Join(fields, ";"),
+			Err:    fmt.Errorf("expected %d columns, got %d", len(header), len(fields)),
+		}
+	}
+
+	date, err := time.Parse("02.01.2006", value(columnDate))
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "date", Value: value(columnDate), Err: err}
+	}
+	valueDate := date
+	valueColumn := columnValueDate
+	if kind == sectionVisa {
+		valueColumn = columnTransactionDate
+	}
+	if v := value(valueColumn); v != "" && v != "--" {
+		valueDate, err = time.Parse("02.01.2006", v)
+		if err != nil {
+			return nil, &mt940.ParseError{Column: "valueDate", Value: v, Err: err}
+		}
+	}
+	amount, err := converter.ParseAmount(value(columnAmount), ",", ".", "EUR")
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "amount", Value: value(columnAmount), Err: err}
+	}
+
+	var text bookingText
+	if kind == sectionVisa {
+		// the Buchungstext of the card is the merchant
+		text = bookingText{payee: value(columnText), reference: value(columnReference)}
+	} else {
+		text = parseBookingText(value(columnText))
+	}
+
+	transactionType := value(columnType)
+	gvc, ok := gvcCodes[transactionType]
+	if !ok {
+		gvc = "051"
+		if amount.IsNegative() {
+			gvc = "020"
+		}
+	}
+
+	references := converter.ParseSEPA(text.purpose)
+	line := &mt940.StatementLine{
+		Sales: mt940.NewSalesLine(valueDate, date, amount, gvc, references.EndToEnd),
+		Details: mt940.Details{
+			GVC:         gvc,
+			BookingText: converter.ConvertUmlauts(transactionType),
+		},
+	}
+	line.Sales.BankReference = mt940.SanitizeReference(text.reference)
+	line.SetReferences(references, maxPurposeParts)
+	line.SetCounterparty(mt940.Counterparty{Name: text.payee, Account: mt940.NewCounterpartyAccount(text.iban, text.bic)})
+	return line, nil
+}
@@ -0,0 +1,48 @@
+package comdirect
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseBookingText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bookingText
+	}{
+		{
+			name:  "debit with reference",
+			input: "Auftraggeber: Yabox Buchungstext: Reactive full-range local area network Ref. 3H2C21S2A1B2C3D4/1",
+			want:  bookingText{payee: "Yabox", purpose: "Reactive full-range local area network", reference: "3H2C21S2A1B2C3D4/1"},
+		},
+		{
+			name:  "transfer with account",
+			input: "Empfänger: Jörg Müller Kto/IBAN: DE02 1203 0000 0000 2020 51 BLZ/BIC: BYLADEM1001 Buchungstext: Miete Januar Ref. ABC123",
+			want: bookingText{
+				payee:     "Jörg Müller",
+				iban:      "DE02120300000000202051",
+				bic:       "BYLADEM1001",
+				purpose:   "Miete Januar",
+				reference: "ABC123",
+			},
+		},
+		{
+			name:  "text before the first marker",
+			input: "Kontoabschluss Buchungstext: Entgelt",
+			want:  bookingText{purpose: "Kontoabschluss Entgelt"},
+		},
+		{
+			name:  "without markers",
+			input: " Bargeldauszahlung Berlin ",
+			want:  bookingText{purpose: "Bargeldauszahlung Berlin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseBookingText(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBookingText() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package banks
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// CurrencyCSV reads the csv exports with named columns and a balance per currency, e.g. of revolut, wise and paypal
+type CurrencyCSV struct {
+	// Required are the columns that have to be in the header
+	Required []string
+	Logger   *log.Logger
+}
+
+// Row is a row of the csv, its values are accessed by the column names of the header
+type Row struct {
+	fields  []string
+	columns map[string]int
+}
+
+// Value returns the value of the column without surrounding whitespace, it is empty if the header has no such column
+func (r Row) Value(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[i])
+}
+
+// ReadRows reads the header and passes every row to parse, parse returns the reason why a row is skipped, e.g. its status,
+// the number of skipped rows is logged per reason
+func (c *CurrencyCSV) ReadRows(ctx context.Context, r io.Reader, parse func(Row) (string, error)) error {
+	// the export may start with a byte order mark
+	cr := csv.NewReader(unicode.UTF8BOM.NewDecoder().Reader(r))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	fields, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("could not read header from csv: %w", err)
+	}
+	columns := make(map[string]int, len(fields))
+	for i, name := range fields {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range c.Required {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("column %q not found in header", name)
+		}
+	}
+
+	skipped := map[string]int{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("could not read data from csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		if len(fields) < len(columns) {
+			return &mt940.ParseError{
+				Line:   line,
+				Column: "entry",
+				Value:  strings.Join(fields, ","),
+				Err:    fmt.Errorf("expected %d columns, got %d", len(columns), len(fields)),
+			}
+		}
+		skip, err := parse(Row{fields: fields, columns: columns})
+		if err != nil {
+			var pErr *mt940.ParseError
+			if errors.As(err, &pErr) {
+				pErr.Line = line
+				return pErr
+			}
+			return fmt.Errorf("could not convert entry to struct in line %d: %w", line, err)
+		}
+		if skip != "" {
+			skipped[skip]++
+		}
+	}
+
+	reasons := make([]string, 0, len(skipped))
+	for reason := range skipped {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		c.Logger.Printf("skipped %d %s transactions", skipped[reason], reason)
+	}
+	return nil
+}
+
+// Statements returns a BankData with the transactions of every currency in the order of their first transaction,
+// the account is copied for every currency and its account number gets the currency as suffix
+func (c *CurrencyCSV) Statements(account mt940.BankData, transactions []mt940.Transaction) ([]*mt940.BankData, error) {
+	var result []*mt940.BankData
+	byCurrency := map[string]*mt940.BankData{}
+	for _, t := range transactions {
+		currency := t.Amount().Currency().Code
+		data, ok := byCurrency[currency]
+		if !ok {
+			d := account
+			d.Currency = currency
+			d.AccountNumber += currency
+			data = &d
+			byCurrency[currency] = data
+			result = append(result, data)
+		}
+		data.Transactions = append(data.Transactions, t)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no completed transactions found in csv")
+	}
+
+	for _, data := range result {
+		for _, b := range data.VerifyBalances() {
+			c.Logger.Printf("WARNING: %s: %s", data.Currency, b)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,107 @@
+package banks
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+func TestCurrencyCSV_ReadRows(t *testing.T) {
+	input := "\xef\xbb\xbf" + `"Currency","Amount","State"
+"EUR","1.00","COMPLETED"
+"USD","2.00","PENDING"
+"EUR","3.00","PENDING"
+`
+	var logs strings.Builder
+	c := &CurrencyCSV{Required: []string{"Currency", "Amount"}, Logger: log.New(&logs, "", 0)}
+	var amounts []string
+	err := c.ReadRows(context.Background(), strings.NewReader(input), func(fields Row) (string, error) {
+		if state := fields.Value("State"); state != "COMPLETED" {
+			return strings.ToLower(state), nil
+		}
+		amounts = append(amounts, fields.Value("Amount")+fields.Value("Missing"))
+		return "", nil
+	})
+	if err != nil {
+		t.Fatalf("ReadRows() error = %v", err)
+	}
+	if len(amounts) != 1 || amounts[0] != "1.00" {
+		t.Errorf("ReadRows() rows = %v, want [1.00]", amounts)
+	}
+	if want := "skipped 2 pending transactions\n"; logs.String() != want {
+		t.Errorf("ReadRows() logs = %q, want %q", logs.String(), want)
+	}
+}
+
+func TestCurrencyCSV_ReadRowsErrors(t *testing.T) {
+	parseErr := errors.New("invalid amount")
+	tests := []struct {
+		name     string
+		input    string
+		wantLine int
+	}{
+		{name: "missing column", input: `"Currency"` + "\n"},
+		{name: "too few columns", input: `"Currency","Amount"` + "\n" + `"EUR"` + "\n", wantLine: 2},
+		{name: "parse error", input: `"Currency","Amount"` + "\n" + `"EUR","1.00"` + "\n" + `"EUR","x"` + "\n", wantLine: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &CurrencyCSV{Required: []string{"Currency", "Amount"}, Logger: log.New(&strings.Builder{}, "", 0)}
+			err := c.ReadRows(context.Background(), strings.NewReader(tt.input), func(fields Row) (string, error) {
+				if fields.Value("Amount") == "x" {
+					return "", &mt940.ParseError{Column: "amount", Err: parseErr}
+				}
+				return "", nil
+			})
+			if err == nil {
+				t.Fatalf("ReadRows() error = nil")
+			}
+			var pErr *mt940.ParseError
+			if tt.wantLine > 0 && (!errors.As(err, &pErr) || pErr.Line != tt.wantLine) {
+				t.Errorf("ReadRows() error = %v, want a parse error in line %d", err, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestCurrencyCSV_Statements(t *testing.T) {
+	date := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	line := func(amount, saldo int64, currency string) mt940.Transaction {
+		return &mt940.StatementLine{
+			Sales:   mt940.SalesLine{ValueDate: date, Amount: money.New(amount, currency)},
+			Balance: money.New(saldo, currency),
+		}
+	}
+	var logs strings.Builder
+	c := &CurrencyCSV{Logger: log.New(&logs, "", 0)}
+
+	got, err := c.Statements(mt940.BankData{IBAN: "LT353250012345678901", BankNumber: "32500", AccountNumber: "12345678901"}, []mt940.Transaction{
+		line(100, 100, "USD"), line(200, 200, "EUR"), line(50, 150, "USD"),
+	})
+	if err != nil {
+		t.Fatalf("Statements() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Statements() got %d statements, want 2", len(got))
+	}
+	usd, eur := got[0], got[1]
+	if usd.Currency != "USD" || usd.AccountNumber != "12345678901USD" || len(usd.Transactions) != 2 || usd.IBAN != "LT353250012345678901" {
+		t.Errorf("Statements() usd = %s/%s with %d transactions", usd.Currency, usd.AccountNumber, len(usd.Transactions))
+	}
+	if eur.Currency != "EUR" || eur.AccountNumber != "12345678901EUR" || len(eur.Transactions) != 1 {
+		t.Errorf("Statements() eur = %s/%s with %d transactions", eur.Currency, eur.AccountNumber, len(eur.Transactions))
+	}
+	if logs.Len() > 0 {
+		t.Errorf("Statements() logs = %q, want no balance warnings", logs.String())
+	}
+
+	if _, err := c.Statements(mt940.BankData{}, nil); err == nil {
+		t.Errorf("Statements() without transactions returned no error")
+	}
+}
@@ -0,0 +1,251 @@
+package dkb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/iban"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// peekSize is the number of bytes that are used to detect the encoding of the csv
+const peekSize = 4096
+
+// ibanPattern finds the iban in the account line of the preamble, e.g. "DE12 1203 0000 1234 5678 90 / Girokonto"
+var ibanPattern = regexp.MustCompile(`[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}`)
+
+type Dkb struct {
+	logger *log.Logger
+}
+
+func init() {
+	banks.Register("dkb", func(opts banks.Options) (mt940.Bank, error) {
+		return New(), nil
+	}, Detect)
+}
+
+// Detect returns the confidence that peek is the beginning of a dkb csv export,
+// the old export starts with "Kontonummer:", the export since 2023 with "Konto"
+func Detect(peek []byte) float64 {
+	peek = bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf"))
+	switch {
+	case bytes.HasPrefix(peek, []byte(`"Kontonummer:";"`)):
+		return 1
+	case bytes.HasPrefix(peek, []byte(`"Konto";"`)) && bytes.Contains(peek, []byte(`"Buchungsdatum";"Wertstellung";"Status";`)):
+		return 1
+	case bytes.Contains(peek, []byte(`"Buchungstag";"Wertstellung";"Buchungstext";"Auftraggeber / Beg`)):
+		return 0.8
+	case bytes.HasPrefix(peek, []byte(`"Konto";"`)):
+		return 0.6
+	}
+	return 0
+}
+
+func New() *Dkb {
+	logger := log.New(os.Stdout, "[DKB] ", log.Lmsgprefix)
+
+	return &Dkb{
+		logger: logger,
+	}
+}
+
+// Parse reads the dkb csv export from r and converts it into BankData,
+// the saldo of every transaction is calculated backwards from the "Kontostand" of the preamble
+func (d *Dkb) Parse(ctx context.Context, r io.Reader) (*mt940.BankData, error) {
+	cr, err := newCsvReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// everything before the header line is the preamble with the account infos
+	meta := map[string]string{}
+	var header []string
+	for header == nil {
+		entry, err := cr.Read()
+		if err == io.EOF {
+			return nil, fmt.Errorf("could not find header line in csv")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read preamble from csv: %w", err)
+		}
+		switch entry[0] {
+		case "Buchungstag", "Buchungsdatum":
+			header = entry
+		default:
+			if len(entry) > 1 {
+				meta[strings.TrimSuffix(entry[0], ":")] = entry[1]
+			}
+		}
+	}
+
+	accountIBAN, err := getIban(meta)
+	if err != nil {
+		return nil, err
+	}
+	bankNumber, accountNo, err := iban.Split(accountIBAN)
+	if err != nil {
+		return nil, err
+	}
+	closingBalance, err := getClosingBalance(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	parse := parseEntry
+	if header[0] == "Buchungsdatum" {
+		parse = parseEntry2023
+	}
+
+	// the export is sorted descending, the newest transaction is the first one
+	var ta []mt940.Transaction
+	pending := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read data from csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		e, err := parse(fields)
+		if err != nil {
+			var pErr *mt940.ParseError
+			if errors.As(err, &pErr) {
+				pErr.Line = line
+				return nil, pErr
+			}
+			return nil, fmt.Errorf("could not convert entry to struct in line %d: %w", line, err)
+		}
+		if e.pending {
+			pending++
+			continue
+		}
+		ta = append(ta, e.statementLine())
+	}
+	if len(ta) == 0 {
+		return nil, fmt.Errorf("no transactions found in csv")
+	}
+	if pending > 0 {
+		d.logger.Printf("skipped %d pending transactions", pending)
+	}
+	for i, j := 0, len(ta)-1; i < j; i, j = i+1, j-1 {
+		ta[i], ta[j] = ta[j], ta[i]
+	}
+
+	err = calculateSaldo(ta, closingBalance)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mt940.BankData{
+		IBAN:          accountIBAN,
+		BankNumber:    bankNumber,
+		AccountNumber: accountNo,
+		Transactions:  ta,
+	}, nil
+}
+
+// newCsvReader returns a csv reader for the dkb export, the old export is ISO-8859-1 encoded, the export since 2023 is UTF-8
+func newCsvReader(r io.Reader) (*csv.Reader, error) {
+	b := bufio.NewReaderSize(r, peekSize)
+	peek, err := b.Peek(peekSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("could not read csv: %w", err)
+	}
+
+	var decoded io.Reader = charmap.ISO8859_1.NewDecoder().Reader(b)
+	if isUTF8(peek) {
+		if bytes.HasPrefix(peek, []byte("\xef\xbb\xbf")) {
+			_, _ = b.Discard(3)
+		}
+		decoded = b
+	}
+
+	cr := csv.NewReader(decoded)
+	cr.Comma = ';'
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	return cr, nil
+}
+
+// isUTF8 returns true if peek starts with a byte order mark or contains valid UTF-8 multibyte characters
+func isUTF8(peek []byte) bool {
+	if bytes.HasPrefix(peek, []byte("\xef\xbb\xbf")) {
+		return true
+	}
+	// ignore a multibyte character that is cut at the end of peek
+	for i := 1; i < utf8.UTFMax && i <= len(peek); i++ {
+		if utf8.RuneStart(peek[len(peek)-i]) {
+			if !utf8.FullRune(peek[len(peek)-i:]) {
+				peek = peek[:len(peek)-i]
+			}
+			break
+		}
+	}
+	return utf8.Valid(peek) && utf8.RuneCount(peek) != len(peek)
+}
+
+// getIban returns the iban from the "Kontonummer" (old export) or "Konto" (export since 2023) line of the preamble
+func getIban(meta map[string]string) (string, error) {
+	account, ok := meta["Kontonummer"]
+	if !ok {
+		account = meta["Konto"]
+	}
+	iban := strings.ReplaceAll(ibanPattern.FindString(account), " ", "")
+	if len(iban) < 12 {
+		return "", fmt.Errorf("could not find iban in account line %q", account)
+	}
+	return iban, nil
+}
+
+// getClosingBalance returns the balance of the "Kontostand vom <date>" line of the preamble
+func getClosingBalance(meta map[string]string) (*money.Money, error) {
+	for key, value := range meta {
+		if !strings.HasPrefix(key, "Kontostand") {
+			continue
+		}
+		value = strings.TrimSpace(strings.NewReplacer("EUR", "", "€", "").Replace(value))
+		balance, err := converter.ParseAmount(value, ",", ".", "EUR")
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s %q: %w", key, value, err)
+		}
+		return balance, nil
+	}
+	return nil, fmt.Errorf("could not find Kontostand in preamble")
+}
+
+// calculateSaldo sets the saldo of every transaction, the last transaction gets the closing balance
+// and every other saldo is the following saldo minus the following amount
+func calculateSaldo(ta []mt940.Transaction, closingBalance *money.Money) error {
+	saldo := closingBalance
+	for i := len(ta) - 1; i >= 0; i-- {
+		line := ta[i].(*mt940.StatementLine)
+		line.Balance = saldo
+
+		var err error
+		saldo, err = saldo.Subtract(line.Amount())
+		if err != nil {
+			return fmt.Errorf("could not subtract amount from saldo: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package dkb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/blz"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// TestMain uses the excerpt of the bank code file because the embedded file is only complete after go generate
+func TestMain(m *testing.M) {
+	d, err := blz.Load("../../blz/testdata/blz.txt")
+	if err != nil {
+		panic(err)
+	}
+	blz.SetDefault(d)
+	os.Exit(m.Run())
+}
+
+const testCsv = `"Kontonummer:";"DE89 3704 0044 0532 0130 00 / Girokonto";
+
+"Von:";"06.01.2020";
+"Bis:";"09.01.2020";
+"Kontostand vom 09.01.2020:";"1.188,32 EUR";
+
+"Buchungstag";"Wertstellung";"Buchungstext";"Auftraggeber / Begünstigter";"Verwendungszweck";"Kontonummer";"BLZ";"Betrag (EUR)";"Gläubiger-ID";"Mandatsreferenz";"Kundenreferenz";
+"09.01.2020";"09.01.2020";"FOLGELASTSCHRIFT";"Yabox";"Reactive full-range local area network";"DE02120300000000202051";"BYLADEM1001";"-1,62";"DE98ZZZ09999999999";"M-123";"E-456";
+"06.01.2020";"06.01.2020";"Gutschrift";"Jörg Müller";"Grass-roots systemic pricing structure";"DE02500105170137075030";"INGDDEFFXXX";"16,20";"";"";"";
+`
+
+const testCsv2023 = "\xef\xbb\xbf" + `"Konto";"Girokonto DE89370400440532013000"
+""
+"Kontostand vom 09.01.2023:";"1.188,32 €"
+""
+"Buchungsdatum";"Wertstellung";"Status";"Zahlungspflichtige*r";"Zahlungsempfänger*in";"Verwendungszweck";"Umsatztyp";"IBAN";"Betrag (€)";"Gläubiger-ID";"Mandatsreferenz";"Kundenreferenz"
+"10.01.23";"10.01.23";"Vorgemerkt";"Test Tester";"Yabox";"Pending";"Ausgang";"DE02120300000000202051";"-5,00 €";"";"";""
+"09.01.23";"09.01.23";"Gebucht";"Test Tester";"Yabox";"Reactive full-range local area network";"Ausgang";"DE02120300000000202051";"-1,62 €";"DE98ZZZ09999999999";"M-123";"E-456"
+"06.01.23";"06.01.23";"Gebucht";"Jörg Müller";"Test Tester";"Grass-roots systemic pricing structure";"Eingang";"DE02500105170137075030";"16,20 €";"";"";""
+`
+
+// latin1 encodes the test csv like the old dkb export
+func latin1(t *testing.T, s string) string {
+	t.Helper()
+	encoded, err := charmap.ISO8859_1.NewEncoder().String(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestDkb_Parse(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantDate    string
+		bookingText string
+	}{
+		{name: "export until 2023", input: testCsv, wantDate: "2020-01-09", bookingText: "FOLGELASTSCHRIFT"},
+		{name: "export since 2023", input: testCsv2023, wantDate: "2023-01-09", bookingText: "Lastschrift"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.input
+			if !strings.HasPrefix(input, "\xef\xbb\xbf") {
+				input = latin1(t, input)
+			}
+			got, err := New().Parse(context.Background(), strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got.IBAN != "DE89370400440532013000" || got.BankNumber != "37040044" || got.AccountNumber != "0532013000" {
+				t.Errorf("Parse() iban = %s, bank = %s, account = %s", got.IBAN, got.BankNumber, got.AccountNumber)
+			}
+			if len(got.Transactions) != 2 {
+				t.Fatalf("Parse() got %d transactions, want 2", len(got.Transactions))
+			}
+
+			credit := got.Transactions[0].(*mt940.StatementLine)
+			if credit.Saldo().Amount() != 118994 || credit.Details.GVC != "051" {
+				t.Errorf("Parse() credit saldo = %d, gvc = %s", credit.Saldo().Amount(), credit.Details.GVC)
+			}
+			if !reflect.DeepEqual(credit.Details.Name, []string{"Joerg Mueller"}) {
+				t.Errorf("Parse() credit name = %v", credit.Details.Name)
+			}
+
+			debit := got.Transactions[1].(*mt940.StatementLine)
+			if d := debit.Date().Format("2006-01-02"); d != tt.wantDate {
+				t.Errorf("Parse() debit date = %s, want %s", d, tt.wantDate)
+			}
+			if debit.Saldo().Amount() != 118832 || debit.Amount().Amount() != -162 {
+				t.Errorf("Parse() debit saldo = %d, amount = %d", debit.Saldo().Amount(), debit.Amount().Amount())
+			}
+			wantDetails := mt940.Details{
+				GVC:         "005",
+				BookingText: tt.bookingText,
+				Purpose: []string{
+					"EREF+E-456", "MREF+M-123", "CRED+DE98ZZZ09999999999",
+					"SVWZ+Reactive full-range lo", "cal area network",
+				},
+				BankCode:      "BYLADEM1001",
+				AccountNumber: "DE02120300000000202051",
+				Name:          []string{"Yabox"},
+			}
+			if !reflect.DeepEqual(debit.Details, wantDetails) {
+				t.Errorf("Parse() debit details = %+v, want %+v", debit.Details, wantDetails)
+			}
+		})
+	}
+}
+
+func TestDkb_ParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "invalid amount",
+			input:   strings.Replace(testCsv, `"16,20"`, `"16x20"`, 1),
+			wantErr: `line 9: could not parse amount from "16x20": invalid number`,
+		},
+		{
+			name:    "invalid date",
+			input:   strings.Replace(testCsv, `"09.01.2020";"09.01.2020"`, `"2020-01-09";"09.01.2020"`, 1),
+			wantErr: `line 8: could not parse date from "2020-01-09"`,
+		},
+		{
+			name:    "missing kontostand",
+			input:   strings.Replace(testCsv, `"Kontostand vom 09.01.2020:";"1.188,32 EUR";`, "", 1),
+			wantErr: "could not find Kontostand in preamble",
+		},
+		{
+			name:    "missing iban",
+			input:   strings.Replace(testCsv, "DE89 3704 0044 0532 0130 00 / ", "", 1),
+			wantErr: `could not find iban in account line "Girokonto"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New().Parse(context.Background(), strings.NewReader(latin1(t, tt.input)))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %s", err, tt.wantErr)
+			}
+			var pErr *mt940.ParseError
+			if strings.HasPrefix(tt.wantErr, "line") && !errors.As(err, &pErr) {
+				t.Errorf("Parse() error = %v, want *mt940.ParseError", err)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		peek string
+		want float64
+	}{
+		{name: "export until 2023", peek: testCsv, want: 1},
+		{name: "export since 2023", peek: testCsv2023, want: 1},
+		{name: "header without preamble", peek: testCsv[strings.Index(testCsv, `"Buchungstag"`):], want: 0.8},
+		{name: "other csv", peek: `"Datum","Empfänger","Kontonummer"`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.peek)); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
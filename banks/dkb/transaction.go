@@ -0,0 +1,209 @@
+package dkb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+// column mapping of the dkb csv export until 2023
+const (
+	date int = iota
+	valueDate
+	bookingText
+	payee
+	reference
+	accountNumber
+	bankCode
+	amount
+	creditorID
+	mandateReference
+	customerReference
+)
+
+// column mapping of the dkb csv export since 2023
+const (
+	date2023 int = iota
+	valueDate2023
+	status2023
+	payer2023
+	payee2023
+	reference2023
+	transactionType2023
+	iban2023
+	amount2023
+	creditorID2023
+	mandateReference2023
+	customerReference2023
+)
+
+// gvcCodes returns the GVC Code for the upper case Buchungstext of the old export, note this list is not complete,
+// unknown texts get the code of a transfer or a credit
+var gvcCodes = map[string]string{
+	"ABSCHLUSS":                 "805",
+	"ENTGELT":                   "808",
+	"LASTSCHRIFT":               "005",
+	"ERSTLASTSCHRIFT":           "005",
+	"FOLGELASTSCHRIFT":          "005",
+	"GUTSCHRIFT":                "051",
+	"ÜBERWEISUNG":               "020",
+	"ONLINE-UEBERWEISUNG":       "020",
+	"UMBUCHUNG":                 "020",
+	"DAUERAUFTRAG":              "008",
+	"KARTENZAHLUNG/-ABRECHNUNG": "004",
+	"KREDITKARTENABRECHNUNG":    "004",
+	"LOHN, GEHALT, RENTE":       "053",
+	"GEHALT/RENTE":              "053",
+	"ZINSEN/DIVIDENDEN":         "805",
+	"BARGELDAUSZAHLUNG":         "083",
+	"AUSZAHLUNG":                "083",
+}
+
+// maxPurposeParts limits the subfields ?20 to ?29 so that the :86: line does not exceed 390 characters
+const maxPurposeParts = 8
+
+// entry is a transaction of one of the dkb export layouts
+type entry struct {
+	date              time.Time
+	valueDate         time.Time
+	bookingText       string
+	payee             string
+	reference         string
+	iban              string
+	bic               string
+	amount            *money.Money
+	creditorID        string
+	mandateReference  string
+	customerReference string
+	pending           bool
+}
+
+// parseEntry returns the entry of a csv line of the export until 2023
+func parseEntry(fields []string) (*entry, error) {
+	if len(fields) <= customerReference {
+		return nil, &mt940.ParseError{
+			Column: "entry",
+			Value:  strings.Join(fields, ";"),
+			Err:    fmt.Errorf("expected %d columns, got %d", customerReference+1, len(fields)),
+		}
+	}
+	e := &entry{
+		bookingText:       fields[bookingText],
+		payee:             fields[payee],
+		reference:         fields[reference],
+		iban:              fields[accountNumber],
+		bic:               fields[bankCode],
+		creditorID:        fields[creditorID],
+		mandateReference:  fields[mandateReference],
+		customerReference: fields[customerReference],
+	}
+	err := e.parseDatesAndAmount(fields[date], fields[valueDate], fields[amount])
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// parseEntry2023 returns the entry of a csv line of the export since 2023, it has no Buchungstext,
+// the payee is the payer for incoming transactions and pending transactions are marked with the status "Vorgemerkt"
+func parseEntry2023(fields []string) (*entry, error) {
+	if len(fields) <= customerReference2023 {
+		return nil, &mt940.ParseError{
+			Column: "entry",
+			Value:  strings.Join(fields, ";"),
+			Err:    fmt.Errorf("expected %d columns, got %d", customerReference2023+1, len(fields)),
+		}
+	}
+	e := &entry{
+		reference:         fields[reference2023],
+		iban:              fields[iban2023],
+		creditorID:        fields[creditorID2023],
+		mandateReference:  fields[mandateReference2023],
+		customerReference: fields[customerReference2023],
+		pending:           fields[status2023] == "Vorgemerkt",
+	}
+	err := e.parseDatesAndAmount(fields[date2023], fields[valueDate2023], fields[amount2023])
+	if err != nil {
+		return nil, err
+	}
+
+	e.payee = fields[payee2023]
+	if !e.amount.IsNegative() {
+		e.payee = fields[payer2023]
+	}
+	switch {
+	case e.amount.IsNegative() && e.creditorID != "":
+		e.bookingText = "Lastschrift"
+	case e.amount.IsNegative():
+		e.bookingText = "Überweisung"
+	default:
+		e.bookingText = "Gutschrift"
+	}
+	return e, nil
+}
+
+// parseDatesAndAmount sets booking date, value date and amount of the entry
+func (e *entry) parseDatesAndAmount(dateValue, valueDateValue, amountValue string) error {
+	var err error
+	e.date, err = parseDate(dateValue)
+	if err != nil {
+		return &mt940.ParseError{Column: "date", Value: dateValue, Err: err}
+	}
+	e.valueDate, err = parseDate(valueDateValue)
+	if err != nil {
+		return &mt940.ParseError{Column: "valueDate", Value: valueDateValue, Err: err}
+	}
+	e.amount, err = converter.ParseAmount(strings.TrimSuffix(strings.TrimSpace(amountValue), "€"), ",", ".", "EUR")
+	if err != nil {
+		return &mt940.ParseError{Column: "amount", Value: amountValue, Err: err}
+	}
+	return nil
+}
+
+// parseDate parses the dates of the old export (02.01.2006) and of the export since 2023 (02.01.06)
+func parseDate(value string) (time.Time, error) {
+	if len(value) == len("02.01.06") {
+		return time.Parse("02.01.06", value)
+	}
+	return time.Parse("02.01.2006", value)
+}
+
+// gvc returns the gvc code for the Buchungstext of the entry
+func (e *entry) gvc() string {
+	if code, ok := gvcCodes[strings.ToUpper(e.bookingText)]; ok {
+		return code
+	}
+	if e.amount.IsNegative() {
+		return "020"
+	}
+	return "051"
+}
+
+// statementLine converts the entry into a StatementLine without saldo
+func (e *entry) statementLine() *mt940.StatementLine {
+	gvc, references := e.gvc(), e.references()
+	line := &mt940.StatementLine{
+		Sales: mt940.NewSalesLine(e.valueDate, e.date, e.amount, gvc, references.EndToEnd),
+		Details: mt940.Details{
+			GVC:         gvc,
+			BookingText: converter.ConvertUmlauts(e.bookingText),
+		},
+	}
+	line.SetReferences(references, maxPurposeParts)
+	line.SetCounterparty(mt940.Counterparty{Name: e.payee, Account: mt940.NewCounterpartyAccount(e.iban, e.bic)})
+	return line
+}
+
+// references returns the sepa references of the usage,
+// the references of the columns replace the references found in the usage
+func (e *entry) references() converter.SEPAReferences {
+	return converter.ParseSEPA(e.reference).Merge(converter.SEPAReferences{
+		EndToEnd: e.customerReference,
+		Mandate:  e.mandateReference,
+		Creditor: e.creditorID,
+	})
+}
@@ -0,0 +1,529 @@
+package generic
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/iban"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+// Generic converts csv exports that are described by a Profile
+type Generic struct {
+	Profile *Profile
+	// Iban is used if the profile has no iban source
+	Iban string
+	// StartSaldo is the saldo before the first transaction in cents, it is used if the profile maps no saldo column
+	StartSaldo int64
+	// Lenient logs balance inconsistencies as warnings instead of failing the conversion
+	Lenient bool
+	// FixOrder sorts transactions of the same day into the order of the saldo column
+	FixOrder bool
+	logger   *log.Logger
+}
+
+func init() {
+	banks.Register("generic", func(opts banks.Options) (mt940.Bank, error) {
+		if opts.Profile == "" {
+			return nil, errors.New("generic parser needs a profile, provide it with -profile")
+		}
+		profile, err := LoadProfile(opts.Profile)
+		if err != nil {
+			return nil, err
+		}
+		g := New(profile, opts.Iban, opts.StartSaldo)
+		g.Lenient = opts.Lenient
+		g.FixOrder = opts.FixOrder
+		return g, nil
+	}, Detect)
+}
+
+// Detect always returns 0, a generic csv can only be recognized with its profile
+func Detect(peek []byte) float64 {
+	return 0
+}
+
+func New(profile *Profile, iban string, startSaldo int64) *Generic {
+	name := profile.Name
+	if name == "" {
+		name = "GENERIC"
+	}
+	logger := log.New(os.Stdout, fmt.Sprintf("[%s] ", name), log.Lmsgprefix)
+
+	return &Generic{
+		Profile:    profile,
+		Iban:       iban,
+		StartSaldo: startSaldo,
+		logger:     logger,
+	}
+}
+
+// columns are the resolved indexes of the profile columns, an unmapped column is -1
+type columns struct {
+	date, valueDate, amount, debit, credit, currency, saldo, payee, typ, iban int
+	endToEnd, mandate, creditor, counterpartyIban, counterpartyBic            int
+	purpose                                                                   []int
+	skip                                                                      []skipColumn
+}
+
+// skipColumn is a resolved SkipRule
+type skipColumn struct {
+	index  int
+	values []string
+}
+
+// Parse reads the csv export from r as described in the profile and converts it into BankData
+func (g *Generic) Parse(ctx context.Context, r io.Reader) (*mt940.BankData, error) {
+	p := g.Profile
+	enc, err := p.encoding()
+	if err != nil {
+		return nil, err
+	}
+	b := bufio.NewReader(enc.NewDecoder().Reader(r))
+
+	meta, err := readMetaLines(b, p.MetaLines)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(b)
+	cr.Comma = []rune(p.Delimiter)[0]
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	var header []string
+	if !p.NoHeader {
+		header, err = cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("could not read header from csv: %w", err)
+		}
+	}
+	cols, err := p.resolveColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var ta []mt940.Transaction
+	var lines []int
+	var ibanFromColumn string
+	skipped := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		entry, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read data from csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		line += p.MetaLines
+
+		// skip empty lines, e.g. at the end of the export
+		if strings.TrimSpace(strings.Join(entry, "")) == "" {
+			continue
+		}
+		if cols.skipped(entry) {
+			skipped++
+			continue
+		}
+
+		t, err := p.newTransaction(entry, cols)
+		if err != nil {
+			var pErr *mt940.ParseError
+			if errors.As(err, &pErr) {
+				pErr.Line = line
+				return nil, pErr
+			}
+			return nil, fmt.Errorf("could not convert entry to struct in line %d: %w", line, err)
+		}
+		if ibanFromColumn == "" && cols.iban >= 0 {
+			ibanFromColumn = entry[cols.iban]
+		}
+		ta = append(ta, t)
+		lines = append(lines, line)
+	}
+	if skipped > 0 {
+		g.logger.Printf("skipped %d transactions by the skip rules of the profile", skipped)
+	}
+	if len(ta) == 0 {
+		return nil, fmt.Errorf("no transactions found in csv")
+	}
+
+	if p.Descending {
+		for i, j := 0, len(ta)-1; i < j; i, j = i+1, j-1 {
+			ta[i], ta[j] = ta[j], ta[i]
+			lines[i], lines[j] = lines[j], lines[i]
+		}
+	}
+
+	if cols.saldo < 0 {
+		err = g.calculateSaldo(ta)
+	} else {
+		err = g.verifySaldo(ta, lines)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accountIBAN, err := g.getIban(meta, ibanFromColumn)
+	if err != nil {
+		return nil, err
+	}
+	bankNumber, accountNumber, err := iban.Split(accountIBAN)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mt940.BankData{
+		IBAN:          accountIBAN,
+		BankNumber:    bankNumber,
+		AccountNumber: accountNumber,
+		Transactions:  ta,
+	}, nil
+}
+
+// readMetaLines removes and returns the given number of lines before the header line
+func readMetaLines(b *bufio.Reader, count int) ([]string, error) {
+	meta := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		line, err := b.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("file format incorrect, file has only %d lines, meta block is %d lines long", i, count)
+			}
+			return nil, fmt.Errorf("could not read line %d: %w", i+1, err)
+		}
+		meta = append(meta, strings.TrimRight(line, "\r\n"))
+	}
+	return meta, nil
+}
+
+// resolveColumns returns the indexes of all mapped columns, names are looked up in the header
+func (p *Profile) resolveColumns(header []string) (*columns, error) {
+	names := make(map[string]int, len(header))
+	for i, h := range header {
+		names[strings.TrimSpace(h)] = i
+	}
+	resolve := func(c Column) (int, error) {
+		if c == "" {
+			return -1, nil
+		}
+		if index, err := strconv.Atoi(string(c)); err == nil {
+			if index < 0 {
+				return -1, fmt.Errorf("column index %d must not be negative", index)
+			}
+			return index, nil
+		}
+		index, ok := names[string(c)]
+		if !ok {
+			return -1, fmt.Errorf("column %q not found in header", c)
+		}
+		return index, nil
+	}
+
+	cols := &columns{}
+	var err error
+	for _, m := range []struct {
+		index  *int
+		column Column
+	}{
+		{&cols.date, p.Columns.Date},
+		{&cols.valueDate, p.Columns.ValueDate},
+		{&cols.amount, p.Columns.Amount},
+		{&cols.debit, p.Columns.Debit},
+		{&cols.credit, p.Columns.Credit},
+		{&cols.currency, p.Columns.Currency},
+		{&cols.saldo, p.Columns.Saldo},
+		{&cols.payee, p.Columns.Payee},
+		{&cols.typ, p.Columns.Type},
+		{&cols.iban, p.IBAN.Column},
+		{&cols.endToEnd, p.Columns.EndToEndReference},
+		{&cols.mandate, p.Columns.MandateReference},
+		{&cols.creditor, p.Columns.CreditorID},
+		{&cols.counterpartyIban, p.Columns.CounterpartyIBAN},
+		{&cols.counterpartyBic, p.Columns.CounterpartyBIC},
+	} {
+		*m.index, err = resolve(m.column)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range p.Columns.Purpose {
+		index, err := resolve(c)
+		if err != nil {
+			return nil, err
+		}
+		cols.purpose = append(cols.purpose, index)
+	}
+	for _, rule := range p.Skip {
+		index, err := resolve(rule.Column)
+		if err != nil {
+			return nil, err
+		}
+		cols.skip = append(cols.skip, skipColumn{index: index, values: rule.Values})
+	}
+	return cols, nil
+}
+
+// skipped returns true if the entry matches a skip rule of the profile
+func (c *columns) skipped(entry []string) bool {
+	for _, s := range c.skip {
+		if s.index >= len(entry) {
+			continue
+		}
+		value := strings.TrimSpace(entry[s.index])
+		for _, v := range s.values {
+			if value == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// max returns the highest mapped column index
+func (c *columns) max() int {
+	result := -1
+	for _, i := range append([]int{c.date, c.valueDate, c.amount, c.debit, c.credit, c.currency, c.saldo, c.payee, c.typ, c.iban,
+		c.endToEnd, c.mandate, c.creditor, c.counterpartyIban, c.counterpartyBic}, c.purpose...) {
+		if i > result {
+			result = i
+		}
+	}
+	return result
+}
+
+// newTransaction converts a csv entry into a statement line, the saldo is only set if the saldo column is mapped
+func (p *Profile) newTransaction(entry []string, cols *columns) (*mt940.StatementLine, error) {
+	if len(entry) <= cols.max() {
+		return nil, &mt940.ParseError{
+			Column: "entry",
+			Value:  strings.Join(entry, p.Delimiter),
+			Err:    fmt.Errorf("expected at least %d columns, got %d", cols.max()+1, len(entry)),
+		}
+	}
+	value := func(index int) string {
+		if index < 0 {
+			return ""
+		}
+		return strings.TrimSpace(entry[index])
+	}
+
+	date, err := time.Parse(p.DateFormat, value(cols.date))
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "date", Value: value(cols.date), Err: err}
+	}
+	valueDate := date
+	if v := value(cols.valueDate); v != "" {
+		valueDate, err = time.Parse(p.DateFormat, v)
+		if err != nil {
+			return nil, &mt940.ParseError{Column: "valueDate", Value: v, Err: err}
+		}
+	}
+
+	currency := p.Currency
+	if c := value(cols.currency); c != "" {
+		currency = strings.ToUpper(c)
+	}
+
+	var amount *money.Money
+	switch p.Sign {
+	case SignDebitCredit:
+		debit, err := p.Number.parse(value(cols.debit), currency)
+		if err != nil {
+			return nil, &mt940.ParseError{Column: "debit", Value: value(cols.debit), Err: err}
+		}
+		credit, err := p.Number.parse(value(cols.credit), currency)
+		if err != nil {
+			return nil, &mt940.ParseError{Column: "credit", Value: value(cols.credit), Err: err}
+		}
+		amount, _ = credit.Absolute().Subtract(debit.Absolute())
+	default:
+		if value(cols.amount) == "" {
+			return nil, &mt940.ParseError{Column: "amount", Err: fmt.Errorf("amount is empty")}
+		}
+		amount, err = p.Number.parse(value(cols.amount), currency)
+		if err != nil {
+			return nil, &mt940.ParseError{Column: "amount", Value: value(cols.amount), Err: err}
+		}
+	}
+
+	var saldo *money.Money
+	if cols.saldo >= 0 {
+		saldo, err = p.Number.parse(value(cols.saldo), currency)
+		if err != nil {
+			return nil, &mt940.ParseError{Column: "saldo", Value: value(cols.saldo), Err: err}
+		}
+	}
+
+	details, sepa, err := p.newDetails(value(cols.typ), purpose(entry, cols.purpose), references{
+		endToEnd: value(cols.endToEnd),
+		mandate:  value(cols.mandate),
+		creditor: value(cols.creditor),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	line := &mt940.StatementLine{
+		Sales:   mt940.NewSalesLine(valueDate, date, amount, details.GVC, sepa.EndToEnd),
+		Details: details,
+		Balance: saldo,
+	}
+	line.SetReferences(sepa, maxPurposeParts)
+	line.SetCounterparty(mt940.Counterparty{
+		Name:    value(cols.payee),
+		Account: mt940.NewCounterpartyAccount(value(cols.counterpartyIban), value(cols.counterpartyBic)),
+	})
+	truncatePurpose(&line.Details)
+	return line, nil
+}
+
+// purpose joins the content of all purpose columns with a space
+func purpose(entry []string, indexes []int) string {
+	parts := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		if v := strings.TrimSpace(entry[i]); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// references are the sepa references of a transaction, they are written in front of the usage
+type references struct {
+	endToEnd, mandate, creditor string
+}
+
+// maxDetailsLength is the maximum length of the :86: content
+const maxDetailsLength = 390
+
+// maxPurposeParts is the number of subfields ?20 to ?29 and ?60 to ?63 of the purpose
+const maxPurposeParts = 14
+
+// newDetails creates the :86: subfields with the gvc code of the transaction type and the sepa references of the usage,
+// the sepa references of the columns replace the references found in the usage
+func (p *Profile) newDetails(transactionType, usage string, refs references) (mt940.Details, converter.SEPAReferences, error) {
+	gvc, ok := p.GVC[transactionType]
+	if !ok {
+		gvc = p.DefaultGVC
+	}
+
+	sepa := converter.ParseSEPA(usage).Merge(converter.SEPAReferences{
+		EndToEnd: refs.endToEnd,
+		Mandate:  refs.mandate,
+		Creditor: refs.creditor,
+	})
+	if len(sepa.Fields()) > maxPurposeParts {
+		return mt940.Details{}, sepa, &mt940.ParseError{Column: "purpose", Value: usage, Err: fmt.Errorf("usage line is too long")}
+	}
+
+	return mt940.Details{
+		GVC:         gvc,
+		BookingText: converter.ConvertUmlauts(transactionType),
+	}, sepa, nil
+}
+
+// truncatePurpose removes purpose parts from the end until the :86: content is not longer than 390 characters
+func truncatePurpose(d *mt940.Details) {
+	for len(d.Purpose) > 0 && len(d.String()) > maxDetailsLength {
+		d.Purpose = d.Purpose[:len(d.Purpose)-1]
+	}
+}
+
+// parse converts a formatted number into money, an empty string is zero
+func (f NumberFormat) parse(s, currency string) (*money.Money, error) {
+	return converter.ParseAmount(s, f.Decimal, f.Thousands, currency)
+}
+
+// calculateSaldo sets the saldo of every transaction starting with StartSaldo
+func (g *Generic) calculateSaldo(ta []mt940.Transaction) error {
+	saldo := money.New(g.StartSaldo, ta[0].Amount().Currency().Code)
+	for _, t := range ta {
+		line := t.(*mt940.StatementLine)
+		var err error
+		saldo, err = saldo.Add(line.Amount())
+		if err != nil {
+			return fmt.Errorf("could not add amount to saldo: %w", err)
+		}
+		line.Balance = saldo
+	}
+	return nil
+}
+
+// verifySaldo checks that every saldo of the saldo column is the previous saldo plus the amount,
+// in lenient mode the problems are logged as warnings
+func (g *Generic) verifySaldo(ta []mt940.Transaction, lines []int) error {
+	if g.FixOrder {
+		if order := mt940.ReorderSameDay(ta); order != nil {
+			reordered := make([]int, len(order))
+			for j, k := range order {
+				reordered[j] = lines[k]
+			}
+			copy(lines, reordered)
+			g.logger.Println("reordered transactions of the same day to match the saldo column")
+		}
+	}
+
+	var problems []string
+	for _, b := range mt940.VerifyBalances(ta) {
+		problems = append(problems, fmt.Sprintf("line %d: %s", lines[b.Index], b))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	if g.Lenient {
+		for _, p := range problems {
+			g.logger.Printf("WARNING: %s", p)
+		}
+		return nil
+	}
+	return fmt.Errorf("csv is inconsistent, the export may be truncated or edited:\n\t%s", strings.Join(problems, "\n\t"))
+}
+
+// getIban returns the iban from the source of the profile, it falls back to the iban of the options
+func (g *Generic) getIban(meta []string, fromColumn string) (string, error) {
+	source := g.Profile.IBAN
+	iban := ""
+	switch {
+	case source.Value != "":
+		iban = source.Value
+	case source.Meta != "":
+		pattern := regexp.MustCompile(source.Meta)
+		for _, line := range meta {
+			if match := pattern.FindStringSubmatch(line); len(match) > 1 {
+				iban = match[1]
+				break
+			}
+		}
+		if iban == "" {
+			return "", fmt.Errorf("could not find iban in meta lines with %s", source.Meta)
+		}
+	case source.Column != "":
+		iban = fromColumn
+	default:
+		iban = g.Iban
+	}
+
+	iban = strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+	if iban == "" {
+		return "", fmt.Errorf("profile has no iban source, provide the iban with -iban")
+	}
+	return iban, nil
+}
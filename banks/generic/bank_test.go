@@ -0,0 +1,221 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+const testCsv = `Kontoumsaetze Girokonto
+Konto;DE89 3704 0044 0532 0130 00
+
+Buchungstag;Wertstellung;Vorgang;Empfaenger;Verwendungszweck;Soll;Haben;Saldo
+08.01.2020;09.01.2020;Lastschrift;Yabox;Reactive full-range local area network;1,62;;1.188,32
+06.01.2020;06.01.2020;Gutschrift;Yabox;Grass-roots systemic pricing structure;;16,20;1.189,94
+`
+
+func testProfile() *Profile {
+	p := &Profile{
+		Name:       "Testbank",
+		MetaLines:  3,
+		Descending: true,
+		Sign:       SignDebitCredit,
+		Columns: Columns{
+			Date:      "Buchungstag",
+			ValueDate: "Wertstellung",
+			Debit:     "Soll",
+			Credit:    "Haben",
+			Saldo:     "Saldo",
+			Payee:     "Empfaenger",
+			Type:      "Vorgang",
+			Purpose:   []Column{"Verwendungszweck"},
+		},
+		IBAN: IBANSource{Meta: `^Konto;(.*)$`},
+		GVC:  map[string]string{"Lastschrift": "005", "Gutschrift": "051"},
+	}
+	if err := p.setDefaults(); err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestGeneric_Parse(t *testing.T) {
+	g := New(testProfile(), "", 0)
+	got, err := g.Parse(context.Background(), strings.NewReader(testCsv))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got.IBAN != "DE89370400440532013000" || got.BankNumber != "37040044" || got.AccountNumber != "0532013000" {
+		t.Errorf("Parse() iban = %s, bank = %s, account = %s", got.IBAN, got.BankNumber, got.AccountNumber)
+	}
+	if len(got.Transactions) != 2 {
+		t.Fatalf("Parse() got %d transactions, want 2", len(got.Transactions))
+	}
+
+	var buf bytes.Buffer
+	if err := got.ConvertToMT940(&buf); err != nil {
+		t.Fatalf("ConvertToMT940() error = %v", err)
+	}
+	want := ":20:CSVTOMT940\r\n" +
+		":25:37040044/0532013000\r\n" +
+		":28C:0\r\n" +
+		":60F:C200106EUR1173,74\r\n" +
+		":61:2001060106C16,20NTRFNONREF\r\n" +
+		":86:051?00Gutschrift?20SVWZ+Grass-roots systemic p?21ricing structure\r\n" +
+		"?32Yabox\r\n" +
+		":61:2001090108D1,62NDDTNONREF\r\n" +
+		":86:005?00Lastschrift?20SVWZ+Reactive full-range lo?21cal area networ\r\n" +
+		"k?32Yabox\r\n" +
+		":62F:C200108EUR1188,32\r\n"
+	if buf.String() != want {
+		t.Errorf("ConvertToMT940() =\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestGeneric_ParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile func(p *Profile)
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "invalid amount",
+			input:   strings.Replace(testCsv, ";16,20;", ";16x20;", 1),
+			wantErr: `line 6: could not parse credit from "16x20": invalid number`,
+		},
+		{
+			name:    "invalid date",
+			input:   strings.Replace(testCsv, "08.01.2020;", "2020-01-08;", 1),
+			wantErr: `line 5: could not parse date from "2020-01-08"`,
+		},
+		{
+			name:    "unknown column",
+			profile: func(p *Profile) { p.Columns.Payee = "Name" },
+			input:   testCsv,
+			wantErr: `column "Name" not found in header`,
+		},
+		{
+			name:    "inconsistent saldo",
+			input:   strings.Replace(testCsv, ";1.188,32", ";1.188,00", 1),
+			wantErr: "line 5: saldo €1,188.00 of transaction from 08.01.2020 is not the previous saldo plus amount -€1.62 = €1,188.32",
+		},
+		{
+			name:    "missing iban",
+			profile: func(p *Profile) { p.IBAN = IBANSource{} },
+			input:   testCsv,
+			wantErr: "profile has no iban source",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testProfile()
+			if tt.profile != nil {
+				tt.profile(p)
+			}
+			_, err := New(p, "", 0).Parse(context.Background(), strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %s", err, tt.wantErr)
+			}
+			var pErr *mt940.ParseError
+			if strings.HasPrefix(tt.wantErr, "line") && strings.Contains(tt.wantErr, "could not parse") && !errors.As(err, &pErr) {
+				t.Errorf("Parse() error = %v, want *mt940.ParseError", err)
+			}
+		})
+	}
+}
+
+func TestGeneric_ParseWithoutSaldo(t *testing.T) {
+	p := &Profile{
+		Delimiter:  ",",
+		DateFormat: "2006-01-02",
+		Number:     NumberFormat{Decimal: "."},
+		Columns: Columns{
+			Date:     "0",
+			Amount:   "2",
+			Currency: "3",
+			Payee:    "1",
+		},
+		IBAN: IBANSource{Column: "4"},
+	}
+	if err := p.setDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	p.NoHeader = true
+	input := "2020-01-06,Yabox,16.2,eur,DE89370400440532013000\n2020-01-09,Yabox,-1.62,EUR,DE89370400440532013000\n"
+
+	g := New(p, "", 117212)
+	g.logger = log.New(&strings.Builder{}, "", 0)
+	got, err := g.Parse(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got.IBAN != "DE89370400440532013000" {
+		t.Errorf("Parse() iban = %s", got.IBAN)
+	}
+	saldos := []int64{118832, 118670}
+	for i, tr := range got.Transactions {
+		if tr.Saldo().Amount() != saldos[i] {
+			t.Errorf("Parse() saldo %d = %d, want %d", i, tr.Saldo().Amount(), saldos[i])
+		}
+	}
+	if d := got.Transactions[0].(*mt940.StatementLine).Details; d.GVC != "999" || d.BookingText != "" {
+		t.Errorf("Parse() details = %+v, want default gvc without booking text", d)
+	}
+}
+
+func TestGeneric_ParseReferences(t *testing.T) {
+	p := &Profile{
+		Delimiter:  ",",
+		DateFormat: "2006-01-02",
+		Number:     NumberFormat{Decimal: "."},
+		Columns: Columns{
+			Date:              "Date",
+			Amount:            "Amount",
+			Purpose:           []Column{"Usage"},
+			EndToEndReference: "EREF",
+			MandateReference:  "MREF",
+			CreditorID:        "CRED",
+			CounterpartyIBAN:  "IBAN",
+			CounterpartyBIC:   "BIC",
+		},
+		IBAN: IBANSource{Value: "DE89370400440532013000"},
+		Skip: []SkipRule{{Column: "Status", Values: []string{"pending"}}},
+	}
+	if err := p.setDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	input := "Date,Amount,Usage,EREF,MREF,CRED,IBAN,BIC,Status\n" +
+		"2020-01-06,-1.62,Rent,E2E-1,M-1,DE98ZZZ09999999999,DE12 5001 0517 0648 4898 90,INGDDEFFXXX,booked\n" +
+		"2020-01-07,16.2,Salary Mandatsreferenz: M-2,,,,,,booked\n" +
+		"2020-01-08,-9.99,Pending,,,,,,pending\n"
+
+	var logs strings.Builder
+	g := New(p, "", 0)
+	g.logger = log.New(&logs, "", 0)
+	got, err := g.Parse(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got.Transactions) != 2 {
+		t.Fatalf("Parse() got %d transactions, want 2", len(got.Transactions))
+	}
+	if !strings.Contains(logs.String(), "skipped 1 transactions") {
+		t.Errorf("Parse() logs = %q, want skipped transaction", logs.String())
+	}
+
+	want := "999?20EREF+E2E-1?21MREF+M-1?22CRED+DE98ZZZ09999999999?23SVWZ+Rent" +
+		"?30INGDDEFFXXX?31DE12500105170648489890"
+	if d := got.Transactions[0].(*mt940.StatementLine).Details; d.String() != want {
+		t.Errorf("Parse() details = %q, want %q", d.String(), want)
+	}
+	want = "999?20MREF+M-2?21SVWZ+Salary"
+	if d := got.Transactions[1].(*mt940.StatementLine).Details; d.String() != want {
+		t.Errorf("Parse() details = %q, want %q", d.String(), want)
+	}
+}
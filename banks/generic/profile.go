@@ -0,0 +1,248 @@
+package generic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"gopkg.in/yaml.v3"
+)
+
+// sign conventions of the amount
+const (
+	// SignAmount reads the signed amount from the amount column
+	SignAmount = "amount"
+	// SignDebitCredit reads the amount from separate debit and credit columns,
+	// debit values are booked as negative amounts whether they have a minus sign or not
+	SignDebitCredit = "debit-credit"
+)
+
+// Profile describes the csv export of a bank, it is loaded from a YAML or JSON file
+type Profile struct {
+	Name string `json:"name" yaml:"name"`
+	// Delimiter separates the columns, defaults to ;
+	Delimiter string `json:"delimiter" yaml:"delimiter"`
+	// Encoding of the csv file, utf-8 (default), iso-8859-1 or windows-1252
+	Encoding string `json:"encoding" yaml:"encoding"`
+	// MetaLines is the number of lines before the header line, they are available for the iban source
+	MetaLines int `json:"metaLines" yaml:"metaLines"`
+	// NoHeader is true if the csv has no header line, all columns have to be mapped by index then
+	NoHeader bool `json:"noHeader" yaml:"noHeader"`
+	// Descending is true if the newest transaction is the first row
+	Descending bool    `json:"descending" yaml:"descending"`
+	Columns    Columns `json:"columns" yaml:"columns"`
+	// DateFormat is the go layout of the date columns, defaults to 02.01.2006
+	DateFormat string       `json:"dateFormat" yaml:"dateFormat"`
+	Number     NumberFormat `json:"number" yaml:"number"`
+	// Sign is the sign convention of the amount, amount (default) or debit-credit
+	Sign string `json:"sign" yaml:"sign"`
+	// Currency is used if no currency column is mapped, defaults to EUR
+	Currency string     `json:"currency" yaml:"currency"`
+	IBAN     IBANSource `json:"iban" yaml:"iban"`
+	// GVC maps the value of the type column to the gvc code of :86:
+	GVC map[string]string `json:"gvc" yaml:"gvc"`
+	// DefaultGVC is used for types that are not in GVC, defaults to 999
+	DefaultGVC string `json:"defaultGvc" yaml:"defaultGvc"`
+	// Skip drops rows by the value of a column, e.g. pending transactions
+	Skip []SkipRule `json:"skip" yaml:"skip"`
+}
+
+// Columns maps the fields of a transaction to the columns of the csv,
+// only Date and Amount (or Debit and Credit) are required
+type Columns struct {
+	Date      Column `json:"date" yaml:"date"`
+	ValueDate Column `json:"valueDate" yaml:"valueDate"`
+	Amount    Column `json:"amount" yaml:"amount"`
+	Debit     Column `json:"debit" yaml:"debit"`
+	Credit    Column `json:"credit" yaml:"credit"`
+	Currency  Column `json:"currency" yaml:"currency"`
+	// Saldo is the balance after the transaction, if it is not mapped the balance is calculated from the start saldo
+	Saldo   Column   `json:"saldo" yaml:"saldo"`
+	Payee   Column   `json:"payee" yaml:"payee"`
+	Type    Column   `json:"type" yaml:"type"`
+	Purpose []Column `json:"purpose" yaml:"purpose"`
+	// EndToEndReference, MandateReference and CreditorID are written as EREF+, MREF+ and CRED+ in front of the purpose
+	EndToEndReference Column `json:"endToEndReference" yaml:"endToEndReference"`
+	MandateReference  Column `json:"mandateReference" yaml:"mandateReference"`
+	CreditorID        Column `json:"creditorId" yaml:"creditorId"`
+	// CounterpartyIBAN and CounterpartyBIC are written to the subfields ?31 and ?30 of :86:
+	CounterpartyIBAN Column `json:"counterpartyIban" yaml:"counterpartyIban"`
+	CounterpartyBIC  Column `json:"counterpartyBic" yaml:"counterpartyBic"`
+}
+
+// SkipRule drops every row whose column has one of the values
+type SkipRule struct {
+	Column Column   `json:"column" yaml:"column"`
+	Values []string `json:"values" yaml:"values"`
+}
+
+// Column is the name of a column in the header line or its zero based index
+type Column string
+
+// UnmarshalJSON accepts a column name or an index
+func (c *Column) UnmarshalJSON(b []byte) error {
+	var index int
+	if err := json.Unmarshal(b, &index); err == nil {
+		*c = Column(strconv.Itoa(index))
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return fmt.Errorf("column must be a name or an index: %w", err)
+	}
+	*c = Column(name)
+	return nil
+}
+
+// UnmarshalYAML accepts a column name or an index
+func (c *Column) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: column must be a name or an index", value.Line)
+	}
+	*c = Column(value.Value)
+	return nil
+}
+
+// NumberFormat describes the separators of the amount columns
+type NumberFormat struct {
+	// Decimal separator, defaults to ,
+	Decimal string `json:"decimal" yaml:"decimal"`
+	// Thousands separator, defaults to . if the decimal separator is , and to none otherwise
+	Thousands string `json:"thousands" yaml:"thousands"`
+}
+
+// IBANSource defines where the iban of the account is taken from, if nothing is set the iban from the options is used
+type IBANSource struct {
+	// Value is a fixed iban
+	Value string `json:"value" yaml:"value"`
+	// Meta is a regular expression that is matched against the meta lines, the first group is the iban
+	Meta string `json:"meta" yaml:"meta"`
+	// Column is the column of the first transaction that contains the iban
+	Column Column `json:"column" yaml:"column"`
+}
+
+// LoadProfile reads the profile from a .json, .yaml or .yml file
+func LoadProfile(path string) (*Profile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read profile: %w", err)
+	}
+
+	p := &Profile{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, p)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, p)
+	default:
+		return nil, fmt.Errorf("profile %s must be a .json, .yaml or .yml file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse profile %s: %w", path, err)
+	}
+
+	err = p.setDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// ParseProfile reads the profile from YAML content, e.g. a profile that is embedded into a bank
+func ParseProfile(content []byte) (*Profile, error) {
+	p := &Profile{}
+	err := yaml.Unmarshal(content, p)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse profile: %w", err)
+	}
+	err = p.setDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile: %w", err)
+	}
+	return p, nil
+}
+
+// setDefaults fills the optional fields of the profile and checks the required ones
+func (p *Profile) setDefaults() error {
+	if p.Delimiter == "" {
+		p.Delimiter = ";"
+	}
+	if len([]rune(p.Delimiter)) != 1 {
+		return fmt.Errorf("delimiter %q must be a single character", p.Delimiter)
+	}
+	if _, err := p.encoding(); err != nil {
+		return err
+	}
+	if p.MetaLines < 0 {
+		return fmt.Errorf("metaLines must not be negative")
+	}
+	if p.DateFormat == "" {
+		p.DateFormat = "02.01.2006"
+	}
+	if p.Number.Decimal == "" {
+		p.Number.Decimal = ","
+	}
+	if p.Number.Thousands == "" && p.Number.Decimal == "," {
+		p.Number.Thousands = "."
+	}
+	if p.Number.Decimal == p.Number.Thousands {
+		return fmt.Errorf("decimal and thousands separator must be different")
+	}
+	if p.Currency == "" {
+		p.Currency = "EUR"
+	}
+	if p.DefaultGVC == "" {
+		p.DefaultGVC = "999"
+	}
+	if p.IBAN.Meta != "" {
+		if _, err := regexp.Compile(p.IBAN.Meta); err != nil {
+			return fmt.Errorf("invalid iban meta expression: %w", err)
+		}
+	}
+
+	for i, rule := range p.Skip {
+		if rule.Column == "" {
+			return fmt.Errorf("skip rule %d needs a column", i+1)
+		}
+	}
+
+	if p.Columns.Date == "" {
+		return fmt.Errorf("date column is required")
+	}
+	switch p.Sign {
+	case "", SignAmount:
+		p.Sign = SignAmount
+		if p.Columns.Amount == "" {
+			return fmt.Errorf("amount column is required for sign %s", SignAmount)
+		}
+	case SignDebitCredit:
+		if p.Columns.Debit == "" || p.Columns.Credit == "" {
+			return fmt.Errorf("debit and credit columns are required for sign %s", SignDebitCredit)
+		}
+	default:
+		return fmt.Errorf("unknown sign %q (available options: %s, %s)", p.Sign, SignAmount, SignDebitCredit)
+	}
+	return nil
+}
+
+// encoding returns the decoder of the csv file
+func (p *Profile) encoding() (encoding.Encoding, error) {
+	switch strings.ToLower(p.Encoding) {
+	case "", "utf-8", "utf8":
+		return unicode.UTF8BOM, nil
+	case "iso-8859-1", "latin1":
+		return charmap.ISO8859_1, nil
+	case "iso-8859-15", "latin9":
+		return charmap.ISO8859_15, nil
+	case "windows-1252", "cp1252":
+		return charmap.Windows1252, nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q (available options: utf-8, iso-8859-1, iso-8859-15, windows-1252)", p.Encoding)
+}
@@ -0,0 +1,133 @@
+package generic
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProfile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		wantErr  string
+		validate func(t *testing.T, p *Profile)
+	}{
+		{
+			name: "yaml with defaults",
+			file: "bank.yaml",
+			content: `
+name: Testbank
+columns:
+  date: Buchungstag
+  amount: 3
+  purpose: [Verwendungszweck, 5]
+`,
+			validate: func(t *testing.T, p *Profile) {
+				if p.Delimiter != ";" || p.DateFormat != "02.01.2006" || p.Currency != "EUR" || p.DefaultGVC != "999" {
+					t.Errorf("defaults not set: %+v", p)
+				}
+				if p.Number.Decimal != "," || p.Number.Thousands != "." || p.Sign != SignAmount {
+					t.Errorf("number defaults not set: %+v", p)
+				}
+				if p.Columns.Amount != "3" || len(p.Columns.Purpose) != 2 || p.Columns.Purpose[1] != "5" {
+					t.Errorf("columns = %+v", p.Columns)
+				}
+			},
+		},
+		{
+			name:    "json with index columns",
+			file:    "bank.json",
+			content: `{"delimiter": ",", "number": {"decimal": "."}, "columns": {"date": 0, "amount": "Amount"}}`,
+			validate: func(t *testing.T, p *Profile) {
+				if p.Columns.Date != "0" || p.Columns.Amount != "Amount" {
+					t.Errorf("columns = %+v", p.Columns)
+				}
+				if p.Number.Thousands != "" {
+					t.Errorf("thousands = %q, want none", p.Number.Thousands)
+				}
+			},
+		},
+		{
+			name:    "missing amount column",
+			file:    "bank.yml",
+			content: "columns:\n  date: 0\n",
+			wantErr: "amount column is required",
+		},
+		{
+			name:    "missing debit column",
+			file:    "bank.yml",
+			content: "sign: debit-credit\ncolumns:\n  date: 0\n  credit: 1\n",
+			wantErr: "debit and credit columns are required",
+		},
+		{
+			name:    "unknown encoding",
+			file:    "bank.yml",
+			content: "encoding: ebcdic\ncolumns:\n  date: 0\n  amount: 1\n",
+			wantErr: `unknown encoding "ebcdic"`,
+		},
+		{
+			name:    "unknown extension",
+			file:    "bank.txt",
+			content: "",
+			wantErr: "must be a .json, .yaml or .yml file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := LoadProfile(writeProfile(t, tt.file, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadProfile() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadProfile() error = %v", err)
+			}
+			tt.validate(t, p)
+		})
+	}
+}
+
+func TestNumberFormat_parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   NumberFormat
+		input    string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{name: "german", format: NumberFormat{Decimal: ",", Thousands: "."}, input: "-1.234,5", currency: "EUR", want: -123450},
+		{name: "english", format: NumberFormat{Decimal: ".", Thousands: ","}, input: "1,234.56", currency: "EUR", want: 123456},
+		{name: "whole units", format: NumberFormat{Decimal: ","}, input: "+20", currency: "EUR", want: 2000},
+		{name: "only decimals", format: NumberFormat{Decimal: ","}, input: ",5", currency: "EUR", want: 50},
+		{name: "empty is zero", format: NumberFormat{Decimal: ","}, input: "", currency: "EUR", want: 0},
+		{name: "currency without decimals", format: NumberFormat{Decimal: ","}, input: "150", currency: "JPY", want: 150},
+		{name: "too many decimals", format: NumberFormat{Decimal: ","}, input: "1,234", currency: "EUR", wantErr: true},
+		{name: "invalid", format: NumberFormat{Decimal: ","}, input: "1a,00", currency: "EUR", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.format.parse(tt.input, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Amount() != tt.want {
+				t.Errorf("parse() = %d, want %d", got.Amount(), tt.want)
+			}
+		})
+	}
+}
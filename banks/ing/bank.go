@@ -2,21 +2,60 @@ package ing
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/iban"
 	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
 	"golang.org/x/text/encoding/charmap"
 )
 
 type Ing struct {
 	HasCategory bool
-	data        *mt940.BankData
-	logger      *log.Logger
+	// Lenient logs balance inconsistencies as warnings instead of failing the conversion
+	Lenient bool
+	// FixOrder sorts transactions of the same day into the order of the saldo column
+	FixOrder bool
+	logger   *log.Logger
+}
+
+// record is a csv entry together with its line in the csv file
+type record struct {
+	line   int
+	fields []string
+}
+
+func init() {
+	banks.Register("ing", func(opts banks.Options) (mt940.Bank, error) {
+		i := New(opts.HasCategory)
+		i.Lenient = opts.Lenient
+		i.FixOrder = opts.FixOrder
+		return i, nil
+	}, Detect)
+}
+
+// Detect returns the confidence that peek is the beginning of an ing csv export,
+// ing exports start with the "Umsatzanzeige" meta block
+func Detect(peek []byte) float64 {
+	peek = bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf"))
+	if bytes.HasPrefix(peek, []byte("Umsatzanzeige;")) {
+		return 1
+	}
+	if bytes.Contains(peek, []byte("\nBuchung;Valuta;")) {
+		return 0.8
+	}
+	return 0
 }
 
 func New(hasCategory bool) *Ing {
@@ -28,24 +67,27 @@ func New(hasCategory bool) *Ing {
 	}
 }
 
-func (i *Ing) ParseCsv(csvFile *os.File) *mt940.BankData {
+// Parse reads the ing csv export from r and converts it into BankData
+func (i *Ing) Parse(ctx context.Context, r io.Reader) (*mt940.BankData, error) {
 	// convert to utf8 because ing-diba encodes in ISO8859-1
-	b := bufio.NewReader(charmap.ISO8859_1.NewDecoder().Reader(csvFile))
+	b := bufio.NewReader(charmap.ISO8859_1.NewDecoder().Reader(r))
 
 	// extract the first 14 lines from the reader, thats the meta infos
 	meta, err := extractMetaFields(b)
 	if err != nil {
-		i.logger.Fatalf("could not read meta fields: %v", err)
+		return nil, fmt.Errorf("could not read meta fields: %w", err)
 	}
 
 	// extract banknumber and accountnumber from meta fields
 	bankNumber, accountNumber, err := getAccountNumber(meta)
-
 	if err != nil {
-		i.logger.Fatalf("could not get account number: %v", err)
+		return nil, fmt.Errorf("could not get account number: %w", err)
 	}
 
-	i.data = &mt940.BankData{
+	accountIBAN, _ := getIban(meta)
+
+	data := &mt940.BankData{
+		IBAN:          accountIBAN,
 		AccountNumber: accountNumber,
 		BankNumber:    bankNumber,
 	}
@@ -54,32 +96,72 @@ func (i *Ing) ParseCsv(csvFile *os.File) *mt940.BankData {
 	cr := csv.NewReader(b)
 	cr.Comma = ';'
 
-	transactions, err := cr.ReadAll()
-	if err != nil {
-		i.logger.Fatalf("could not read data from csv %v", err)
+	var transactions []record
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read data from csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		transactions = append(transactions, record{line: metablocksize + line, fields: fields})
+	}
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("could not find header line in csv")
 	}
 	// remove first line and reverse the order
 	transactions = cleanUpTransactions(transactions)
 
 	// create ingTransaction structs
 	var ta = make([]mt940.Transaction, 0, len(transactions))
-	for j, t := range transactions {
-		ts, err := newTransactionFromCSV(t, i.HasCategory)
+	var lines = make([]int, 0, len(transactions))
+	for _, t := range transactions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ts, err := newTransactionFromCSV(t.fields, i.HasCategory)
 		if err != nil {
-			i.logger.Fatalf("could not convert entry to struct in line %d: %v", j, err)
+			var pErr *mt940.ParseError
+			if errors.As(err, &pErr) {
+				pErr.Line = t.line
+				return nil, pErr
+			}
+			return nil, fmt.Errorf("could not convert entry to struct in line %d: %w", t.line, err)
 		}
 		ta = append(ta, ts)
+		lines = append(lines, t.line)
 	}
 
-	i.data.Transactions = ta
+	if i.FixOrder {
+		if order := mt940.ReorderSameDay(ta); order != nil {
+			reordered := make([]int, len(order))
+			for j, k := range order {
+				reordered[j] = lines[k]
+			}
+			lines = reordered
+			i.logger.Println("reordered transactions of the same day to match the saldo column")
+		}
+	}
 
-	return i.data
+	// compare the saldo column and the meta block with the amounts
+	err = i.reconcile(meta, ta, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Transactions = ta
+
+	return data, nil
 }
 
+// metablocksize is the number of lines of the meta block in front of the csv data
+const metablocksize = 13
+
 // extractMetaFields removes and returns the first 14 lines from the csv content,
 // that are in case of the ing-Diba meta fields that are no data and only infos about the sheet
 func extractMetaFields(b *bufio.Reader) ([]string, error) {
-	var metablocksize = 13
 	var meta = make([]string, 0, metablocksize)
 
 	for i := 0; i < metablocksize; i++ {
@@ -98,25 +180,77 @@ func extractMetaFields(b *bufio.Reader) ([]string, error) {
 	return meta, nil
 }
 
-// getAccountNumber returns blz and accountNumber from meta tags of the ING csv
-func getAccountNumber(meta []string) (string, string, error) {
-	// get iban line and split it, iban is in the second row
-	metafields := strings.Split(meta[1], ";")
+// getMetaField returns the values of the meta line that starts with name, e.g. "Saldo;1172,12;EUR"
+func getMetaField(meta []string, name string) ([]string, bool) {
+	for _, line := range meta {
+		metafields := strings.Split(strings.TrimSpace(line), ";")
+		if len(metafields) >= 2 && metafields[0] == name {
+			return metafields[1:], true
+		}
+	}
+	return nil, false
+}
 
-	if len(metafields) < 2 {
-		return "", "", fmt.Errorf("could not split meta field %s", metafields[0])
+// getIban returns the iban without whitespaces from meta tags of the ING csv
+func getIban(meta []string) (string, error) {
+	metafields, ok := getMetaField(meta, "IBAN")
+	if !ok {
+		return "", fmt.Errorf("could not find iban in meta fields")
 	}
-	iban := metafields[1]
 	// replace all whitespaces
-	iban = strings.ReplaceAll(iban, " ", "")
-	// blz begins in position 4 and has 8 chars
-	// accountNumber begins in position 12 and has 10 chars (until the end of iban)
-	return iban[4:12], strings.TrimSpace(iban[12:]), nil
+	return strings.ReplaceAll(metafields[0], " ", ""), nil
+}
+
+// getSaldo returns the saldo from meta tags of the ING csv, it returns nil if the export has no saldo
+func getSaldo(meta []string) (*money.Money, error) {
+	metafields, ok := getMetaField(meta, "Saldo")
+	if !ok {
+		return nil, nil
+	}
+	if len(metafields) < 2 {
+		return nil, fmt.Errorf("saldo meta field has no currency")
+	}
+	saldo, err := converter.MoneyStringToInt(metafields[0])
+	if err != nil {
+		return nil, fmt.Errorf("could not parse saldo meta field %s: %w", metafields[0], err)
+	}
+	return money.New(int64(saldo), metafields[1]), nil
+}
+
+// getPeriod returns start and end date of the "Zeitraum" meta tag of the ING csv,
+// it returns zero times if the export has no period
+func getPeriod(meta []string) (time.Time, time.Time, error) {
+	metafields, ok := getMetaField(meta, "Zeitraum")
+	if !ok {
+		return time.Time{}, time.Time{}, nil
+	}
+	dates := strings.Split(metafields[0], " - ")
+	if len(dates) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not split period meta field %s", metafields[0])
+	}
+	start, err := time.Parse("02.01.2006", strings.TrimSpace(dates[0]))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse period start: %w", err)
+	}
+	end, err := time.Parse("02.01.2006", strings.TrimSpace(dates[1]))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse period end: %w", err)
+	}
+	return start, end, nil
+}
+
+// getAccountNumber returns blz and accountNumber from meta tags of the ING csv
+func getAccountNumber(meta []string) (string, string, error) {
+	ibanValue, err := getIban(meta)
+	if err != nil {
+		return "", "", err
+	}
+	return iban.Split(ibanValue)
 }
 
 // cleanUpTransactions removes the first line of the csv data, and reverses the order of the rest,
 // ING displays all data in ascending order, we need descending for mt940
-func cleanUpTransactions(ts [][]string) [][]string {
+func cleanUpTransactions(ts []record) []record {
 	// remove first entry, thats the header
 	ts = ts[1:]
 
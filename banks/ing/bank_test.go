@@ -2,9 +2,14 @@ package ing
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"log"
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
 )
 
 func Test_getAccountNumber(t *testing.T) {
@@ -20,7 +25,7 @@ func Test_getAccountNumber(t *testing.T) {
 		{
 			name: "without spaces",
 			args: args{
-				meta: []string{"", "IBAN;DE00111111110000000000"},
+				meta: []string{"", "IBAN;DE13111111110000000000"},
 			},
 			bankNumber:    "11111111",
 			accountNumber: "0000000000",
@@ -28,7 +33,7 @@ func Test_getAccountNumber(t *testing.T) {
 		{
 			name: "with spaces",
 			args: args{
-				meta: []string{"", "IBAN;DE22 1111 1111 0000 0000 00"},
+				meta: []string{"", "IBAN;DE13 1111 1111 0000 0000 00"},
 			},
 			bankNumber:    "11111111",
 			accountNumber: "0000000000",
@@ -85,18 +90,18 @@ func Test_extractMetaFields(t *testing.T) {
 func Test_cleanUpTransactions(t *testing.T) {
 	tests := []struct {
 		name string
-		ts   [][]string
-		want [][]string
+		ts   []record
+		want []record
 	}{
 		{
 			name: "test with 3",
-			ts:   [][]string{{"1", "2"}, {"3", "4"}, {"5", "6"}},
-			want: [][]string{{"5", "6"}, {"3", "4"}},
+			ts:   []record{{1, []string{"1", "2"}}, {2, []string{"3", "4"}}, {3, []string{"5", "6"}}},
+			want: []record{{3, []string{"5", "6"}}, {2, []string{"3", "4"}}},
 		},
 		{
 			name: "test with 4",
-			ts:   [][]string{{"1", "2"}, {"3", "4"}, {"5", "6"}, {"7", "8"}},
-			want: [][]string{{"7", "8"}, {"5", "6"}, {"3", "4"}},
+			ts:   []record{{1, []string{"1", "2"}}, {2, []string{"3", "4"}}, {3, []string{"5", "6"}}, {4, []string{"7", "8"}}},
+			want: []record{{4, []string{"7", "8"}}, {3, []string{"5", "6"}}, {2, []string{"3", "4"}}},
 		},
 	}
 	for _, tt := range tests {
@@ -107,3 +112,172 @@ func Test_cleanUpTransactions(t *testing.T) {
 		})
 	}
 }
+
+const testCsv = `Umsatzanzeige;Datei erstellt am: 07.03.2021 13:18
+
+IBAN;DE32 5001 0517 1234 5678 95
+Kontoname;Girokonto
+Bank;ING
+Kunde;Test Tester
+Zeitraum;06.01.2020 - 09.01.2020
+Saldo;1188,32;EUR
+
+Sortierung;Datum absteigend
+
+In der CSV-Datei finden Sie alle bereits gebuchten Umsaetze.
+
+Buchung;Valuta;Auftraggeber/Empfaenger;Buchungstext;Kategorie;Verwendungszweck;Saldo;Waehrung;Betrag;Waehrung
+09.01.2020;09.01.2020;Yabox;Lastschrift;Shopping und Media;Reactive full-range local area network;1188,32;EUR;-1,62;EUR
+06.01.2020;06.01.2020;Yabox;Gutschrift;Shopping und Media;Grass-roots systemic pricing structure;1189,94;EUR;16,20;EUR
+`
+
+func TestIng_Parse(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantCount     int
+		wantFirstDate string
+		wantErr       string
+	}{
+		{
+			name:          "valid csv",
+			input:         testCsv,
+			wantCount:     2,
+			wantFirstDate: "2020-01-06",
+		},
+		{
+			name:    "invalid amount",
+			input:   strings.Replace(testCsv, "16,20;EUR", "16-20;EUR", 1),
+			wantErr: `line 16: could not parse amount from "16-20": strconv.Atoi: parsing "16-20": invalid syntax`,
+		},
+		{
+			name:    "invalid date",
+			input:   strings.Replace(testCsv, "09.01.2020;09.01.2020", "09012020;09.01.2020", 1),
+			wantErr: `line 15: could not parse date from "09012020": parsing time "09012020" as "02.01.2006": cannot parse "012020" as "."`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(true).Parse(context.Background(), strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				var pErr *mt940.ParseError
+				if !errors.As(err, &pErr) {
+					t.Fatalf("Parse() error = %v, want *mt940.ParseError", err)
+				}
+				if err.Error() != tt.wantErr {
+					t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got.BankNumber != "50010517" || got.AccountNumber != "1234567895" {
+				t.Errorf("Parse() bank = %s, account = %s", got.BankNumber, got.AccountNumber)
+			}
+			if len(got.Transactions) != tt.wantCount {
+				t.Fatalf("Parse() got %d transactions, want %d", len(got.Transactions), tt.wantCount)
+			}
+			if d := got.Transactions[0].Date().Format("2006-01-02"); d != tt.wantFirstDate {
+				t.Errorf("Parse() first date = %s, want %s", d, tt.wantFirstDate)
+			}
+		})
+	}
+}
+
+// sameDayCsv contains both transactions of testCsv on the same day in the wrong order
+var sameDayCsv = strings.Replace(testCsv, `09.01.2020;09.01.2020;Yabox;Lastschrift;Shopping und Media;Reactive full-range local area network;1188,32;EUR;-1,62;EUR
+06.01.2020;06.01.2020;Yabox;Gutschrift;Shopping und Media;Grass-roots systemic pricing structure;1189,94;EUR;16,20;EUR`,
+	`09.01.2020;09.01.2020;Yabox;Gutschrift;Shopping und Media;Grass-roots systemic pricing structure;1189,94;EUR;16,20;EUR
+09.01.2020;09.01.2020;Yabox;Lastschrift;Shopping und Media;Reactive full-range local area network;1188,32;EUR;-1,62;EUR`, 1)
+
+func TestIng_ParseReconcile(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		lenient     bool
+		fixOrder    bool
+		wantErr     string
+		wantWarning string
+	}{
+		{
+			name:  "consistent csv",
+			input: testCsv,
+		},
+		{
+			name:    "saldo does not match amount",
+			input:   strings.Replace(testCsv, "network;1188,32;EUR", "network;1188,00;EUR", 1),
+			wantErr: "line 15: saldo €1,188.00 of transaction from 09.01.2020 is not the previous saldo plus amount -€1.62 = €1,188.32",
+		},
+		{
+			name:    "meta saldo does not match last saldo",
+			input:   strings.Replace(testCsv, "Saldo;1188,32;EUR", "Saldo;1200,00;EUR", 1),
+			wantErr: "line 15: saldo €1,188.32 of the last transaction does not match saldo €1,200.00 of the meta block",
+		},
+		{
+			name:    "transaction outside of period",
+			input:   strings.Replace(testCsv, "Zeitraum;06.01.2020 - 09.01.2020", "Zeitraum;07.01.2020 - 09.01.2020", 1),
+			wantErr: "line 16: date 06.01.2020 is outside of period 07.01.2020 - 09.01.2020",
+		},
+		{
+			name:    "same day transactions in wrong order",
+			input:   sameDayCsv,
+			wantErr: "line 15: saldo €1,189.94 of transaction from 09.01.2020 is not the previous saldo plus amount €16.20 = €1,204.52",
+		},
+		{
+			name:        "same day transactions are reordered",
+			input:       sameDayCsv,
+			fixOrder:    true,
+			wantWarning: "reordered transactions of the same day to match the saldo column",
+		},
+		{
+			name:        "lenient mode logs a warning",
+			input:       strings.Replace(testCsv, "Saldo;1188,32;EUR", "Saldo;1200,00;EUR", 1),
+			lenient:     true,
+			wantWarning: "WARNING: line 15: saldo €1,188.32 of the last transaction does not match saldo €1,200.00 of the meta block",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs strings.Builder
+			i := New(true)
+			i.Lenient = tt.lenient
+			i.FixOrder = tt.fixOrder
+			i.logger = log.New(&logs, "", 0)
+
+			_, err := i.Parse(context.Background(), strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := strings.TrimSpace(logs.String()); got != tt.wantWarning {
+				t.Errorf("Parse() logged %q, want %q", got, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		peek string
+		want float64
+	}{
+		{name: "ing export", peek: testCsv, want: 1},
+		{name: "ing export with bom", peek: "\xef\xbb\xbf" + testCsv, want: 1},
+		{name: "ing export without meta block", peek: "\nBuchung;Valuta;Auftraggeber/Empfaenger\n", want: 0.8},
+		{name: "other csv", peek: `"Datum","Empfänger","Kontonummer"`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.peek)); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package ing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+// reconcile checks that the saldo of every transaction is the saldo of the previous transaction plus its amount,
+// that the last saldo matches the saldo of the meta block and that all transactions are inside the period of the meta block.
+// In lenient mode all problems are logged as warnings, otherwise an error with all problems is returned.
+// The transactions have to be in ascending order, lines contains the csv line of each transaction.
+func (i *Ing) reconcile(meta []string, transactions []mt940.Transaction, lines []int) error {
+	problems, err := findInconsistencies(meta, transactions, lines)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	if i.Lenient {
+		for _, p := range problems {
+			i.logger.Printf("WARNING: %s", p)
+		}
+		return nil
+	}
+	return fmt.Errorf("csv is inconsistent, the export may be truncated or edited:\n\t%s", strings.Join(problems, "\n\t"))
+}
+
+// findInconsistencies returns a description of every balance or period inconsistency of the transactions
+func findInconsistencies(meta []string, transactions []mt940.Transaction, lines []int) ([]string, error) {
+	metaSaldo, err := getSaldo(meta)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := getPeriod(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for j, t := range transactions {
+		if !start.IsZero() && (t.Date().Before(start) || t.Date().After(end)) {
+			problems = append(problems, fmt.Sprintf(
+				"line %d: date %s is outside of period %s - %s",
+				lines[j], t.Date().Format("02.01.2006"), start.Format("02.01.2006"), end.Format("02.01.2006"),
+			))
+		}
+	}
+	for _, b := range mt940.VerifyBalances(transactions) {
+		problems = append(problems, fmt.Sprintf("line %d: %s", lines[b.Index], b))
+	}
+
+	if metaSaldo != nil && len(transactions) > 0 {
+		last := transactions[len(transactions)-1]
+		if ok, err := last.Saldo().Equals(metaSaldo); err != nil || !ok {
+			problems = append(problems, fmt.Sprintf(
+				"line %d: saldo %s of the last transaction does not match saldo %s of the meta block",
+				lines[len(lines)-1], last.Saldo().Display(), metaSaldo.Display(),
+			))
+		}
+	}
+	return problems, nil
+}
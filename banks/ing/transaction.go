@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/JHeimbach/csvtomt940/converter"
-	"github.com/JHeimbach/csvtomt940/formatter"
+	"github.com/JHeimbach/csvtomt940/mt940"
 	"github.com/Rhymond/go-money"
 )
 
@@ -44,6 +44,7 @@ type ingTransaction struct {
 	date            time.Time
 	valueDate       time.Time
 	payee           string
+	name            string
 	transactionType string
 	category        string
 	reference       string
@@ -66,31 +67,54 @@ func (t *ingTransaction) Date() time.Time {
 	return t.date
 }
 
+// Category returns category field, it is empty if the csv has no category column
+func (t *ingTransaction) Category() string {
+	return t.category
+}
+
+// Counterparty returns the complete payee, the payee of ?32 and ?33 is cut to 54 characters,
+// the ing csv has no account of the counterparty
+func (t *ingTransaction) Counterparty() mt940.Counterparty {
+	return mt940.Counterparty{Name: strings.TrimSpace(t.name)}
+}
+
+// References returns the SEPA references of the Verwendungszweck
+func (t *ingTransaction) References() converter.SEPAReferences {
+	return converter.ParseSEPA(t.reference)
+}
+
 // newTransactionFromCSV returns a transaction from csv entry
 func newTransactionFromCSV(entry []string, hasCategory bool) (*ingTransaction, error) {
 	var offset = 0
 	if !hasCategory {
 		offset = -1
 	}
+	if len(entry) <= aCurrency+offset {
+		return nil, &mt940.ParseError{
+			Column: "entry",
+			Value:  strings.Join(entry, ";"),
+			Err:    fmt.Errorf("expected %d columns, got %d", aCurrency+offset+1, len(entry)),
+		}
+	}
 	bT, err := time.Parse("02.01.2006", entry[date])
 	if err != nil {
-		return nil, fmt.Errorf("could not parse date: %w", err)
+		return nil, &mt940.ParseError{Column: "date", Value: entry[date], Err: err}
 	}
 
 	vT, err := time.Parse("02.01.2006", entry[valueDate])
 	if err != nil {
-		return nil, fmt.Errorf("could not parse valueDate: %w", err)
+		return nil, &mt940.ParseError{Column: "valueDate", Value: entry[valueDate], Err: err}
 	}
 
 	sInt, err := converter.MoneyStringToInt(entry[saldo+offset])
 	if err != nil {
-		return nil, fmt.Errorf("could not parse saldo to int: %w", err)
+		return nil, &mt940.ParseError{Column: "saldo", Value: entry[saldo+offset], Err: err}
 	}
 	sMoney := money.New(int64(sInt), entry[sCurrency+offset])
 
 	bInt, err := converter.MoneyStringToInt(entry[amount+offset])
 	if err != nil {
-		return nil, fmt.Errorf("could not parse amount to int: %w", err)
+		return nil, &mt940.ParseError{Column: "amount", Value: entry[amount+offset], Err: err}
 	}
 	bMoney := money.New(int64(bInt), entry[aCurrency+offset])
 
@@ -102,6 +126,7 @@ func newTransactionFromCSV(entry []string, hasCategory bool) (*ingTransaction, e
 		date:            bT,
 		valueDate:       vT,
 		payee:           cText,
+		name:            entry[payee],
 		transactionType: entry[transactionType],
 		reference:       entry[reference+offset],
 		saldo:           sMoney,
@@ -114,23 +139,11 @@ func newTransactionFromCSV(entry []string, hasCategory bool) (*ingTransaction, e
 	return transaction, nil
 }
 
-//createSalesLine creates :61: line for MT940 from transaction
+// createSalesLine creates :61: line for MT940 from transaction
 func (t *ingTransaction) createSalesLine(writer io.Writer) error {
-	// :61:<ValueDate><Date><IsCreditOrDebit><Amount>NTRFNONREF
-	// :61:_YYMMDD_MMDD_C/D_00,00NTRFNONREF
-	_, err := writer.Write(
-		[]byte(fmt.Sprintf(":61:%s%s%s%sNTRFNONREF\r\n",
-			t.valueDate.Format("060102"),
-			t.date.Format("0102"),
-			converter.IsCreditOrDebit(t.Amount()),
-			formatter.ConvertMoneyToString(t.Amount().Absolute()),
-		)),
-	)
-
-	if err != nil {
-		return fmt.Errorf("could not create sales line: %w", err)
-	}
-	return nil
+	// the type code and the reversal mark are derived from the gvc code, the end to end reference is the customer reference
+	sales := mt940.NewSalesLine(t.valueDate, t.date, t.Amount(), gvcCodes[t.transactionType], t.References().EndToEnd)
+	return sales.Write(writer)
 }
 
 // createMultipurposeLine creates :86: line for MT940 from transaction
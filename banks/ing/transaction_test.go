@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/JHeimbach/csvtomt940/mt940"
 	"github.com/Rhymond/go-money"
 )
 
@@ -34,13 +35,13 @@ func Test_newTransactionFromCSV(t *testing.T) {
 			name:    "bt times is invalid",
 			entry:   []string{"0201.2000", "03.02.2001", "", "", "", "", "", "", ""},
 			want:    nil,
-			wantErr: fmt.Errorf("could not parse date: %w", errors.New("parsing time \"0201.2000\" as \"02.01.2006\": cannot parse \"01.2000\" as \".\"")),
+			wantErr: &mt940.ParseError{Column: "date", Value: "0201.2000", Err: errors.New("parsing time \"0201.2000\" as \"02.01.2006\": cannot parse \"01.2000\" as \".\"")},
 		},
 		{
 			name:    "vt times is invalid",
 			entry:   []string{"02.01.2000", "0302.2001", "", "", "", "", "", "", ""},
 			want:    nil,
-			wantErr: fmt.Errorf("could not parse valueDate: %w", errors.New("parsing time \"0302.2001\" as \"02.01.2006\": cannot parse \"02.2001\" as \".\"")),
+			wantErr: &mt940.ParseError{Column: "valueDate", Value: "0302.2001", Err: errors.New("parsing time \"0302.2001\" as \"02.01.2006\": cannot parse \"02.2001\" as \".\"")},
 		},
 		{
 			name:  "both money values are valid",
@@ -57,13 +58,13 @@ func Test_newTransactionFromCSV(t *testing.T) {
 			name:    "saldo money is invalid",
 			entry:   []string{"02.01.2000", "02.01.2000", "", "", "", "12-00", "EUR", "5,00", "EUR"},
 			want:    nil,
-			wantErr: fmt.Errorf("could not parse saldo to int: %w", errors.New("strconv.Atoi: parsing \"12-00\": invalid syntax")),
+			wantErr: &mt940.ParseError{Column: "saldo", Value: "12-00", Err: errors.New("strconv.Atoi: parsing \"12-00\": invalid syntax")},
 		},
 		{
 			name:    "amount money is invalid",
 			entry:   []string{"02.01.2000", "02.01.2000", "", "", "", "12,00", "EUR", "5-00", "EUR"},
 			want:    nil,
-			wantErr: fmt.Errorf("could not parse amount to int: %w", errors.New("strconv.Atoi: parsing \"5-00\": invalid syntax")),
+			wantErr: &mt940.ParseError{Column: "amount", Value: "5-00", Err: errors.New("strconv.Atoi: parsing \"5-00\": invalid syntax")},
 		},
 		{
 			name:  "string fields are set",
@@ -163,6 +164,29 @@ func Test_ingtransaction_createSalesLine(t1 *testing.T) {
 			wantWriter: ":61:0001010102D10,50NTRFNONREF\r\n",
 			wantErr:    false,
 		},
+		{
+			name: "create salesline of direct debit with end to end reference",
+			transaction: &ingTransaction{
+				date:            time.Date(2000, 01, 02, 0, 0, 0, 0, time.UTC),
+				valueDate:       time.Date(2000, 01, 02, 0, 0, 0, 0, time.UTC),
+				transactionType: "Lastschrift",
+				reference:       "Rechnung 4711 Mandatsref.: M-1 End-to-End-Ref.: 2000-01-02/4711",
+				amount:          money.New(-1050, "EUR"),
+			},
+			wantWriter: ":61:0001020102D10,50NDDT2000-01-02/4711\r\n",
+			wantErr:    false,
+		},
+		{
+			name: "create salesline of returned direct debit",
+			transaction: &ingTransaction{
+				date:            time.Date(2000, 01, 02, 0, 0, 0, 0, time.UTC),
+				valueDate:       time.Date(2000, 01, 02, 0, 0, 0, 0, time.UTC),
+				transactionType: "Retouren",
+				amount:          money.New(1050, "EUR"),
+			},
+			wantWriter: ":61:0001020102RD10,50NTRFNONREF\r\n",
+			wantErr:    false,
+		},
 	}
 	for _, tt := range tests {
 		t1.Run(tt.name, func(t1 *testing.T) {
@@ -197,7 +221,7 @@ func Test_ingtransaction_createMultipurposeField(t1 *testing.T) {
 				saldo:           nil,
 				amount:          nil,
 			},
-			wantWriter: ":86:005?00Lastschrift?20KREF+NONREF\r\n",
+			wantWriter: ":86:005?00Lastschrift\r\n",
 			wantErr:    false,
 		},
 		{
@@ -211,7 +235,7 @@ func Test_ingtransaction_createMultipurposeField(t1 *testing.T) {
 				saldo:           nil,
 				amount:          nil,
 			},
-			wantWriter: ":86:005?00Lastschrift?20SVWZ+test?21KREF+NONREF\r\n",
+			wantWriter: ":86:005?00Lastschrift?20SVWZ+test\r\n",
 			wantErr:    false,
 		},
 		{
@@ -225,7 +249,21 @@ func Test_ingtransaction_createMultipurposeField(t1 *testing.T) {
 				saldo:           nil,
 				amount:          nil,
 			},
-			wantWriter: ":86:005?00Lastschrift?20SVWZ+test?21KREF+NONREF?32testname\r\n",
+			wantWriter: ":86:005?00Lastschrift?20SVWZ+test?32testname\r\n",
+			wantErr:    false,
+		},
+		{
+			name: "reference line with sepa references",
+			transaction: &ingTransaction{
+				date:            time.Date(2000, 01, 02, 0, 0, 0, 0, time.UTC),
+				valueDate:       time.Date(2000, 01, 02, 0, 0, 0, 0, time.UTC),
+				payee:           "testname",
+				transactionType: "Lastschrift",
+				reference:       "test Mandatsreferenz: M-1 Glaeubiger-ID: DE98ZZZ09999999999",
+				saldo:           nil,
+				amount:          nil,
+			},
+			wantWriter: ":86:005?00Lastschrift?20MREF+M-1?21CRED+DE98ZZZ09999999999?22SVWZ+tes\r\nt?32testname\r\n",
 			wantErr:    false,
 		},
 		{
@@ -239,7 +277,7 @@ func Test_ingtransaction_createMultipurposeField(t1 *testing.T) {
 				saldo:           nil,
 				amount:          nil,
 			},
-			wantWriter: ":86:020?00UEberweisung?20SVWZ+test?21KREF+NONREF?32testname\r\n",
+			wantWriter: ":86:020?00UEberweisung?20SVWZ+test?32testname\r\n",
 			wantErr:    false,
 		},
 		{
@@ -263,7 +301,7 @@ func Test_ingtransaction_createMultipurposeField(t1 *testing.T) {
 				valueDate:       time.Date(2000, 01, 02, 0, 0, 0, 0, time.UTC),
 				payee:           "testname",
 				transactionType: "Lastschrift",
-				reference:       strings.Repeat("a", 8*27),
+				reference:       strings.Repeat("a", 10*27),
 				saldo:           nil,
 				amount:          nil,
 			},
@@ -299,7 +337,7 @@ func Test_ingtransaction_createMultipurposeField(t1 *testing.T) {
 				"005?00Lastschrift?20SVWZ+aaaaaaaaaaaaaaaaaaaaaa?21aaaaaaaaaaaaaaa",
 				"aaaaaaaaaaaa?22aaaaaaaaaaaaaaaaaaaaaaaaaaa?23aaaaaaaaaaaaaaaaaaaa",
 				"aaaaaaa?24aaaaaaaaaaaaaaaaaaaaaaaaaaa?25aaaaaaaaaaaaaaaaaaaaaaaaa",
-				"aa?26aaaaaaaaaaaaaaaaaaaaaaaaaaa?27aaaaa?28KREF+NONREF?32testname",
+				"aa?26aaaaaaaaaaaaaaaaaaaaaaaaaaa?27aaaaa?32testname",
 			}, "\r\n")),
 			wantErr: false,
 		},
@@ -315,8 +353,8 @@ func Test_ingtransaction_createMultipurposeField(t1 *testing.T) {
 				amount:          nil,
 			},
 			wantWriter: fmt.Sprintf(":86:%s\r\n", strings.Join([]string{
-				"005?00Lastschrift?20SVWZ+aaaaaaaaaaaaaaaaaaaaaa?21aaaaa?22KREF+NO",
-				"NREF?32bbbbbbbbbbbbbbbbbbbbbbbbbbb?33bbbbbbbbbbbbbbbbbbbbbbbbbb",
+				"005?00Lastschrift?20SVWZ+aaaaaaaaaaaaaaaaaaaaaa?21aaaaa?32bbbbbbb",
+				"bbbbbbbbbbbbbbbbbbbb?33bbbbbbbbbbbbbbbbbbbbbbbbbb",
 			}, "\r\n")),
 			wantErr: false,
 		},
@@ -356,7 +394,7 @@ func Test_ingtransaction_ConvertTOMT940(t1 *testing.T) {
 				saldo:           money.New(5000, "EUR"),
 				amount:          money.New(-1050, "EUR"),
 			},
-			wantWriter: ":61:0001020102D10,50NTRFNONREF\r\n:86:805?00Abschluss?20SVWZ+test?21KREF+NONREF?32testname\r\n",
+			wantWriter: ":61:0001020102D10,50NINTNONREF\r\n:86:805?00Abschluss?20SVWZ+test?32testname\r\n",
 			wantErr:    false,
 		},
 	}
@@ -1,11 +1,17 @@
 package n26
 
 import (
+	"bytes"
+	"context"
 	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"os"
-	"strings"
 
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/iban"
 	"github.com/JHeimbach/csvtomt940/mt940"
 	"github.com/Rhymond/go-money"
 )
@@ -15,7 +21,32 @@ type N26 struct {
 	StartSaldo  int64
 	HasCategory bool
 	logger      *log.Logger
-	data        *mt940.BankData
+}
+
+func init() {
+	banks.Register("n26", func(opts banks.Options) (mt940.Bank, error) {
+		if opts.Iban == "" {
+			return nil, errors.New("parser for N26 needs iban provided")
+		}
+		if opts.StartSaldo == 0 {
+			log.Println("WARNING: N26 has no Saldo in its transaction statements, do you mean to start with saldo = 0?")
+		}
+		return New(opts.Iban, opts.StartSaldo, opts.HasCategory), nil
+	}, Detect)
+}
+
+// Detect returns the confidence that peek is the beginning of a n26 csv export,
+// n26 exports start with a quoted header row in german or english
+func Detect(peek []byte) float64 {
+	peek = bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf"))
+	if bytes.HasPrefix(peek, []byte(`"Datum","Empfänger","Kontonummer","Transaktionstyp"`)) ||
+		bytes.HasPrefix(peek, []byte(`"Date","Payee","Account number","Transaction type"`)) {
+		return 1
+	}
+	if bytes.HasPrefix(peek, []byte(`"Datum","Empf`)) || bytes.HasPrefix(peek, []byte(`"Date","Payee"`)) {
+		return 0.6
+	}
+	return 0
 }
 
 func New(iban string, startSaldo int64, hasCategory bool) *N26 {
@@ -30,51 +61,60 @@ func New(iban string, startSaldo int64, hasCategory bool) *N26 {
 	}
 }
 
-func (n *N26) ParseCsv(csvFile *os.File) *mt940.BankData {
+// Parse reads the n26 csv export from r and converts it into BankData
+func (n *N26) Parse(ctx context.Context, r io.Reader) (*mt940.BankData, error) {
 	// extract banknumber and accountnumber from meta fields
-	bankNumber, accountNumber := extractAccountAndBankNumber(n.Iban)
+	bankNumber, accountNumber, err := iban.Split(n.Iban)
+	if err != nil {
+		return nil, err
+	}
 
-	n.data = &mt940.BankData{
+	data := &mt940.BankData{
+		IBAN:          iban.Normalize(n.Iban),
 		AccountNumber: accountNumber,
 		BankNumber:    bankNumber,
 	}
 
 	// read rest of the file as csv
-	cr := csv.NewReader(csvFile)
+	cr := csv.NewReader(r)
 	cr.Comma = ','
 	cr.LazyQuotes = true
 	// header line
-	_, err := cr.Read()
+	_, err = cr.Read()
 	if err != nil {
-		n.logger.Fatalf("could not read data from csv %v", err)
+		return nil, fmt.Errorf("could not read header from csv: %w", err)
 	}
 
-	transactions, err := cr.ReadAll()
-	if err != nil {
-		n.logger.Fatalf("could not read data from csv %v", err)
-	}
 	saldo := money.New(n.StartSaldo, "EUR")
-	// create ingTransaction structs
-	var ta = make([]mt940.Transaction, 0, len(transactions))
-	for j, t := range transactions {
-		ts, nSaldo, err := newTransactionFromCsv(t, saldo, n.HasCategory)
+	// create n26Transaction structs
+	var ta []mt940.Transaction
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		entry, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			log.Fatalf("could not convert entry to struct in line %d: %v", j, err)
+			return nil, fmt.Errorf("could not read data from csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		ts, nSaldo, err := newTransactionFromCsv(entry, saldo, n.HasCategory)
+		if err != nil {
+			var pErr *mt940.ParseError
+			if errors.As(err, &pErr) {
+				pErr.Line = line
+				return nil, pErr
+			}
+			return nil, fmt.Errorf("could not convert entry to struct in line %d: %w", line, err)
 		}
 		saldo = nSaldo
 		ta = append(ta, ts)
 	}
 
-	n.data.Transactions = ta
-
-	return n.data
-}
+	data.Transactions = ta
 
-// extractAccountAndBankNumber returns blz and accountNumber from meta tags of the ING csv
-func extractAccountAndBankNumber(iban string) (string, string) {
-	// replace all whitespaces
-	iban = strings.ReplaceAll(iban, " ", "")
-	// blz begins in position 4 and has 8 chars
-	// accountNumber begins in position 12 and has 10 chars (until the end of iban)
-	return iban[4:12], strings.TrimSpace(iban[12:])
+	return data, nil
 }
@@ -1,35 +1,114 @@
 package n26
 
-import "testing"
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
 
-func Test_extractAccountAndBankNumber(t *testing.T) {
+	"github.com/JHeimbach/csvtomt940/blz"
+)
+
+// TestMain uses the excerpt of the bank code file because the embedded file is only complete after go generate
+func TestMain(m *testing.M) {
+	d, err := blz.Load("../../blz/testdata/blz.txt")
+	if err != nil {
+		panic(err)
+	}
+	blz.SetDefault(d)
+	os.Exit(m.Run())
+}
+
+const testCsv = `"Datum","Empfänger","Kontonummer","Transaktionstyp","Verwendungszweck","Kategorie","Betrag (EUR)","Betrag (Fremdwährung)","Fremdwährung","Wechselkurs"
+"2021-02-08","Yabox","DE00111111110000000000","Gutschrift","Grass-roots systemic pricing structure","Medien & Elektronik","16.2","","",""
+"2021-02-08","Yabox","DE00111111110000000000","Lastschrift","Grass-roots systemic pricing structure","Medien & Elektronik","-1.62","","",""
+`
+
+func TestN26_Parse(t *testing.T) {
 	tests := []struct {
-		name          string
-		iban          string
-		bankNumber    string
-		accountNumber string
+		name      string
+		iban      string
+		input     string
+		wantSaldo int64
+		wantBank  string
+		wantAcc   string
+		wantErr   string
 	}{
 		{
-			name:          "without spaces",
-			iban:          "DE00111111110000000000",
-			bankNumber:    "11111111",
-			accountNumber: "0000000000",
+			name:      "valid csv",
+			iban:      "DE13 1111 1111 0000 0000 00",
+			input:     testCsv,
+			wantSaldo: 2458,
+			wantBank:  "11111111",
+			wantAcc:   "0000000000",
+		},
+		{
+			name:      "austrian iban",
+			iban:      "AT611904300234573201",
+			input:     testCsv,
+			wantSaldo: 2458,
+			wantBank:  "19043",
+			wantAcc:   "00234573201",
+		},
+		{
+			name:    "invalid checksum",
+			iban:    "DE00111111110000000000",
+			input:   testCsv,
+			wantErr: "iban DE00111111110000000000 has an invalid checksum",
 		},
 		{
-			name:          "with spaces",
-			iban:          "DE22 1111 1111 0000 0000 00",
-			bankNumber:    "11111111",
-			accountNumber: "0000000000",
+			name:    "invalid iban",
+			iban:    "DE00",
+			input:   testCsv,
+			wantErr: "iban DE00 must have 22 characters, got 4",
+		},
+		{
+			name:    "invalid date",
+			iban:    "DE13111111110000000000",
+			input:   strings.Replace(testCsv, `"2021-02-08","Yabox","DE00111111110000000000","Lastschrift"`, `"2021-0208","Yabox","DE00111111110000000000","Lastschrift"`, 1),
+			wantErr: `line 3: could not parse date from "2021-0208": parsing time "2021-0208" as "2006-01-02": cannot parse "08" as "-"`,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			bankNumber, accountNumber := extractAccountAndBankNumber(tt.iban)
-			if bankNumber != tt.bankNumber {
-				t.Errorf("extractAccountAndBankNumber() bankNumber = %v, accountNumber %v", bankNumber, tt.bankNumber)
+			got, err := New(tt.iban, 1000, true).Parse(context.Background(), strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
 			}
-			if accountNumber != tt.accountNumber {
-				t.Errorf("extractAccountAndBankNumber() accountNumber = %v, accountNumber %v", accountNumber, tt.accountNumber)
+			if len(got.Transactions) != 2 {
+				t.Fatalf("Parse() got %d transactions, want 2", len(got.Transactions))
+			}
+			if s := got.Transactions[1].Saldo().Amount(); s != tt.wantSaldo {
+				t.Errorf("Parse() saldo = %d, want %d", s, tt.wantSaldo)
+			}
+			if got.BankNumber != tt.wantBank || got.AccountNumber != tt.wantAcc {
+				t.Errorf("Parse() account = %s/%s, want %s/%s", got.BankNumber, got.AccountNumber, tt.wantBank, tt.wantAcc)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		peek string
+		want float64
+	}{
+		{name: "german export", peek: testCsv, want: 1},
+		{name: "english export", peek: `"Date","Payee","Account number","Transaction type","Payment reference"`, want: 1},
+		{name: "changed columns", peek: `"Date","Payee","IBAN"`, want: 0.6},
+		{name: "other csv", peek: "Umsatzanzeige;Datei erstellt am: 07.03.2021 13:18", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.peek)); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/JHeimbach/csvtomt940/converter"
-	"github.com/JHeimbach/csvtomt940/formatter"
+	"github.com/JHeimbach/csvtomt940/mt940"
 	"github.com/Rhymond/go-money"
 )
 
@@ -49,10 +49,12 @@ var gvcCodes = map[string]string{
 type n26Transaction struct {
 	date                  time.Time
 	payee                 string
+	name                  string
 	transactionType       string
 	transactionTypeLookup string
 	category              string
 	reference             string
+	counterparty          mt940.CounterpartyAccount
 	saldo                 *money.Money
 	amount                *money.Money
 }
@@ -69,20 +71,44 @@ func (n *n26Transaction) Date() time.Time {
 	return n.date
 }
 
+// Category returns category field, it is empty if the csv has no category column
+func (n *n26Transaction) Category() string {
+	return n.category
+}
+
+// Counterparty returns the complete payee, the payee of ?32 and ?33 is cut to 54 characters,
+// and the iban of the kontonummer column with the bank code derived from it
+func (n *n26Transaction) Counterparty() mt940.Counterparty {
+	return mt940.Counterparty{Name: strings.TrimSpace(n.name), Account: n.counterparty}
+}
+
+// References returns the SEPA references of the Verwendungszweck
+func (n *n26Transaction) References() converter.SEPAReferences {
+	return converter.ParseSEPA(n.reference)
+}
+
 func newTransactionFromCsv(entry []string, startSaldo *money.Money, hasCategory bool) (*n26Transaction, *money.Money, error) {
 	var offset = 0
 	if !hasCategory {
 		offset = -1
 	}
 
+	if len(entry) <= amount+offset {
+		return nil, nil, &mt940.ParseError{
+			Column: "entry",
+			Value:  strings.Join(entry, ","),
+			Err:    fmt.Errorf("expected at least %d columns, got %d", amount+offset+1, len(entry)),
+		}
+	}
+
 	tDate, err := time.Parse("2006-01-02", entry[date])
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not parse date from %s: %w", entry[date], err)
+		return nil, nil, &mt940.ParseError{Column: "date", Value: entry[date], Err: err}
 	}
 
 	tAmount, err := converter.MoneyStringToInt(getAmount(entry[amount+offset]))
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not parse amount to int: %w", err)
+		return nil, nil, &mt940.ParseError{Column: "amount", Value: entry[amount+offset], Err: err}
 	}
 	tAmountMoney := money.New(int64(tAmount), "EUR")
 
@@ -109,14 +135,19 @@ func newTransactionFromCsv(entry []string, startSaldo *money.Money, hasCategory
 	transaction := &n26Transaction{
 		date:                  tDate,
 		payee:                 payeeText,
+		name:                  entry[payee],
 		transactionType:       tType,
 		transactionTypeLookup: ttLookup,
-		category:              entry[category],
 		reference:             entry[reference],
+		counterparty:          mt940.NewCounterpartyAccount(entry[accountNumber], ""),
 		saldo:                 saldo,
 		amount:                tAmountMoney,
 	}
 
+	if hasCategory {
+		transaction.category = entry[category]
+	}
+
 	return transaction, saldo, nil
 }
 
@@ -146,21 +177,9 @@ func (n *n26Transaction) ConvertToMT940(writer io.Writer) error {
 }
 
 func (n *n26Transaction) createSalesLine(writer io.Writer) error {
-	// :61:<ValueDate><Date><IsCreditOrDebit><Amount>NTRFNONREF
-	// :61:_YYMMDD_MMDD_C/D_00,00NTRFNONREF
-	_, err := writer.Write(
-		[]byte(fmt.Sprintf(":61:%s%s%s%sNTRFNONREF\r\n",
-			n.date.Format("060102"),
-			n.date.Format("0102"),
-			converter.IsCreditOrDebit(n.Amount()),
-			formatter.ConvertMoneyToString(n.Amount().Absolute()),
-		)),
-	)
-
-	if err != nil {
-		return fmt.Errorf("could not create sales line: %w", err)
-	}
-	return nil
+	// the type code and the reversal mark are derived from the gvc code, the end to end reference is the customer reference
+	sales := mt940.NewSalesLine(n.date, n.date, n.Amount(), gvcCodes[n.transactionTypeLookup], n.References().EndToEnd)
+	return sales.Write(writer)
 }
 
 // createMultipurposeLine creates :86: line for MT940 from transaction
@@ -181,14 +200,14 @@ func (n *n26Transaction) createMultipurposeLine(writer io.Writer) error {
 		return fmt.Errorf("could not convert reference line: %w", err)
 	}
 
-	lineStr := fmt.Sprintf("%s?00%s%s%s", gvcCode, converter.ConvertUmlauts(n.transactionType), u, c)
+	lineStr := fmt.Sprintf("%s?00%s%s%s%s", gvcCode, converter.ConvertUmlauts(n.transactionType), u, n.counterparty.Subfields(), c)
 	if len(lineStr) > 390 {
 		return fmt.Errorf("mulitpurpose line is too long")
 	}
 	lineParts := converter.SplitStringInParts(lineStr, 65, false)
 
-	// :86:<GVCCode>?00<GVCText>?20..29<MEMO>?32<Payee>
-	//:86:999?00BuchungsText?20...?29Verwendungszweck?32Auftraggeber
+	// :86:<GVCCode>?00<GVCText>?20..29<MEMO>?30<BLZ>?31<IBAN>?32<Payee>
+	//:86:999?00BuchungsText?20...?29Verwendungszweck?30BLZ?31IBAN?32Auftraggeber
 	_, err = writer.Write(
 		[]byte(
 			fmt.Sprintf(
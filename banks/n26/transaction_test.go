@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/JHeimbach/csvtomt940/mt940"
 	"github.com/Rhymond/go-money"
 )
 
@@ -32,7 +33,7 @@ func Test_newTransactionFromCSV(t *testing.T) {
 			name:    "time is invalid",
 			entry:   []string{"2000-0102", "", "", "", "", "", "", "", "", ""},
 			want:    nil,
-			wantErr: fmt.Errorf("could not parse date from 2000-0102: %w", errors.New("parsing time \"2000-0102\" as \"2006-01-02\": cannot parse \"02\" as \"-\"")),
+			wantErr: &mt940.ParseError{Column: "date", Value: "2000-0102", Err: errors.New("parsing time \"2000-0102\" as \"2006-01-02\": cannot parse \"02\" as \"-\"")},
 		},
 		{
 			name:  "both money values are valid",
@@ -48,17 +49,18 @@ func Test_newTransactionFromCSV(t *testing.T) {
 			name:    "amount money is invalid",
 			entry:   []string{"2000-01-02", "", "", "", "", "", "12-00", "", "", ""},
 			want:    nil,
-			wantErr: fmt.Errorf("could not parse amount to int: %w", errors.New("strconv.Atoi: parsing \"12-00\": invalid syntax")),
+			wantErr: &mt940.ParseError{Column: "amount", Value: "12-00", Err: errors.New("strconv.Atoi: parsing \"12-00\": invalid syntax")},
 		},
 		{
 			name:  "string fields are set",
-			entry: []string{"2000-01-02", "test", "test2", "Income", "reference", "Salary", "12.00", "", "", ""},
+			entry: []string{"2000-01-02", "test", "DE89 3704 0044 0532 0130 00", "Income", "reference", "Salary", "12.00", "", "", ""},
 			want: &n26Transaction{
 				date:            time.Date(2000, 01, 02, 00, 00, 00, 00, time.UTC),
 				payee:           "test",
 				transactionType: "Income",
 				reference:       "reference",
 				category:        "Salary",
+				counterparty:    mt940.CounterpartyAccount{AccountNumber: "DE89370400440532013000", BankCode: "COBADEFFXXX"},
 				saldo:           money.New(1200, "EUR"),
 				amount:          money.New(1200, "EUR"),
 			},
@@ -128,6 +130,16 @@ func Test_newTransactionFromCSV(t *testing.T) {
 	}
 }
 
+func Test_newTransactionFromCSV_WithoutCategory(t *testing.T) {
+	got, _, err := newTransactionFromCsv([]string{"2000-01-02", "test", "test2", "Income", "reference", "12.00", "", "", ""}, money.New(0, "EUR"), false)
+	if err != nil {
+		t.Fatalf("newTransactionFromCsv() error = %v", err)
+	}
+	if got.Category() != "" || got.Amount().Amount() != 1200 {
+		t.Errorf("newTransactionFromCsv() category = %q, amount = %d", got.Category(), got.Amount().Amount())
+	}
+}
+
 func Test_newTransactionFromCSV_CalculatesSaldoCorrect(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -255,6 +267,9 @@ func transactionsAreEqual(t *testing.T, a *n26Transaction, b *n26Transaction) {
 	if a.reference != b.reference {
 		t.Fatalf("reference is not equal: %s !== %s", a.reference, b.reference)
 	}
+	if b.counterparty != (mt940.CounterpartyAccount{}) && a.counterparty != b.counterparty {
+		t.Fatalf("counterparty is not equal: %+v !== %+v", a.counterparty, b.counterparty)
+	}
 	if ok, _ := a.saldo.Equals(b.saldo); !ok {
 		t.Fatalf("saldo is not equal: %s !== %s", a.saldo.Display(), b.saldo.Display())
 	}
@@ -284,7 +299,7 @@ func Test_n26transaction_ConvertTOMT940(t1 *testing.T) {
 				saldo:                 money.New(5000, "EUR"),
 				amount:                money.New(-1050, "EUR"),
 			},
-			wantWriter: ":61:0001020102D10,50NTRFNONREF\r\n:86:020?00UEberweisung?20SVWZ+test?21KREF+NONREF?32testname\r\n",
+			wantWriter: ":61:0001020102D10,50NTRFNONREF\r\n:86:020?00UEberweisung?20SVWZ+test?32testname\r\n",
 			wantErr:    false,
 		},
 	}
@@ -336,6 +351,27 @@ func Test_n26transaction_createSalesLine(t1 *testing.T) {
 			wantWriter: ":61:0001020102D10,50NTRFNONREF\r\n",
 			wantErr:    false,
 		},
+		{
+			name: "create salesline of direct debit",
+			transaction: &n26Transaction{
+				date:                  time.Date(2000, 01, 02, 0, 0, 0, 0, time.UTC),
+				transactionTypeLookup: "Direct Debit",
+				reference:             "EREF+INV/2000/17 SVWZ+Invoice",
+				amount:                money.New(-1050, "EUR"),
+			},
+			wantWriter: ":61:0001020102D10,50NDDTINV/2000/17\r\n",
+			wantErr:    false,
+		},
+		{
+			name: "create salesline of fee",
+			transaction: &n26Transaction{
+				date:                  time.Date(2000, 01, 02, 0, 0, 0, 0, time.UTC),
+				transactionTypeLookup: "Fee",
+				amount:                money.New(-100, "EUR"),
+			},
+			wantWriter: ":61:0001020102D1,00NCHGNONREF\r\n",
+			wantErr:    false,
+		},
 	}
 	for _, tt := range tests {
 		t1.Run(tt.name, func(t1 *testing.T) {
@@ -370,7 +406,7 @@ func Test_n26transaction_createMultipurposeField(t1 *testing.T) {
 				saldo:                 nil,
 				amount:                nil,
 			},
-			wantWriter: ":86:004?00MasterCard Payment?20KREF+NONREF\r\n",
+			wantWriter: ":86:004?00MasterCard Payment\r\n",
 			wantErr:    false,
 		},
 		{
@@ -384,7 +420,7 @@ func Test_n26transaction_createMultipurposeField(t1 *testing.T) {
 				saldo:                 nil,
 				amount:                nil,
 			},
-			wantWriter: ":86:004?00MasterCard Payment?20SVWZ+test?21KREF+NONREF\r\n",
+			wantWriter: ":86:004?00MasterCard Payment?20SVWZ+test\r\n",
 			wantErr:    false,
 		},
 		{
@@ -398,7 +434,20 @@ func Test_n26transaction_createMultipurposeField(t1 *testing.T) {
 				saldo:                 nil,
 				amount:                nil,
 			},
-			wantWriter: ":86:004?00MasterCard Payment?20SVWZ+test?21KREF+NONREF?32testname\r\n",
+			wantWriter: ":86:004?00MasterCard Payment?20SVWZ+test?32testname\r\n",
+			wantErr:    false,
+		},
+		{
+			name: "with counterparty account",
+			transaction: &n26Transaction{
+				date:                  time.Date(2000, 01, 02, 0, 0, 0, 0, time.UTC),
+				payee:                 "testname",
+				transactionType:       "Lastschrift",
+				transactionTypeLookup: "Lastschrift",
+				reference:             "test",
+				counterparty:          mt940.CounterpartyAccount{AccountNumber: "DE89370400440532013000", BankCode: "37040044"},
+			},
+			wantWriter: ":86:005?00Lastschrift?20SVWZ+test?3037040044?31DE89370400440532013000\r\n?32testname\r\n",
 			wantErr:    false,
 		},
 		{
@@ -412,7 +461,7 @@ func Test_n26transaction_createMultipurposeField(t1 *testing.T) {
 				saldo:                 nil,
 				amount:                nil,
 			},
-			wantWriter: ":86:020?00UEberweisung?20SVWZ+test?21KREF+NONREF?32testname\r\n",
+			wantWriter: ":86:020?00UEberweisung?20SVWZ+test?32testname\r\n",
 			wantErr:    false,
 		},
 		{
@@ -435,7 +484,7 @@ func Test_n26transaction_createMultipurposeField(t1 *testing.T) {
 				payee:                 "testname",
 				transactionType:       "MasterCard Payment",
 				transactionTypeLookup: "MasterCard Payment Debit",
-				reference:             strings.Repeat("a", 8*27),
+				reference:             strings.Repeat("a", 10*27),
 				saldo:                 nil,
 				amount:                nil,
 			},
@@ -471,8 +520,7 @@ func Test_n26transaction_createMultipurposeField(t1 *testing.T) {
 				"004?00MasterCard Payment?20SVWZ+aaaaaaaaaaaaaaaaaaaaaa?21aaaaaaaa",
 				"aaaaaaaaaaaaaaaaaaa?22aaaaaaaaaaaaaaaaaaaaaaaaaaa?23aaaaaaaaaaaaa",
 				"aaaaaaaaaaaaaa?24aaaaaaaaaaaaaaaaaaaaaaaaaaa?25aaaaaaaaaaaaaaaaaa",
-				"aaaaaaaaa?26aaaaaaaaaaaaaaaaaaaaaaaaaaa?27aaaaa?28KREF+NONREF?32t",
-				"estname",
+				"aaaaaaaaa?26aaaaaaaaaaaaaaaaaaaaaaaaaaa?27aaaaa?32testname",
 			}, "\r\n")),
 			wantErr: false,
 		},
@@ -488,9 +536,8 @@ func Test_n26transaction_createMultipurposeField(t1 *testing.T) {
 				amount:                nil,
 			},
 			wantWriter: fmt.Sprintf(":86:%s\r\n", strings.Join([]string{
-				"004?00MasterCard Payment?20SVWZ+aaaaaaaaaaaaaaaaaaaaaa?21aaaaa?22",
-				"KREF+NONREF?32bbbbbbbbbbbbbbbbbbbbbbbbbbb?33bbbbbbbbbbbbbbbbbbbbb",
-				"bbbbb",
+				"004?00MasterCard Payment?20SVWZ+aaaaaaaaaaaaaaaaaaaaaa?21aaaaa?32",
+				"bbbbbbbbbbbbbbbbbbbbbbbbbbb?33bbbbbbbbbbbbbbbbbbbbbbbbbb",
 			}, "\r\n")),
 			wantErr: false,
 		},
@@ -0,0 +1,150 @@
+package paypal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+// bankNumber is written to :25: of every statement, the account is identified by the currency
+const bankNumber = "PAYPAL"
+
+type PayPal struct {
+	logger *log.Logger
+}
+
+func init() {
+	banks.Register("paypal", func(opts banks.Options) (mt940.Bank, error) {
+		return New(), nil
+	}, Detect)
+}
+
+// Detect returns the confidence that peek is the beginning of a german paypal activity download
+func Detect(peek []byte) float64 {
+	peek = bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf"))
+	switch {
+	case bytes.HasPrefix(peek, []byte(`"Datum","Uhrzeit","Zeitzone","Name","Typ","Status","Währung","Brutto","Gebühr","Netto"`)):
+		return 1
+	case bytes.HasPrefix(peek, []byte(`"Datum","Uhrzeit","Zeitzone",`)):
+		return 0.8
+	}
+	return 0
+}
+
+func New() *PayPal {
+	logger := log.New(os.Stdout, "[PAYPAL] ", log.Lmsgprefix)
+
+	return &PayPal{
+		logger: logger,
+	}
+}
+
+// Parse returns the statement of the first currency of the paypal activity download
+func (p *PayPal) Parse(ctx context.Context, r io.Reader) (*mt940.BankData, error) {
+	all, err := p.ParseAll(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return all[0], nil
+}
+
+// ParseAll reads the paypal activity download from r and returns a BankData for every currency
+// in the order of their first transaction, the saldo is taken from the Guthaben column
+func (p *PayPal) ParseAll(ctx context.Context, r io.Reader) ([]*mt940.BankData, error) {
+	statement := banks.CurrencyCSV{Required: requiredColumns, Logger: p.logger}
+	var rows []*row
+	err := statement.ReadRows(ctx, r, func(fields banks.Row) (string, error) {
+		row, err := parseRow(fields)
+		if err != nil {
+			return "", err
+		}
+		if row.skip == "" {
+			rows = append(rows, row)
+		}
+		return row.skip, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// older downloads are sorted descending, the newest transaction is the first one
+	if len(rows) > 0 && rows[0].time.After(rows[len(rows)-1].time) {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].time.Before(rows[j].time)
+	})
+	rows = netConversions(rows)
+
+	var ta []mt940.Transaction
+	for _, row := range rows {
+		ta = append(ta, row.statementLines()...)
+	}
+	return statement.Statements(mt940.BankData{BankNumber: bankNumber}, ta)
+}
+
+// netConversions merges the currency conversions into the payment they belong to,
+// the conversion into the currency of the payment is added to its gross amount so that a payment
+// that is completely paid by a conversion disappears from its currency, the conversion from the
+// other currency keeps its amount and gets the details of the payment
+func netConversions(rows []*row) []*row {
+	index := make(map[*row]int, len(rows))
+	payments := map[string]*row{}
+	for i, r := range rows {
+		index[r] = i
+		if !r.isConversion() && r.code != "" {
+			payments[r.code] = r
+		}
+	}
+
+	merged := map[*row]bool{}
+	// last is the last conversion of a payment in its currency, the payment takes its position and balance
+	last := map[*row]*row{}
+	for _, r := range rows {
+		payment, ok := payments[r.relatedCode]
+		if !ok || !r.isConversion() {
+			continue
+		}
+		if r.currency != payment.currency {
+			r.takeDetails(payment)
+			continue
+		}
+		payment.gross, _ = payment.gross.Add(r.gross)
+		merged[r] = true
+		if index[r] > index[payment] {
+			last[payment] = r
+		}
+	}
+	moved := make(map[*row]*row, len(last))
+	for payment, r := range last {
+		payment.balance = r.balance
+		moved[r] = payment
+	}
+
+	result := make([]*row, 0, len(rows))
+	for _, r := range rows {
+		if _, ok := last[r]; ok {
+			continue
+		}
+		if merged[r] {
+			payment, ok := moved[r]
+			if !ok {
+				continue
+			}
+			r = payment
+		}
+		if r.gross.IsZero() && r.fee.IsZero() {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
@@ -0,0 +1,200 @@
+package paypal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+const testCsv = "\xef\xbb\xbf" + `"Datum","Uhrzeit","Zeitzone","Name","Typ","Status","Währung","Brutto","Gebühr","Netto","Transaktionscode","Zugehöriger Transaktionscode","Guthaben","Betreff","Auswirkung auf Guthaben"
+"02.01.2023","10:00:00","CET","Test Tester","Bankgutschrift auf PayPal-Konto","Abgeschlossen","EUR","100,00","0,00","100,00","1AA11111AA1111111","","100,00","","Haben"
+"03.01.2023","12:00:00","CET","Yabox","Website-Zahlung","Abgeschlossen","EUR","25,00","-1,10","23,90","2BB22222BB2222222","","123,90","Rechnung 42","Haben"
+"04.01.2023","09:00:00","CET","US Shop","PayPal Express-Zahlung","Abgeschlossen","USD","-10,00","0,00","-10,00","3CC33333CC3333333","","-10,00","Order 7","Soll"
+"04.01.2023","09:00:00","CET","","Allgemeine Währungsumrechnung","Abgeschlossen","EUR","-9,20","0,00","-9,20","4DD44444DD4444444","3CC33333CC3333333","114,70","","Soll"
+"04.01.2023","09:00:00","CET","","Allgemeine Währungsumrechnung","Abgeschlossen","USD","10,00","0,00","10,00","5EE55555EE5555555","3CC33333CC3333333","0,00","","Haben"
+"05.01.2023","08:00:00","CET","Yabox","Allgemeine Zahlung","Ausstehend","EUR","-5,00","0,00","-5,00","6FF66666FF6666666","","114,70","","Soll"
+"05.01.2023","08:00:00","CET","Yabox","Allgemeine Autorisierung","Abgeschlossen","EUR","-5,00","0,00","-5,00","7GG77777GG7777777","","114,70","","Memo"
+"06.01.2023","18:00:00","CET","Friend","Allgemeine Zahlung","Abgeschlossen","USD","5,00","0,00","5,00","8HH88888HH8888888","","5,00","Thanks","Haben"
+`
+
+func TestPayPal_ParseAll(t *testing.T) {
+	var logs strings.Builder
+	p := New()
+	p.logger = log.New(&logs, "", 0)
+
+	got, err := p.ParseAll(context.Background(), strings.NewReader(testCsv))
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	for _, skipped := range []string{"skipped 1 ausstehend transactions", "skipped 1 memo transactions"} {
+		if !strings.Contains(logs.String(), skipped) {
+			t.Errorf("ParseAll() logs = %q, want %q", logs.String(), skipped)
+		}
+	}
+	if strings.Contains(logs.String(), "WARNING") {
+		t.Errorf("ParseAll() logs = %q, want no balance warnings", logs.String())
+	}
+	if len(got) != 2 {
+		t.Fatalf("ParseAll() got %d currencies, want 2", len(got))
+	}
+
+	var buf bytes.Buffer
+	if err := got[0].ConvertToMT940(&buf); err != nil {
+		t.Fatalf("ConvertToMT940() error = %v", err)
+	}
+	want := ":20:CSVTOMT940\r\n" +
+		":25:PAYPAL/EUR\r\n" +
+		":28C:0\r\n" +
+		":60F:C230102EUR0,00\r\n" +
+		":61:2301020102C100,00NTRFNONREF//1AA11111AA111111\r\n" +
+		"1AA11111AA1111111\r\n" +
+		":86:051?00Bankgutschrift auf PayPal-K?32Test Tester\r\n" +
+		":61:2301030103C25,00NTRFNONREF//2BB22222BB222222\r\n" +
+		"2BB22222BB2222222\r\n" +
+		":86:051?00Website-Zahlung?20SVWZ+Rechnung 42?32Yabox\r\n" +
+		":61:2301030103D1,10NCHGNONREF//2BB22222BB222222\r\n" +
+		"2BB22222BB2222222\r\n" +
+		":86:808?00Gebuehr?20SVWZ+Gebuehr 2BB22222BB2222?21222?32Yabox\r\n" +
+		":61:2301040104D9,20NTRFNONREF//3CC33333CC333333\r\n" +
+		"3CC33333CC3333333\r\n" +
+		":86:020?00PayPal Express-Zahlung?20SVWZ+Order 7?32US Shop\r\n" +
+		":62F:C230104EUR114,70\r\n"
+	if buf.String() != want {
+		t.Errorf("ConvertToMT940() =\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	// the payment in USD is completely paid by the conversion and only the later credit remains
+	usd := got[1]
+	if usd.AccountNumber != "USD" || len(usd.Transactions) != 1 || usd.Transactions[0].Saldo().Amount() != 500 {
+		t.Errorf("ParseAll() usd = %s with %d transactions", usd.AccountNumber, len(usd.Transactions))
+	}
+}
+
+func Test_netConversions(t *testing.T) {
+	// the payment of 10 USD is paid with 4 USD of the balance and a conversion of 6 USD
+	input := `"Datum","Uhrzeit","Name","Typ","Status","Währung","Brutto","Gebühr","Transaktionscode","Zugehöriger Transaktionscode","Guthaben"
+"01.01.2023","08:00:00","Friend","Allgemeine Zahlung","Abgeschlossen","USD","4,00","0,00","A","","4,00"
+"01.01.2023","08:00:00","Friend","Allgemeine Zahlung","Abgeschlossen","EUR","50,00","0,00","B","","50,00"
+"04.01.2023","09:00:00","","Allgemeine Währungsumrechnung","Abgeschlossen","EUR","-5,52","0,00","C","P","44,48"
+"04.01.2023","09:00:00","","Allgemeine Währungsumrechnung","Abgeschlossen","USD","6,00","0,00","D","P","10,00"
+"04.01.2023","09:00:00","US Shop","PayPal Express-Zahlung","Abgeschlossen","USD","-10,00","0,00","P","","0,00"
+`
+	p := New()
+	p.logger = log.New(&strings.Builder{}, "", 0)
+	got, err := p.ParseAll(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ParseAll() got %d currencies, want 2", len(got))
+	}
+	tests := []struct {
+		data    *mt940.BankData
+		amounts []int64
+		saldos  []int64
+	}{
+		{data: got[0], amounts: []int64{400, -400}, saldos: []int64{400, 0}},
+		{data: got[1], amounts: []int64{5000, -552}, saldos: []int64{5000, 4448}},
+	}
+	for _, tt := range tests {
+		if len(tt.data.Transactions) != len(tt.amounts) {
+			t.Fatalf("ParseAll() %s got %d transactions, want %d", tt.data.Currency, len(tt.data.Transactions), len(tt.amounts))
+		}
+		for i, tr := range tt.data.Transactions {
+			if tr.Amount().Amount() != tt.amounts[i] || tr.Saldo().Amount() != tt.saldos[i] {
+				t.Errorf("ParseAll() %s transaction %d = %d/%d, want %d/%d", tt.data.Currency, i,
+					tr.Amount().Amount(), tr.Saldo().Amount(), tt.amounts[i], tt.saldos[i])
+			}
+			if name := tr.(*mt940.StatementLine).Details.Name; i == 1 && (len(name) == 0 || name[0] != "US Shop") {
+				t.Errorf("ParseAll() %s transaction %d name = %v, want US Shop", tt.data.Currency, i, name)
+			}
+		}
+	}
+}
+
+func TestPayPal_ParseValidStatement(t *testing.T) {
+	// the type of withdrawals contains an en dash
+	input := `"Datum","Uhrzeit","Name","Typ","Status","Währung","Brutto","Gebühr","Transaktionscode","Zugehöriger Transaktionscode","Guthaben"
+"01.01.2023","08:00:00","Friend","Allgemeine Zahlung","Abgeschlossen","EUR","50,00","0,00","A","","50,00"
+"02.01.2023","08:00:00","","Allgemeine Abbuchung – Bankkonto","Abgeschlossen","EUR","-50,00","0,00","B","","0,00"
+`
+	p := New()
+	p.logger = log.New(&strings.Builder{}, "", 0)
+	got, err := p.Parse(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := got.ConvertToMT940(&buf); err != nil {
+		t.Fatalf("ConvertToMT940() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "?00Allgemeine Abbuchung - Bank\r\n") {
+		t.Errorf("ConvertToMT940() = %q, want the en dash as hyphen", buf.String())
+	}
+	if issues := mt940.Validate(&buf); len(issues) != 0 {
+		t.Errorf("Validate() = %v, want no issues", issues)
+	}
+}
+
+func TestPayPal_ParseErrors(t *testing.T) {
+	header := `"Datum","Uhrzeit","Name","Typ","Status","Währung","Brutto","Gebühr","Transaktionscode","Guthaben"` + "\n"
+	tests := []struct {
+		name    string
+		csv     string
+		wantErr string
+	}{
+		{
+			name:    "missing column",
+			csv:     `"Datum","Uhrzeit","Name"` + "\n",
+			wantErr: `column "Typ" not found in header`,
+		},
+		{
+			name:    "invalid gross",
+			csv:     header + `"01.01.2023","08:00:00","Friend","Allgemeine Zahlung","Abgeschlossen","EUR","abc","0,00","A","4,00"` + "\n",
+			wantErr: "line 2",
+		},
+		{
+			name:    "only pending",
+			csv:     header + `"01.01.2023","08:00:00","Friend","Allgemeine Zahlung","Ausstehend","EUR","4,00","0,00","A","4,00"` + "\n",
+			wantErr: "no completed transactions found in csv",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			p.logger = log.New(&strings.Builder{}, "", 0)
+			_, err := p.Parse(context.Background(), strings.NewReader(tt.csv))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %s", err, tt.wantErr)
+			}
+			var pErr *mt940.ParseError
+			if strings.HasPrefix(tt.wantErr, "line") && !errors.As(err, &pErr) {
+				t.Errorf("Parse() error = %v, want *mt940.ParseError", err)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		peek string
+		want float64
+	}{
+		{name: "activity download", peek: testCsv, want: 1},
+		{name: "other columns", peek: `"Datum","Uhrzeit","Zeitzone","Name","Typ"`, want: 0.8},
+		{name: "other csv", peek: `"Datum","Empfänger","Kontonummer"`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.peek)); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package paypal
+
+import (
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+// column names of the german paypal activity download
+const (
+	columnDate        = "Datum"
+	columnTime        = "Uhrzeit"
+	columnName        = "Name"
+	columnType        = "Typ"
+	columnStatus      = "Status"
+	columnCurrency    = "Währung"
+	columnGross       = "Brutto"
+	columnFee         = "Gebühr"
+	columnCode        = "Transaktionscode"
+	columnRelatedCode = "Zugehöriger Transaktionscode"
+	columnBalance     = "Guthaben"
+	columnImpact      = "Auswirkung auf Guthaben"
+	columnSubject     = "Betreff"
+	columnItem        = "Artikelbezeichnung"
+	columnNote        = "Hinweis"
+)
+
+// requiredColumns have to be in the header, the other columns are optional
+var requiredColumns = []string{columnDate, columnName, columnType, columnStatus, columnCurrency, columnGross, columnFee, columnCode, columnBalance}
+
+// statusCompleted is the status of booked transactions, pending and other transactions are skipped
+const statusCompleted = "Abgeschlossen"
+
+// impactMemo marks transactions that do not change the balance, e.g. authorizations
+const impactMemo = "Memo"
+
+// typeConversion is the type of the helper rows of a payment in another currency
+const typeConversion = "Allgemeine Währungsumrechnung"
+
+// feeGVC is the gvc code of the separate fee transaction
+const feeGVC = "808"
+
+// maxPurposeParts limits the subfields ?20 to ?29 of the transaction, the fee gets maxFeePurposeParts
+const (
+	maxPurposeParts    = 8
+	maxFeePurposeParts = 2
+)
+
+// gvcCodes returns the GVC Code for the type of the transaction, note this list is not complete,
+// unknown types get the code of a transfer or a credit depending on the sign of the amount
+var gvcCodes = map[string]string{
+	"Bankgutschrift auf PayPal-Konto":       "051",
+	"Allgemeine Abbuchung – Bankkonto":      "020",
+	"Allgemeine Abbuchung":                  "020",
+	"Zahlung im Einzugsverfahren":           "005",
+	"Rückzahlung":                           "051",
+	"Zahlungsrückerstattung":                "051",
+	"Allgemeine Gutschrift mit Kreditkarte": "051",
+}
+
+// row is a completed transaction of the activity download
+type row struct {
+	time        time.Time
+	name        string
+	typ         string
+	currency    string
+	gross       *money.Money
+	fee         *money.Money
+	balance     *money.Money
+	code        string
+	relatedCode string
+	purpose     string
+	// skip is the reason why the row is skipped, e.g. the status of a pending transaction
+	skip string
+}
+
+// parseRow converts a csv line into a row, rows that do not change the balance are returned with a skip reason
+func parseRow(fields banks.Row) (*row, error) {
+	if status := fields.Value(columnStatus); status != statusCompleted {
+		return &row{skip: strings.ToLower(status)}, nil
+	}
+	if fields.Value(columnImpact) == impactMemo {
+		return &row{skip: "memo"}, nil
+	}
+
+	r := &row{
+		name:        fields.Value(columnName),
+		typ:         fields.Value(columnType),
+		currency:    strings.ToUpper(fields.Value(columnCurrency)),
+		code:        fields.Value(columnCode),
+		relatedCode: fields.Value(columnRelatedCode),
+	}
+	for _, c := range []string{columnSubject, columnItem, columnNote} {
+		if v := fields.Value(c); v != "" {
+			r.purpose = v
+			break
+		}
+	}
+
+	timestamp := fields.Value(columnDate)
+	layout := "02.01.2006"
+	if t := fields.Value(columnTime); t != "" {
+		timestamp += " " + t
+		layout += " 15:04:05"
+	}
+	var err error
+	r.time, err = time.Parse(layout, timestamp)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "date", Value: timestamp, Err: err}
+	}
+	r.gross, err = converter.ParseAmount(fields.Value(columnGross), ",", ".", r.currency)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "gross", Value: fields.Value(columnGross), Err: err}
+	}
+	r.fee, err = converter.ParseAmount(fields.Value(columnFee), ",", ".", r.currency)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "fee", Value: fields.Value(columnFee), Err: err}
+	}
+	r.balance, err = converter.ParseAmount(fields.Value(columnBalance), ",", ".", r.currency)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "balance", Value: fields.Value(columnBalance), Err: err}
+	}
+	return r, nil
+}
+
+// isConversion returns true for the currency conversion rows of a payment in another currency
+func (r *row) isConversion() bool {
+	return r.typ == typeConversion
+}
+
+// takeDetails books the conversion with the name, type, purpose and code of the payment
+func (r *row) takeDetails(payment *row) {
+	r.name = payment.name
+	r.typ = payment.typ
+	r.purpose = payment.purpose
+	r.code = payment.code
+}
+
+// statementLines returns the gross amount and the fee as separate statement lines,
+// the Guthaben is the balance after the fee so the gross amount gets the balance minus the fee
+func (r *row) statementLines() []mt940.Transaction {
+	gvc, ok := gvcCodes[r.typ]
+	if !ok {
+		gvc = "051"
+		if r.gross.IsNegative() {
+			gvc = "020"
+		}
+	}
+	reference, supplementary := bankReference(r.code)
+	balance, _ := r.balance.Subtract(r.fee)
+	references := converter.ParseSEPA(r.purpose)
+
+	var lines []mt940.Transaction
+	if !r.gross.IsZero() {
+		line := &mt940.StatementLine{
+			Sales: mt940.NewSalesLine(r.time, r.time, r.gross, gvc, references.EndToEnd),
+			Details: mt940.Details{
+				GVC:         gvc,
+				BookingText: strings.Join(converter.SplitSubfields(r.typ, 1), ""),
+			},
+			Balance: balance,
+		}
+		line.Sales.BankReference, line.Sales.SupplementaryDetails = reference, supplementary
+		line.SetReferences(references, maxPurposeParts)
+		line.SetCounterparty(mt940.Counterparty{Name: r.name})
+		lines = append(lines, line)
+	}
+	if !r.fee.IsZero() {
+		line := &mt940.StatementLine{
+			Sales: mt940.NewSalesLine(r.time, r.time, r.fee, feeGVC, ""),
+			Details: mt940.Details{
+				GVC:         feeGVC,
+				BookingText: "Gebuehr",
+			},
+			Balance: r.balance,
+		}
+		line.Sales.BankReference, line.Sales.SupplementaryDetails = reference, supplementary
+		line.SetReferences(converter.SEPAReferences{Remittance: "Gebuehr " + r.code}, maxFeePurposeParts)
+		line.SetCounterparty(mt940.Counterparty{Name: r.name})
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// bankReference returns the Transaktionscode as reference of at most 16 characters,
+// a longer code is cut and returned completely as supplementary details
+func bankReference(code string) (string, string) {
+	if len(code) <= 16 {
+		return code, ""
+	}
+	return code[:16], code
+}
@@ -0,0 +1,98 @@
+package revolut
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/iban"
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+// header is the beginning of the header line of the revolut account statement
+const header = "Type,Product,Started Date,Completed Date,Description,Amount,Fee,Currency,State,Balance"
+
+type Revolut struct {
+	Iban   string
+	logger *log.Logger
+}
+
+func init() {
+	banks.Register("revolut", func(opts banks.Options) (mt940.Bank, error) {
+		if opts.Iban == "" {
+			return nil, errors.New("parser for revolut needs iban provided, use -iban")
+		}
+		return New(opts.Iban), nil
+	}, Detect)
+}
+
+// Detect returns the confidence that peek is the beginning of a revolut account statement
+func Detect(peek []byte) float64 {
+	peek = bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf"))
+	switch {
+	case bytes.HasPrefix(peek, []byte(header)):
+		return 1
+	case bytes.HasPrefix(peek, []byte("Type,Product,Started Date,Completed Date,")):
+		return 0.8
+	}
+	return 0
+}
+
+func New(iban string) *Revolut {
+	logger := log.New(os.Stdout, "[REVOLUT] ", log.Lmsgprefix)
+
+	return &Revolut{
+		Iban:   iban,
+		logger: logger,
+	}
+}
+
+// Parse returns the first currency pocket of the revolut account statement
+func (rv *Revolut) Parse(ctx context.Context, r io.Reader) (*mt940.BankData, error) {
+	all, err := rv.ParseAll(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return all[0], nil
+}
+
+// ParseAll reads the revolut account statement from r and returns a BankData for every currency pocket
+// in the order of their first transaction, pending, reverted and declined transactions are skipped
+func (rv *Revolut) ParseAll(ctx context.Context, r io.Reader) ([]*mt940.BankData, error) {
+	accountIBAN := iban.Normalize(rv.Iban)
+	bankNumber, accountNumber, err := iban.Split(accountIBAN)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := banks.CurrencyCSV{Required: requiredColumns, Logger: rv.logger}
+	var rows []*row
+	err = statement.ReadRows(ctx, r, func(fields banks.Row) (string, error) {
+		row, err := parseRow(fields)
+		if err != nil {
+			return "", err
+		}
+		if row.skip == "" {
+			rows = append(rows, row)
+		}
+		return row.skip, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the balance follows the completion of the transactions
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].completed.Before(rows[j].completed)
+	})
+	var ta []mt940.Transaction
+	for _, row := range rows {
+		ta = append(ta, row.statementLines()...)
+	}
+	return statement.Statements(mt940.BankData{IBAN: accountIBAN, BankNumber: bankNumber, AccountNumber: accountNumber}, ta)
+}
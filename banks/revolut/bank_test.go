@@ -0,0 +1,142 @@
+package revolut
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+const testCsv = header + `
+TOPUP,Current,2023-01-02 10:00:00,2023-01-02 10:00:05,Payment from Test Tester,100.00,0.00,EUR,COMPLETED,100.00
+CARD_PAYMENT,Current,2023-01-03 12:00:00,2023-01-04 09:00:00,Yabox,-10.50,0.00,EUR,COMPLETED,89.50
+EXCHANGE,Current,2023-01-05 08:00:00,2023-01-05 08:00:01,Exchanged to USD,-20.00,0.20,EUR,COMPLETED,69.30
+EXCHANGE,Current,2023-01-05 08:00:00,2023-01-05 08:00:01,Exchanged from EUR,21.40,0.00,USD,COMPLETED,21.40
+CARD_PAYMENT,Current,2023-01-06 08:00:00,,Pending shop,-5.00,0.00,EUR,PENDING,
+TRANSFER,Current,2023-01-06 09:00:00,2023-01-06 09:00:00,To Yabox,-1.00,0.00,EUR,REVERTED,
+TRANSFER,Savings,2023-01-06 09:00:00,2023-01-06 09:00:00,To pocket,5.00,0.00,EUR,COMPLETED,5.00
+`
+
+func TestRevolut_ParseAll(t *testing.T) {
+	var logs strings.Builder
+	rv := New("LT35 3250 0123 4567 8901")
+	rv.logger = log.New(&logs, "", 0)
+
+	got, err := rv.ParseAll(context.Background(), strings.NewReader(testCsv))
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ParseAll() got %d pockets, want 2", len(got))
+	}
+	for _, skipped := range []string{"skipped 1 pending", "skipped 1 reverted", "skipped 1 savings"} {
+		if !strings.Contains(logs.String(), skipped) {
+			t.Errorf("ParseAll() logs = %q, want %q", logs.String(), skipped)
+		}
+	}
+	if strings.Contains(logs.String(), "WARNING") {
+		t.Errorf("ParseAll() logs = %q, want no balance warnings", logs.String())
+	}
+
+	eur, usd := got[0], got[1]
+	if eur.Currency != "EUR" || eur.AccountNumber != "12345678901EUR" || usd.Currency != "USD" || usd.AccountNumber != "12345678901USD" {
+		t.Errorf("ParseAll() pockets = %s/%s, %s/%s", eur.Currency, eur.AccountNumber, usd.Currency, usd.AccountNumber)
+	}
+
+	var buf bytes.Buffer
+	if err := eur.ConvertToMT940(&buf); err != nil {
+		t.Fatalf("ConvertToMT940() error = %v", err)
+	}
+	want := ":20:CSVTOMT940\r\n" +
+		":25:32500/12345678901EUR\r\n" +
+		":28C:0\r\n" +
+		":60F:C230102EUR0,00\r\n" +
+		":61:2301020102C100,00NTRFNONREF\r\n" +
+		":86:051?00TOPUP?20SVWZ+Payment from Test Test?21er\r\n" +
+		":61:2301030104D10,50NMSCNONREF\r\n" +
+		":86:004?00CARD PAYMENT?20SVWZ+Yabox\r\n" +
+		":61:2301050105D20,00NTRFNONREF\r\n" +
+		":86:020?00EXCHANGE?20SVWZ+Exchanged to USD\r\n" +
+		":61:2301050105D0,20NCHGNONREF\r\n" +
+		":86:808?00FEE?20SVWZ+Fee Exchanged to USD\r\n" +
+		":62F:C230105EUR69,30\r\n"
+	if buf.String() != want {
+		t.Errorf("ConvertToMT940() =\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	if len(usd.Transactions) != 1 || usd.Transactions[0].Saldo().Currency().Code != "USD" || usd.Transactions[0].Saldo().Amount() != 2140 {
+		t.Errorf("ParseAll() usd transactions = %v", usd.Transactions)
+	}
+}
+
+func TestRevolut_ParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		iban    string
+		csv     string
+		wantErr string
+	}{
+		{
+			name:    "short iban",
+			iban:    "LT12",
+			csv:     testCsv,
+			wantErr: "iban LT12 must have 20 characters, got 4",
+		},
+		{
+			name:    "missing column",
+			iban:    "LT353250012345678901",
+			csv:     "Type,Product,Started Date\n",
+			wantErr: `column "Completed Date" not found in header`,
+		},
+		{
+			name:    "invalid amount",
+			iban:    "LT353250012345678901",
+			csv:     header + "\nTOPUP,Current,2023-01-02 10:00:00,2023-01-02 10:00:05,Test,1.0.0,0.00,EUR,COMPLETED,1.00\n",
+			wantErr: "line 2",
+		},
+		{
+			name:    "only pending",
+			iban:    "LT353250012345678901",
+			csv:     header + "\nTOPUP,Current,2023-01-02 10:00:00,,Test,1.00,0.00,EUR,PENDING,\n",
+			wantErr: "no completed transactions found in csv",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rv := New(tt.iban)
+			rv.logger = log.New(&strings.Builder{}, "", 0)
+			_, err := rv.Parse(context.Background(), strings.NewReader(tt.csv))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %s", err, tt.wantErr)
+			}
+			var pErr *mt940.ParseError
+			if strings.HasPrefix(tt.wantErr, "line") && !errors.As(err, &pErr) {
+				t.Errorf("Parse() error = %v, want *mt940.ParseError", err)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		peek string
+		want float64
+	}{
+		{name: "account statement", peek: testCsv, want: 1},
+		{name: "account statement with bom", peek: "\xef\xbb\xbf" + testCsv, want: 1},
+		{name: "other columns", peek: "Type,Product,Started Date,Completed Date,Description,Amount", want: 0.8},
+		{name: "other csv", peek: `"Datum","Empfänger","Kontonummer"`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.peek)); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
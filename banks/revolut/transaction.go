@@ -0,0 +1,150 @@
+package revolut
+
+import (
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+// column names of the revolut account statement
+const (
+	columnType          = "Type"
+	columnProduct       = "Product"
+	columnStartedDate   = "Started Date"
+	columnCompletedDate = "Completed Date"
+	columnDescription   = "Description"
+	columnAmount        = "Amount"
+	columnFee           = "Fee"
+	columnCurrency      = "Currency"
+	columnState         = "State"
+	columnBalance       = "Balance"
+)
+
+// requiredColumns have to be in the header, the product column is optional
+var requiredColumns = []string{
+	columnType, columnStartedDate, columnCompletedDate, columnDescription,
+	columnAmount, columnFee, columnCurrency, columnState, columnBalance,
+}
+
+// dateLayout is the format of the started and completed date
+const dateLayout = "2006-01-02 15:04:05"
+
+// stateCompleted is the state of booked transactions, all other states (pending, reverted, declined, failed) are skipped
+const stateCompleted = "COMPLETED"
+
+// productCurrent is the product of the current account, savings vaults have their own balance and are skipped
+const productCurrent = "Current"
+
+// feeGVC is the gvc code of the separate fee transaction
+const feeGVC = "808"
+
+// maxPurposeParts limits the subfields ?20 to ?29 of the transaction, the fee gets maxFeePurposeParts
+const (
+	maxPurposeParts    = 8
+	maxFeePurposeParts = 2
+)
+
+// gvcCodes returns the GVC Code for the type of the transaction, note this list is not complete,
+// unknown types get the code of a transfer or a credit depending on the sign of the amount
+var gvcCodes = map[string]string{
+	"CARD_PAYMENT": "004",
+	"CARD_REFUND":  "004",
+	"ATM":          "083",
+	"FEE":          "808",
+	"INTEREST":     "805",
+	"CASHBACK":     "051",
+	"TOPUP":        "051",
+}
+
+// row is a completed transaction of the account statement
+type row struct {
+	typ         string
+	started     time.Time
+	completed   time.Time
+	description string
+	amount      *money.Money
+	fee         *money.Money
+	currency    string
+	balance     *money.Money
+	// skip is the reason why the row is skipped, e.g. the state of a pending transaction
+	skip string
+}
+
+// parseRow converts a csv line into a row, rows that are not completed are returned with a skip reason
+func parseRow(fields banks.Row) (*row, error) {
+	if state := fields.Value(columnState); state != stateCompleted {
+		return &row{skip: strings.ToLower(state)}, nil
+	}
+	if product := fields.Value(columnProduct); product != "" && product != productCurrent {
+		return &row{skip: strings.ToLower(product)}, nil
+	}
+
+	r := &row{
+		typ:         fields.Value(columnType),
+		description: fields.Value(columnDescription),
+		currency:    strings.ToUpper(fields.Value(columnCurrency)),
+	}
+	var err error
+	r.started, err = time.Parse(dateLayout, fields.Value(columnStartedDate))
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "startedDate", Value: fields.Value(columnStartedDate), Err: err}
+	}
+	r.completed, err = time.Parse(dateLayout, fields.Value(columnCompletedDate))
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "completedDate", Value: fields.Value(columnCompletedDate), Err: err}
+	}
+	r.amount, err = converter.ParseAmount(fields.Value(columnAmount), ".", "", r.currency)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "amount", Value: fields.Value(columnAmount), Err: err}
+	}
+	r.fee, err = converter.ParseAmount(fields.Value(columnFee), ".", "", r.currency)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "fee", Value: fields.Value(columnFee), Err: err}
+	}
+	r.balance, err = converter.ParseAmount(fields.Value(columnBalance), ".", "", r.currency)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "balance", Value: fields.Value(columnBalance), Err: err}
+	}
+	return r, nil
+}
+
+// statementLines returns the transaction and its fee as separate statement lines, the fee is always a debit,
+// the balance of the statement is after the fee so the transaction gets the balance plus the fee
+func (r *row) statementLines() []mt940.Transaction {
+	gvc, ok := gvcCodes[r.typ]
+	if !ok {
+		gvc = "051"
+		if r.amount.IsNegative() {
+			gvc = "020"
+		}
+	}
+	balance, _ := r.balance.Add(r.fee.Absolute())
+	references := converter.ParseSEPA(r.description)
+	line := &mt940.StatementLine{
+		Sales: mt940.NewSalesLine(r.started, r.completed, r.amount, gvc, references.EndToEnd),
+		Details: mt940.Details{
+			GVC:         gvc,
+			BookingText: strings.ReplaceAll(r.typ, "_", " "),
+		},
+		Balance: balance,
+	}
+	line.SetReferences(references, maxPurposeParts)
+	lines := []mt940.Transaction{line}
+	if !r.fee.IsZero() {
+		feeLine := &mt940.StatementLine{
+			Sales: mt940.NewSalesLine(r.started, r.completed, r.fee.Negative(), feeGVC, ""),
+			Details: mt940.Details{
+				GVC:         feeGVC,
+				BookingText: "FEE",
+			},
+			Balance: r.balance,
+		}
+		feeLine.SetReferences(converter.SEPAReferences{Remittance: "Fee " + r.description}, maxFeePurposeParts)
+		lines = append(lines, feeLine)
+	}
+	return lines
+}
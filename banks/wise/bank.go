@@ -0,0 +1,96 @@
+package wise
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/iban"
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+type Wise struct {
+	Iban   string
+	logger *log.Logger
+}
+
+func init() {
+	banks.Register("wise", func(opts banks.Options) (mt940.Bank, error) {
+		if opts.Iban == "" {
+			return nil, errors.New("parser for wise needs iban provided, use -iban")
+		}
+		return New(opts.Iban), nil
+	}, Detect)
+}
+
+// Detect returns the confidence that peek is the beginning of a wise balance statement,
+// the statement starts with the "TransferWise ID" column
+func Detect(peek []byte) float64 {
+	peek = bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf"))
+	if bytes.HasPrefix(peek, []byte(`"TransferWise ID",`)) || bytes.HasPrefix(peek, []byte(`TransferWise ID,`)) {
+		return 1
+	}
+	return 0
+}
+
+func New(iban string) *Wise {
+	logger := log.New(os.Stdout, "[WISE] ", log.Lmsgprefix)
+
+	return &Wise{
+		Iban:   iban,
+		logger: logger,
+	}
+}
+
+// Parse returns the first currency balance of the wise statement
+func (w *Wise) Parse(ctx context.Context, r io.Reader) (*mt940.BankData, error) {
+	all, err := w.ParseAll(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return all[0], nil
+}
+
+// ParseAll reads the wise balance statement from r and returns a BankData for every currency balance
+// in the order of their first transaction, transactions that are not completed are skipped
+func (w *Wise) ParseAll(ctx context.Context, r io.Reader) ([]*mt940.BankData, error) {
+	accountIBAN := iban.Normalize(w.Iban)
+	bankNumber, accountNumber, err := iban.Split(accountIBAN)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := banks.CurrencyCSV{Required: requiredColumns, Logger: w.logger}
+	var rows []*row
+	err = statement.ReadRows(ctx, r, func(fields banks.Row) (string, error) {
+		row, err := parseRow(fields)
+		if err != nil {
+			return "", err
+		}
+		if row.skip == "" {
+			rows = append(rows, row)
+		}
+		return row.skip, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the statement is sorted descending, the newest transaction is the first one
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].date.Before(rows[j].date)
+	})
+	var ta []mt940.Transaction
+	for _, row := range rows {
+		ta = append(ta, row.statementLines()...)
+	}
+	return statement.Statements(mt940.BankData{IBAN: accountIBAN, BankNumber: bankNumber, AccountNumber: accountNumber}, ta)
+}
@@ -0,0 +1,161 @@
+package wise
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/blz"
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+// TestMain uses the excerpt of the bank code file because the embedded file is only complete after go generate
+func TestMain(m *testing.M) {
+	d, err := blz.Load("../../blz/testdata/blz.txt")
+	if err != nil {
+		panic(err)
+	}
+	blz.SetDefault(d)
+	os.Exit(m.Run())
+}
+
+const testCsv = "\xef\xbb\xbf" + `"TransferWise ID","Date","Amount","Currency","Description","Payment Reference","Running Balance","Exchange From","Exchange To","Exchange Rate","Payer Name","Payee Name","Payee Account Number","Merchant","Card Last Four Digits","Card Holder Full Name","Attachment","Note","Total fees","Exchange To Amount"
+"CARD-123456789","05-01-2023","-10.50","EUR","Card transaction of 10.00 EUR issued by Yabox","","69.50","","","","","","","Yabox","1234","Test Tester","","","0.50",""
+"TRANSFER-234567890","03-01-2023","-20.00","EUR","Sent money to Yabox","Invoice 42","80.00","","","","","Yabox","DE02120300000000202051","","","","","","0.00",""
+"TRANSFER-456789012","02-01-2023","25.00","USD","Received money from Test Tester","","25.00","","","","Test Tester","","","","","","","","0.00",""
+"TRANSFER-345678901","02-01-2023","100.00","EUR","Received money from Test Tester","","100.00","","","","Test Tester","","","","","","","","0.00",""
+`
+
+func TestWise_ParseAll(t *testing.T) {
+	var logs strings.Builder
+	w := New("BE11 9670 1234 5678")
+	w.logger = log.New(&logs, "", 0)
+
+	got, err := w.ParseAll(context.Background(), strings.NewReader(testCsv))
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	if logs.Len() > 0 {
+		t.Errorf("ParseAll() logs = %q, want no warnings", logs.String())
+	}
+	if len(got) != 2 {
+		t.Fatalf("ParseAll() got %d balances, want 2", len(got))
+	}
+	eur, usd := got[0], got[1]
+	if eur.Currency != "EUR" || usd.Currency != "USD" || usd.AccountNumber != "012345678USD" {
+		t.Errorf("ParseAll() balances = %s/%s, %s/%s", eur.Currency, eur.AccountNumber, usd.Currency, usd.AccountNumber)
+	}
+
+	var buf bytes.Buffer
+	if err := eur.ConvertToMT940(&buf); err != nil {
+		t.Fatalf("ConvertToMT940() error = %v", err)
+	}
+	want := ":20:CSVTOMT940\r\n" +
+		":25:967/012345678EUR\r\n" +
+		":28C:0\r\n" +
+		":60F:C230102EUR0,00\r\n" +
+		":61:2301020102C100,00NTRFNONREF//345678901\r\n" +
+		":86:051?00TRANSFER?20SVWZ+Received money from Te?21st Tester?32Test T\r\n" +
+		"ester\r\n" +
+		":61:2301030103D20,00NTRFNONREF//234567890\r\n" +
+		":86:020?00TRANSFER?20SVWZ+Invoice 42?30BYLADEM1001?31DE02120300000000\r\n" +
+		"202051?32Yabox\r\n" +
+		":61:2301050105D10,00NMSCNONREF//CARD-123456789\r\n" +
+		":86:004?00CARD?20SVWZ+Card transaction of 10?21.00 EUR issued by Yabo\r\n" +
+		"x?32Yabox\r\n" +
+		":61:2301050105D0,50NCHGNONREF//CARD-123456789\r\n" +
+		":86:808?00FEE?20SVWZ+Fee CARD-123456789\r\n" +
+		":62F:C230105EUR69,50\r\n"
+	if buf.String() != want {
+		t.Errorf("ConvertToMT940() =\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestWise_ParseStatus(t *testing.T) {
+	input := `"TransferWise ID","Date","Amount","Currency","Running Balance","Status"
+"TRANSFER-2","03-01-2023","-5.00","EUR","","PENDING"
+"TRANSFER-3","03-01-2023","-5.00","EUR","","REVERTED"
+"TRANSFER-1","02-01-2023 10:00:00.000","100.00","EUR","100.00","COMPLETED"
+`
+	var logs strings.Builder
+	w := New("BE11967012345678")
+	w.logger = log.New(&logs, "", 0)
+	got, err := w.Parse(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got.Transactions) != 1 {
+		t.Errorf("Parse() got %d transactions, want 1", len(got.Transactions))
+	}
+	for _, skipped := range []string{"skipped 1 pending", "skipped 1 reverted"} {
+		if !strings.Contains(logs.String(), skipped) {
+			t.Errorf("Parse() logs = %q, want %q", logs.String(), skipped)
+		}
+	}
+}
+
+func TestWise_ParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		iban    string
+		csv     string
+		wantErr string
+	}{
+		{
+			name:    "short iban",
+			iban:    "BE12",
+			csv:     testCsv,
+			wantErr: "iban BE12 must have 16 characters, got 4",
+		},
+		{
+			name:    "missing column",
+			iban:    "BE11967012345678",
+			csv:     `"TransferWise ID","Date","Amount","Currency"` + "\n",
+			wantErr: `column "Running Balance" not found in header`,
+		},
+		{
+			name:    "invalid date",
+			iban:    "BE11967012345678",
+			csv:     `"TransferWise ID","Date","Amount","Currency","Running Balance"` + "\n" + `"CARD-1","2023-01-02","1.00","EUR","1.00"` + "\n",
+			wantErr: "line 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := New(tt.iban)
+			w.logger = log.New(&strings.Builder{}, "", 0)
+			_, err := w.Parse(context.Background(), strings.NewReader(tt.csv))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %s", err, tt.wantErr)
+			}
+			var pErr *mt940.ParseError
+			if strings.HasPrefix(tt.wantErr, "line") && !errors.As(err, &pErr) {
+				t.Errorf("Parse() error = %v, want *mt940.ParseError", err)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		peek string
+		want float64
+	}{
+		{name: "balance statement", peek: testCsv, want: 1},
+		{name: "without bom", peek: testCsv[3:], want: 1},
+		{name: "unquoted header", peek: "TransferWise ID,Date,Amount", want: 1},
+		{name: "other csv", peek: `"Datum","Empfänger","Kontonummer"`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.peek)); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
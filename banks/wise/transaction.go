@@ -0,0 +1,181 @@
+package wise
+
+import (
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/banks"
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+// column names of the wise balance statement
+const (
+	columnID                 = "TransferWise ID"
+	columnDate               = "Date"
+	columnAmount             = "Amount"
+	columnCurrency           = "Currency"
+	columnDescription        = "Description"
+	columnReference          = "Payment Reference"
+	columnBalance            = "Running Balance"
+	columnPayerName          = "Payer Name"
+	columnPayeeName          = "Payee Name"
+	columnPayeeAccountNumber = "Payee Account Number"
+	columnMerchant           = "Merchant"
+	columnFees               = "Total fees"
+	columnStatus             = "Status"
+)
+
+// requiredColumns have to be in the header, the other columns are optional
+var requiredColumns = []string{columnID, columnDate, columnAmount, columnCurrency, columnBalance}
+
+// dateLayout is the format of the date, newer statements add the time which is ignored
+const dateLayout = "02-01-2006"
+
+// statusCompleted is the status of booked transactions, older statements have no status column and contain only those
+const statusCompleted = "COMPLETED"
+
+// feeGVC is the gvc code of the separate fee transaction
+const feeGVC = "808"
+
+// maxPurposeParts limits the subfields ?20 to ?29 of the transaction, the fee gets maxFeePurposeParts
+const (
+	maxPurposeParts    = 8
+	maxFeePurposeParts = 2
+)
+
+// gvcCodes returns the GVC Code for the prefix of the TransferWise ID, note this list is not complete,
+// unknown prefixes get the code of a transfer or a credit depending on the sign of the amount
+var gvcCodes = map[string]string{
+	"CARD":         "004",
+	"DIRECT_DEBIT": "005",
+	"FEE":          "808",
+	"INTEREST":     "805",
+}
+
+// row is a completed transaction of the balance statement
+type row struct {
+	id            string
+	date          time.Time
+	amount        *money.Money
+	fees          *money.Money
+	currency      string
+	balance       *money.Money
+	purpose       string
+	name          string
+	accountNumber string
+	// skip is the reason why the row is skipped, e.g. the status of a pending transaction
+	skip string
+}
+
+// parseRow converts a csv line into a row, rows that are not completed are returned with a skip reason
+func parseRow(fields banks.Row) (*row, error) {
+	if status := fields.Value(columnStatus); status != "" && !strings.EqualFold(status, statusCompleted) {
+		return &row{skip: strings.ToLower(status)}, nil
+	}
+
+	r := &row{
+		id:       fields.Value(columnID),
+		currency: strings.ToUpper(fields.Value(columnCurrency)),
+		purpose:  fields.Value(columnReference),
+	}
+	if r.purpose == "" {
+		r.purpose = fields.Value(columnDescription)
+	}
+
+	date := fields.Value(columnDate)
+	if len(date) > len(dateLayout) {
+		date = date[:len(dateLayout)]
+	}
+	var err error
+	r.date, err = time.Parse(dateLayout, date)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "date", Value: fields.Value(columnDate), Err: err}
+	}
+	r.amount, err = converter.ParseAmount(fields.Value(columnAmount), ".", "", r.currency)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "amount", Value: fields.Value(columnAmount), Err: err}
+	}
+	r.fees, err = converter.ParseAmount(fields.Value(columnFees), ".", "", r.currency)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "fees", Value: fields.Value(columnFees), Err: err}
+	}
+	r.balance, err = converter.ParseAmount(fields.Value(columnBalance), ".", "", r.currency)
+	if err != nil {
+		return nil, &mt940.ParseError{Column: "balance", Value: fields.Value(columnBalance), Err: err}
+	}
+
+	switch {
+	case fields.Value(columnMerchant) != "":
+		r.name = fields.Value(columnMerchant)
+	case r.amount.IsNegative():
+		r.name = fields.Value(columnPayeeName)
+		r.accountNumber = strings.ReplaceAll(fields.Value(columnPayeeAccountNumber), " ", "")
+	default:
+		r.name = fields.Value(columnPayerName)
+	}
+	return r, nil
+}
+
+// kind returns the prefix of the TransferWise ID, e.g. CARD for CARD-123456789
+func (r *row) kind() string {
+	if i := strings.LastIndex(r.id, "-"); i > 0 {
+		return r.id[:i]
+	}
+	return ""
+}
+
+// statementLines returns the transaction and its fee as separate statement lines, the amount of the statement
+// includes the fee, so the transaction gets the amount without the fee and the balance before the fee
+func (r *row) statementLines() []mt940.Transaction {
+	kind := r.kind()
+	gvc, ok := gvcCodes[kind]
+	if !ok {
+		gvc = "051"
+		if r.amount.IsNegative() {
+			gvc = "020"
+		}
+	}
+	fee := r.fees.Absolute()
+	amount, _ := r.amount.Add(fee)
+	balance, _ := r.balance.Add(fee)
+	references := converter.ParseSEPA(r.purpose)
+	line := &mt940.StatementLine{
+		Sales: mt940.NewSalesLine(r.date, r.date, amount, gvc, references.EndToEnd),
+		Details: mt940.Details{
+			GVC:         gvc,
+			BookingText: strings.ReplaceAll(kind, "_", " "),
+		},
+		Balance: balance,
+	}
+	line.Sales.BankReference = bankReference(r.id)
+	line.SetReferences(references, maxPurposeParts)
+	line.SetCounterparty(mt940.Counterparty{Name: r.name, Account: mt940.NewCounterpartyAccount(r.accountNumber, "")})
+	lines := []mt940.Transaction{line}
+	if !fee.IsZero() {
+		feeLine := &mt940.StatementLine{
+			Sales: mt940.NewSalesLine(r.date, r.date, fee.Negative(), feeGVC, ""),
+			Details: mt940.Details{
+				GVC:         feeGVC,
+				BookingText: "FEE",
+			},
+			Balance: r.balance,
+		}
+		feeLine.Sales.BankReference = bankReference(r.id)
+		feeLine.SetReferences(converter.SEPAReferences{Remittance: "Fee " + r.id}, maxFeePurposeParts)
+		lines = append(lines, feeLine)
+	}
+	return lines
+}
+
+// bankReference returns the TransferWise ID as reference, ids longer than 16 characters lose their prefix
+func bankReference(id string) string {
+	if len(id) > 16 {
+		id = id[strings.LastIndex(id, "-")+1:]
+	}
+	if len(id) > 16 {
+		id = id[:16]
+	}
+	return id
+}
@@ -0,0 +1,215 @@
+// Package blz looks up german bank codes (Bankleitzahlen) in the Bankleitzahlendatei of the Deutsche Bundesbank
+package blz
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// embedded is the Bankleitzahlendatei in its fixed-width format, it is empty in the repository and has to be
+// generated with the complete file of the Deutsche Bundesbank before building, see gen.go
+//
+//go:generate go run gen.go -o blz.txt $BLZ_SOURCE
+//go:embed blz.txt
+var embedded []byte
+
+// columns of the fixed-width format, the positions are zero based and the end is exclusive
+const (
+	blzStart, blzEnd             = 0, 8
+	featureStart                 = 8
+	nameStart, nameEnd           = 9, 67
+	postalCodeStart, postalEnd   = 67, 72
+	cityStart, cityEnd           = 72, 107
+	shortNameStart, shortNameEnd = 107, 134
+	bicStart, bicEnd             = 139, 150
+	// minLineLength is the length up to the BIC, the columns after it are not used
+	minLineLength = bicEnd
+)
+
+var (
+	blzPattern        = regexp.MustCompile(`^[0-9]{8}$`)
+	germanIBANPattern = regexp.MustCompile(`^DE[0-9]{2}([0-9]{8})[0-9]{10}$`)
+)
+
+// Bank is a record of the Bankleitzahlendatei
+type Bank struct {
+	BLZ        string
+	Name       string
+	PostalCode string
+	City       string
+	ShortName  string
+	// BIC is empty for banks that do not take part in the payment transactions
+	BIC string
+}
+
+// Directory is an index of the banks by their bank code
+type Directory struct {
+	banks map[string]Bank
+}
+
+// Parse reads the Bankleitzahlendatei in its fixed-width format (ISO-8859-1), for every bank code
+// the record of the main office (feature 1) is used, branches only if there is no main office
+func Parse(r io.Reader) (*Directory, error) {
+	d := &Directory{banks: make(map[string]Bank)}
+	mainOffice := make(map[string]bool)
+
+	scanner := bufio.NewScanner(charmap.ISO8859_1.NewDecoder().Reader(r))
+	for line := 1; scanner.Scan(); line++ {
+		record := []rune(strings.TrimRight(scanner.Text(), "\r"))
+		if len(strings.TrimSpace(string(record))) == 0 {
+			continue
+		}
+		if len(record) < minLineLength {
+			return nil, fmt.Errorf("line %d of the bank code file is too short", line)
+		}
+		field := func(start, end int) string {
+			return strings.TrimSpace(string(record[start:end]))
+		}
+		code := field(blzStart, blzEnd)
+		if !blzPattern.MatchString(code) {
+			return nil, fmt.Errorf("line %d of the bank code file has an invalid bank code %q", line, code)
+		}
+
+		isMain := record[featureStart] == '1'
+		if _, ok := d.banks[code]; ok && (mainOffice[code] || !isMain) {
+			continue
+		}
+		d.banks[code] = Bank{
+			BLZ:        code,
+			Name:       field(nameStart, nameEnd),
+			PostalCode: field(postalCodeStart, postalEnd),
+			City:       field(cityStart, cityEnd),
+			ShortName:  field(shortNameStart, shortNameEnd),
+			BIC:        field(bicStart, bicEnd),
+		}
+		mainOffice[code] = isMain
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read bank code file: %w", err)
+	}
+	if len(d.banks) == 0 {
+		return nil, fmt.Errorf("bank code file is empty")
+	}
+	return d, nil
+}
+
+// Load reads the Bankleitzahlendatei from path, e.g. a newer file than the embedded one
+func Load(path string) (*Directory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open bank code file: %w", err)
+	}
+	defer f.Close()
+
+	d, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse bank code file %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Lookup returns the bank with the given bank code
+func (d *Directory) Lookup(blz string) (Bank, bool) {
+	b, ok := d.banks[strings.ReplaceAll(blz, " ", "")]
+	return b, ok
+}
+
+// BICForIBAN returns the BIC of the bank of a german IBAN
+func (d *Directory) BICForIBAN(iban string) (string, bool) {
+	code, ok := FromIBAN(iban)
+	if !ok {
+		return "", false
+	}
+	b, ok := d.Lookup(code)
+	if !ok || b.BIC == "" {
+		return "", false
+	}
+	return b.BIC, true
+}
+
+// Len returns the number of bank codes in the directory
+func (d *Directory) Len() int {
+	return len(d.banks)
+}
+
+var (
+	defaultMu        sync.Mutex
+	defaultDirectory *Directory
+	// misses are the bank codes of german IBANs that BICForIBAN did not find in the default directory
+	misses = make(map[string]bool)
+)
+
+// Default returns the directory that is used by LookupBLZ and BICForIBAN, it is the embedded file unless SetDefault was called,
+// the directory is empty if the file was not generated
+func Default() *Directory {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultDirectory == nil {
+		defaultDirectory = &Directory{banks: make(map[string]Bank)}
+		if len(bytes.TrimSpace(embedded)) > 0 {
+			d, err := Parse(bytes.NewReader(embedded))
+			if err != nil {
+				panic("blz: embedded bank code file is invalid: " + err.Error())
+			}
+			defaultDirectory = d
+		}
+	}
+	return defaultDirectory
+}
+
+// SetDefault replaces the directory that is used by LookupBLZ and BICForIBAN
+func SetDefault(d *Directory) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultDirectory = d
+}
+
+// LookupBLZ returns the bank with the given bank code from the default directory
+func LookupBLZ(blz string) (Bank, bool) {
+	return Default().Lookup(blz)
+}
+
+// BICForIBAN returns the BIC of the bank of a german IBAN from the default directory,
+// the bank codes that are not found are returned by Misses
+func BICForIBAN(iban string) (string, bool) {
+	bic, ok := Default().BICForIBAN(iban)
+	if code, german := FromIBAN(iban); german && !ok {
+		defaultMu.Lock()
+		misses[code] = true
+		defaultMu.Unlock()
+	}
+	return bic, ok
+}
+
+// Misses returns the sorted bank codes of german IBANs whose BIC BICForIBAN did not find
+func Misses() []string {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	codes := make([]string, 0, len(misses))
+	for code := range misses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// FromIBAN returns the bank code of a german IBAN, false is returned if iban is no german IBAN
+func FromIBAN(iban string) (string, bool) {
+	m := germanIBANPattern.FindStringSubmatch(strings.ToUpper(strings.ReplaceAll(iban, " ", "")))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
@@ -0,0 +1,173 @@
+package blz
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// record creates a line of the fixed-width format
+func record(code, feature, name, postalCode, city, shortName, bic string) string {
+	pad := func(s string, n int) string {
+		return s + strings.Repeat(" ", n-len([]rune(s)))
+	}
+	return code + feature + pad(name, 58) + pad(postalCode, 5) + pad(city, 35) + pad(shortName, 27) +
+		"     " + pad(bic, 11) + "13000001U000000000000000"
+}
+
+func TestParse(t *testing.T) {
+	file := strings.Join([]string{
+		record("37040044", "2", "Commerzbank", "50447", "K\xf6ln", "Commerzbank Filiale", ""),
+		record("37040044", "1", "Commerzbank", "50447", "K\xf6ln", "Commerzbank K\xf6ln", "COBADEFFXXX"),
+		record("37040044", "2", "Commerzbank", "53111", "Bonn", "Commerzbank Bonn", ""),
+		record("12345678", "2", "Testbank", "12345", "Berlin", "Testbank Berlin", ""),
+		"",
+	}, "\r\n")
+
+	d, err := Parse(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if d.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", d.Len())
+	}
+
+	want := Bank{BLZ: "37040044", Name: "Commerzbank", PostalCode: "50447", City: "Köln", ShortName: "Commerzbank Köln", BIC: "COBADEFFXXX"}
+	if got, ok := d.Lookup("370 400 44"); !ok || got != want {
+		t.Errorf("Lookup() = %+v, %v, want %+v", got, ok, want)
+	}
+	if bic, ok := d.BICForIBAN("DE89 3704 0044 0532 0130 00"); !ok || bic != "COBADEFFXXX" {
+		t.Errorf("BICForIBAN() = %s, %v", bic, ok)
+	}
+	if _, ok := d.BICForIBAN("DE00123456780000000000"); ok {
+		t.Errorf("BICForIBAN() of bank without BIC returned a BIC")
+	}
+	if _, ok := d.Lookup("11111111"); ok {
+		t.Errorf("Lookup() of unknown bank code returned a bank")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{"empty", "\r\n", "bank code file is empty"},
+		{"short line", "37040044" + "1Commerzbank", "line 1 of the bank code file is too short"},
+		{"invalid bank code", record("3704004X", "1", "Commerzbank", "50447", "Koeln", "Commerzbank", ""), `invalid bank code "3704004X"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.file))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// testdata is an excerpt of the Bankleitzahlendatei with the banks that are used in the tests
+const testdata = "testdata/blz.txt"
+
+func TestDefault(t *testing.T) {
+	previous := Default()
+	defer SetDefault(previous)
+
+	d, err := Load(testdata)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	SetDefault(d)
+	b, ok := LookupBLZ("10011001")
+	if !ok || b.BIC != "NTSBDEB1XXX" {
+		t.Errorf("LookupBLZ() = %+v, %v", b, ok)
+	}
+	// the main office is used although the bank code has branches in other cities
+	want := Bank{BLZ: "37050198", Name: "Sparkasse KölnBonn", PostalCode: "50667", City: "Köln", ShortName: "Sparkasse KölnBonn", BIC: "COLSDE33XXX"}
+	if b, ok := LookupBLZ("37050198"); !ok || b != want {
+		t.Errorf("LookupBLZ() = %+v, %v, want %+v", b, ok, want)
+	}
+	if bic, ok := BICForIBAN("DE32500105171234567895"); !ok || bic != "INGDDEFFXXX" {
+		t.Errorf("BICForIBAN() = %s, %v", bic, ok)
+	}
+
+	path := filepath.Join(t.TempDir(), "blz.txt")
+	if err := os.WriteFile(path, []byte(record("12345678", "1", "Testbank", "12345", "Berlin", "Testbank", "TESTDEB1XXX")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	d, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	SetDefault(d)
+	if _, ok := LookupBLZ("10011001"); ok {
+		t.Errorf("LookupBLZ() uses the previous directory after SetDefault")
+	}
+	if b, ok := LookupBLZ("12345678"); !ok || b.Name != "Testbank" {
+		t.Errorf("LookupBLZ() = %+v, %v", b, ok)
+	}
+}
+
+func TestDefaultNotGenerated(t *testing.T) {
+	previous, previousEmbedded := Default(), embedded
+	defer func() {
+		embedded = previousEmbedded
+		SetDefault(previous)
+	}()
+
+	embedded = nil
+	SetDefault(nil)
+	if d := Default(); d.Len() != 0 {
+		t.Errorf("Default().Len() = %d, want an empty directory", d.Len())
+	}
+}
+
+func TestMisses(t *testing.T) {
+	previous := Default()
+	defer func() {
+		SetDefault(previous)
+		misses = make(map[string]bool)
+	}()
+
+	SetDefault(&Directory{banks: map[string]Bank{"37040044": {BLZ: "37040044", BIC: "COBADEFFXXX"}}})
+	misses = make(map[string]bool)
+	for _, iban := range []string{"DE89370400440532013000", "DE00111111110000000000", "GB29NWBK60161331926819", "DE00111111110000000001", "DE02100100100006820101"} {
+		BICForIBAN(iban)
+	}
+	if got, want := strings.Join(Misses(), ","), "10010010,11111111"; got != want {
+		t.Errorf("Misses() = %s, want %s", got, want)
+	}
+}
+
+func TestTestdataRecordLength(t *testing.T) {
+	b, err := os.ReadFile(testdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, line := range strings.Split(strings.TrimRight(string(b), "\r\n"), "\r\n") {
+		// the file is ISO-8859-1 encoded, every character is one byte
+		if len(line) != 174 {
+			t.Errorf("line %d of %s has %d characters, want 174", i+1, testdata, len(line))
+		}
+	}
+}
+
+func TestFromIBAN(t *testing.T) {
+	tests := []struct {
+		iban   string
+		want   string
+		wantOk bool
+	}{
+		{"DE89370400440532013000", "37040044", true},
+		{"de89 3704 0044 0532 0130 00", "37040044", true},
+		{"DE8937040044053201300", "", false},
+		{"AT611904300234573201", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := FromIBAN(tt.iban); got != tt.want || ok != tt.wantOk {
+			t.Errorf("FromIBAN(%s) = %s, %v, want %s, %v", tt.iban, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+//go:build ignore
+// +build ignore
+
+// gen replaces blz.txt with the complete Bankleitzahlendatei of the Deutsche Bundesbank, it is run by go generate:
+//
+//	BLZ_SOURCE=<url or path of the file> go generate ./blz
+//
+// the file is published every three months on bundesbank.de under
+// Aufgaben > Unbarer Zahlungsverkehr > Serviceangebot > Bankleitzahlen as "Bankleitzahlendatei ungepackt" in the txt format
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/JHeimbach/csvtomt940/blz"
+)
+
+func main() {
+	out := flag.String("o", "blz.txt", "file that the Bankleitzahlendatei is written to")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: go run gen.go [-o blz.txt] <url or path of the Bankleitzahlendatei>, with go generate set BLZ_SOURCE")
+		os.Exit(2)
+	}
+
+	data, err := read(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	d, err := blz.Parse(bytes.NewReader(data))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s is no valid Bankleitzahlendatei: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "could not write %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %d bank codes to %s\n", d.Len(), *out)
+}
+
+// read downloads the file if source is an url and reads it from disk otherwise
+func read(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("could not read bank code file: %w", err)
+		}
+		return data, nil
+	}
+
+	resp, err := http.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not download bank code file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not download bank code file: %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not download bank code file: %w", err)
+	}
+	return data, nil
+}
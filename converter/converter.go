@@ -10,9 +10,10 @@ import (
 )
 
 // UmlautsReplacer replaces all umlauts with the two letter equivalent
-var umlautsReplacer = strings.NewReplacer("Ä", "AE", "Ö", "OE", "Ü", "UE", "ß", "ss", "ä", "ae", "ö", "oe", "ü", "ue")
+var umlautsReplacer = strings.NewReplacer("Ä", "AE", "Ö", "OE", "Ü", "UE", "ß", "ss", "ä", "ae", "ö", "oe", "ü", "ue", "–", "-", "—", "-")
 
-// ConvertUmlauts replaces all umlauts with the two letter equivalent
+// ConvertUmlauts replaces all umlauts with the two letter equivalent and the en and em dash with a hyphen,
+// they are not part of the SWIFT character set
 func ConvertUmlauts(s string) string {
 	return umlautsReplacer.Replace(s)
 }
@@ -43,23 +44,6 @@ func MoneyStringToInt(m string) (int, error) {
 	return strconv.Atoi(m)
 }
 
-// ConvertUsageToFields splits the usage line in strings of 27 chars and adds control chars from ?20... to ?29
-// if usage line is longer than 8*27 chars, it returns an error
-func ConvertUsageToFields(usage string) (string, error) {
-	parts := SplitStringInParts(fmt.Sprintf("SVWZ+%s", usage), 27, true)
-	if usage == "" {
-		parts = []string{}
-	}
-	if len(parts) > 8 {
-		return "", fmt.Errorf("usage line is too long")
-	}
-
-	result, startControl := JoinFieldsWithControl(parts, 20)
-
-	result += fmt.Sprintf("?%d%s", startControl, "KREF+NONREF")
-	return result, nil
-}
-
 // JoinFieldsWithControl adds control number to the beginning of the line
 func JoinFieldsWithControl(parts []string, startControl int) (string, int) {
 	result := ""
@@ -70,6 +54,23 @@ func JoinFieldsWithControl(parts []string, startControl int) (string, int) {
 	return result, startControl
 }
 
+// SplitSubfields converts the umlauts and splits s into at most max subfields of 27 characters without surrounding whitespace,
+// it returns nil if s is empty or max is less than 1
+func SplitSubfields(s string, max int) []string {
+	s = strings.TrimSpace(s)
+	if s == "" || max < 1 {
+		return nil
+	}
+	parts := SplitStringInParts(ConvertUmlauts(s), 27, true)
+	if len(parts) > max {
+		parts = parts[:max]
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
 // SplitStringInParts cuts the string in pieces each l chars long
 func SplitStringInParts(s string, l int, trimWhitespace bool) []string {
 	parts := make([]string, 0, int(math.Ceil(float64(len(s))/float64(l))))
@@ -95,6 +96,64 @@ func SplitStringInParts(s string, l int, trimWhitespace bool) []string {
 	return parts
 }
 
+// ParseAmount converts a formatted number with the given decimal and thousands separator into money,
+// whitespaces are ignored and an empty string is zero
+func ParseAmount(s, decimal, thousands, currency string) (*money.Money, error) {
+	s = strings.ReplaceAll(s, " ", "")
+	if thousands != "" {
+		s = strings.ReplaceAll(s, thousands, "")
+	}
+	if s == "" {
+		return money.New(0, currency), nil
+	}
+
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	units, fraction := s, ""
+	if i := strings.Index(s, decimal); i >= 0 {
+		units, fraction = s[:i], s[i+len(decimal):]
+	}
+	c := money.GetCurrency(currency)
+	if c == nil {
+		return nil, fmt.Errorf("unknown currency %s", currency)
+	}
+	if len(fraction) > c.Fraction {
+		return nil, fmt.Errorf("amount has more than %d decimal places", c.Fraction)
+	}
+	fraction += strings.Repeat("0", c.Fraction-len(fraction))
+	if units == "" {
+		units = "0"
+	}
+	if !isDigits(units) || (fraction != "" && !isDigits(fraction)) {
+		return nil, fmt.Errorf("invalid number")
+	}
+
+	amount, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if negative {
+		amount = -amount
+	}
+	return money.New(amount, currency), nil
+}
+
+// isDigits returns true if s only contains the characters 0-9
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
 func IsDebit(amount *money.Money) bool {
 	return amount.IsNegative()
 }
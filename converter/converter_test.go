@@ -2,6 +2,7 @@ package converter
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Rhymond/go-money"
@@ -51,6 +52,32 @@ func Test_splitStringInParts(t *testing.T) {
 	}
 }
 
+func TestSplitSubfields(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		max  int
+		want []string
+	}{
+		{name: "empty", s: " ", max: 2, want: nil},
+		{name: "no parts", s: "Yabox", max: 0, want: nil},
+		{name: "umlauts", s: "  Jörg Müller ", max: 2, want: []string{"Joerg Mueller"}},
+		{
+			name: "cut to max parts",
+			s:    "SVWZ+NR7778648141 INTERNET KAUFUMSATZ 25.12 256515 ARN85941831134325711900635",
+			max:  2,
+			want: []string{"SVWZ+NR7778648141 INTERNETK", "AUFUMSATZ 25.12 256515 ARN8"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitSubfields(tt.s, tt.max); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitSubfields() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_moneyStringToInt(t *testing.T) {
 	type args struct {
 		m string
@@ -166,17 +193,22 @@ func Test_convertUsageToFields(t *testing.T) {
 		{
 			name:  "empty usage",
 			usage: "",
-			want:  "?20KREF+NONREF",
+			want:  "",
 		},
 		{
 			name:  "short usage, under 27 chars",
 			usage: "this is a test",
-			want:  "?20SVWZ+this is a test?21KREF+NONREF",
+			want:  "?20SVWZ+this is a test",
 		},
 		{
 			name:  "long usage",
 			usage: "VISA 4546 XXXX XXXX XXXX 1,75%AUSLANDSEINSATZENTGELT VISA CARD (DEBITKARTE) ARN24492150077637298081121\n",
-			want:  "?20SVWZ+VISA 4546 XXXX XXXX XX?21XX 1,75%AUSLANDSEINSATZENTG?22ELT VISA CARD (DEBITKARTE)A?23RN24492150077637298081121?24KREF+NONREF",
+			want:  "?20SVWZ+VISA 4546 XXXX XXXX XX?21XX 1,75%AUSLANDSEINSATZENTG?22ELT VISA CARD (DEBITKARTE)A?23RN24492150077637298081121",
+		},
+		{
+			name:  "sepa references",
+			usage: "Beitrag Januar Mandatsreferenz: M-123 Glaeubiger-ID: DE98ZZZ09999999999 End-to-End-Ref.: E-456",
+			want:  "?20EREF+E-456?21MREF+M-123?22CRED+DE98ZZZ09999999999?23SVWZ+Beitrag Januar",
 		},
 		{
 			name:    "usage to long",
@@ -225,3 +257,106 @@ func Test_isCreditOrDebit(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		decimal   string
+		thousands string
+		currency  string
+		want      int64
+		wantErr   bool
+	}{
+		{name: "german format", input: "-1.234,5", decimal: ",", thousands: ".", currency: "EUR", want: -123450},
+		{name: "english format", input: "1,234.56", decimal: ".", thousands: ",", currency: "USD", want: 123456},
+		{name: "whole units", input: "+20", decimal: ",", currency: "EUR", want: 2000},
+		{name: "empty amount", input: "", decimal: ",", currency: "EUR", want: 0},
+		{name: "currency without decimals", input: "150", decimal: ",", currency: "JPY", want: 150},
+		{name: "too many decimals", input: "1,234", decimal: ",", currency: "EUR", wantErr: true},
+		{name: "unknown currency", input: "1,23", decimal: ",", currency: "XXY", wantErr: true},
+		{name: "invalid number", input: "1a,00", decimal: ",", currency: "EUR", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAmount(tt.input, tt.decimal, tt.thousands, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAmount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Amount() != tt.want {
+				t.Errorf("ParseAmount() = %d, want %d", got.Amount(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSEPA(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage string
+		want  SEPAReferences
+	}{
+		{
+			name:  "no markers",
+			usage: "Rechnung 42",
+			want:  SEPAReferences{Remittance: "Rechnung 42"},
+		},
+		{
+			name:  "german direct debit",
+			usage: "Beitrag Januar Mandatsreferenz: M-123 Glaeubiger-ID: DE98ZZZ09999999999 End-to-End-Ref.: E-456",
+			want:  SEPAReferences{Remittance: "Beitrag Januar", Mandate: "M-123", Creditor: "DE98ZZZ09999999999", EndToEnd: "E-456"},
+		},
+		{
+			name:  "german with umlaut and customer reference without colon",
+			usage: "Gläubiger-ID: DE98ZZZ09999999999 Kundenreferenz K-1 Abweichender Empfänger: Verein",
+			want:  SEPAReferences{Creditor: "DE98ZZZ09999999999", Customer: "K-1", UltimateCreditor: "Verein"},
+		},
+		{
+			name:  "english markers",
+			usage: "Invoice 7 Mandate Reference: M-1 Creditor ID: DE98ZZZ09999999999 Customer Reference: K-2 Ultimate Debtor: Jane",
+			want:  SEPAReferences{Remittance: "Invoice 7", Mandate: "M-1", Creditor: "DE98ZZZ09999999999", Customer: "K-2", UltimateDebtor: "Jane"},
+		},
+		{
+			name:  "tags",
+			usage: "EREF+E-1 SVWZ+Miete DEBT+D-2 ABWA+Max",
+			want:  SEPAReferences{EndToEnd: "E-1", Remittance: "Miete", Debtor: "D-2", UltimateDebtor: "Max"},
+		},
+		{
+			name:  "marker inside a word",
+			usage: "XMandatsreferenz: 1",
+			want:  SEPAReferences{Remittance: "XMandatsreferenz: 1"},
+		},
+		{
+			name:  "tags of joined subfields",
+			usage: "EREF+E-456MREF+M-123SVWZ+Rechnung",
+			want:  SEPAReferences{EndToEnd: "E-456", Mandate: "M-123", Remittance: "Rechnung"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseSEPA(tt.usage); got != tt.want {
+				t.Errorf("ParseSEPA() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSEPAReferences_Merge(t *testing.T) {
+	refs := SEPAReferences{EndToEnd: "E-1", Mandate: "M-1", Remittance: "Miete"}
+	got := refs.Merge(SEPAReferences{EndToEnd: "E-2", Creditor: " DE98ZZZ09999999999 "})
+	want := SEPAReferences{EndToEnd: "E-2", Mandate: "M-1", Creditor: "DE98ZZZ09999999999", Remittance: "Miete"}
+	if got != want {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+	if tags := got.Tags(); len(tags) != 4 || tags["CRED"] != "DE98ZZZ09999999999" || tags["SVWZ"] != "Miete" {
+		t.Errorf("Tags() = %v", tags)
+	}
+}
+
+func TestSEPAReferences_LimitedFields(t *testing.T) {
+	refs := SEPAReferences{EndToEnd: "E-1", Remittance: "Rückzahlung " + strings.Repeat("x", 60)}
+	want := []string{"EREF+E-1", "SVWZ+Rueckzahlung xxxxxxxxx", "xxxxxxxxxxxxxxxxxxxxxxxxxxx"}
+	if got := refs.LimitedFields(3); !reflect.DeepEqual(got, want) {
+		t.Errorf("LimitedFields() = %q, want %q", got, want)
+	}
+}
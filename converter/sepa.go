@@ -0,0 +1,166 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxPurposeFields is the number of subfields ?20 to ?29 of the purpose in :86:
+const maxPurposeFields = 10
+
+// SEPAReferences are the references of a SEPA transaction that are written as tags into the purpose of :86:
+type SEPAReferences struct {
+	EndToEnd         string // EREF+
+	Customer         string // KREF+
+	Mandate          string // MREF+
+	Creditor         string // CRED+
+	Debtor           string // DEBT+
+	Remittance       string // SVWZ+
+	UltimateDebtor   string // ABWA+
+	UltimateCreditor string // ABWE+
+}
+
+// sepaMarkers maps the german and english markers of the purpose texts in csv exports to the SEPA tags,
+// the markers are recognized at the start of a word in any case, the tags in upper case are recognized everywhere
+var sepaMarkers = []struct {
+	tag     string
+	pattern string
+}{
+	{"EREF", `EREF\+|End-to-End-Ref(?:erenz|erence|\.)?:|End-to-End-ID:|EndToEndId:|End to End Ref(?:erence|\.)?:`},
+	{"KREF", `KREF\+|Kundenreferenz:?|Customer Ref(?:erence|\.)?:`},
+	{"MREF", `MREF\+|Mandatsreferenz:|Mandatsref\.:|Mandate Ref(?:erence|\.)?:|Mandate ID:`},
+	{"CRED", `CRED\+|Gl(?:ae|ä)ubiger-?ID:|Gl(?:ae|ä)ubiger ID:|Creditor[- ]ID:|Creditor Identifier:`},
+	{"DEBT", `DEBT\+|Zahlungspflichtigen-?ID:|Debtor[- ]ID:|Debtor Identifier:`},
+	{"SVWZ", `SVWZ\+|Verwendungszweck:|Remittance Information:`},
+	{"ABWA", `ABWA\+|Abweichender Auftraggeber:|Ultimate Debtor:`},
+	{"ABWE", `ABWE\+|Abweichender Empf(?:ae|ä)nger:|Ultimate Creditor:`},
+}
+
+var sepaMarkerPattern = func() *regexp.Regexp {
+	groups := make([]string, 0, len(sepaMarkers))
+	for _, m := range sepaMarkers {
+		groups = append(groups, `((?:^|\s)(?i:`+m.pattern+`)|`+m.tag+`\+)`)
+	}
+	return regexp.MustCompile(strings.Join(groups, "|"))
+}()
+
+// ParseSEPA splits the purpose at the SEPA markers, the text before the first marker belongs to the remittance information,
+// values of markers that occur twice are joined with a space
+func ParseSEPA(usage string) SEPAReferences {
+	values := make(map[string][]string)
+	add := func(tag, value string) {
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			values[tag] = append(values[tag], value)
+		}
+	}
+
+	matches := sepaMarkerPattern.FindAllStringSubmatchIndex(usage, -1)
+	tag, start := "SVWZ", 0
+	for _, m := range matches {
+		add(tag, usage[start:m[0]])
+		for i := range sepaMarkers {
+			if m[2+2*i] >= 0 {
+				tag = sepaMarkers[i].tag
+				break
+			}
+		}
+		start = m[1]
+	}
+	add(tag, usage[start:])
+
+	get := func(tag string) string {
+		return strings.Join(values[tag], " ")
+	}
+	return SEPAReferences{
+		EndToEnd:         get("EREF"),
+		Customer:         get("KREF"),
+		Mandate:          get("MREF"),
+		Creditor:         get("CRED"),
+		Debtor:           get("DEBT"),
+		Remittance:       get("SVWZ"),
+		UltimateDebtor:   get("ABWA"),
+		UltimateCreditor: get("ABWE"),
+	}
+}
+
+// tags returns the tags with their values in the order of the DK specification
+func (r SEPAReferences) tags() []struct{ tag, value string } {
+	return []struct{ tag, value string }{
+		{"EREF", r.EndToEnd},
+		{"KREF", r.Customer},
+		{"MREF", r.Mandate},
+		{"CRED", r.Creditor},
+		{"DEBT", r.Debtor},
+		{"SVWZ", r.Remittance},
+		{"ABWA", r.UltimateDebtor},
+		{"ABWE", r.UltimateCreditor},
+	}
+}
+
+// Tags returns the references that are not empty by their tag, e.g. EREF for the end to end reference
+func (r SEPAReferences) Tags() map[string]string {
+	tags := make(map[string]string)
+	for _, t := range r.tags() {
+		if t.value != "" {
+			tags[t.tag] = t.value
+		}
+	}
+	return tags
+}
+
+// Merge returns the references with the values of other that are not empty,
+// e.g. the references of separate csv columns replace the references found in the purpose
+func (r SEPAReferences) Merge(other SEPAReferences) SEPAReferences {
+	for _, v := range []struct {
+		dst *string
+		src string
+	}{
+		{&r.EndToEnd, other.EndToEnd},
+		{&r.Customer, other.Customer},
+		{&r.Mandate, other.Mandate},
+		{&r.Creditor, other.Creditor},
+		{&r.Debtor, other.Debtor},
+		{&r.Remittance, other.Remittance},
+		{&r.UltimateDebtor, other.UltimateDebtor},
+		{&r.UltimateCreditor, other.UltimateCreditor},
+	} {
+		if v.src = strings.TrimSpace(v.src); v.src != "" {
+			*v.dst = v.src
+		}
+	}
+	return r
+}
+
+// Fields returns the subfields of the purpose, every tag starts a new subfield of at most 27 chars
+// in the order of the DK specification
+func (r SEPAReferences) Fields() []string {
+	var parts []string
+	for _, t := range r.tags() {
+		if t.value != "" {
+			parts = append(parts, SplitStringInParts(ConvertUmlauts(t.tag+"+"+t.value), 27, true)...)
+		}
+	}
+	return parts
+}
+
+// LimitedFields returns the first max subfields of Fields, the remittance information is cut if it is too long
+func (r SEPAReferences) LimitedFields(max int) []string {
+	parts := r.Fields()
+	if len(parts) > max {
+		parts = parts[:max]
+	}
+	return parts
+}
+
+// ConvertUsageToFields parses the SEPA references of the usage line and adds control chars from ?20... to ?29
+// if the references need more than 10 subfields, it returns an error
+func ConvertUsageToFields(usage string) (string, error) {
+	parts := ParseSEPA(usage).Fields()
+	if len(parts) > maxPurposeFields {
+		return "", fmt.Errorf("usage line is too long")
+	}
+
+	result, _ := JoinFieldsWithControl(parts, 20)
+	return result, nil
+}
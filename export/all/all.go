@@ -0,0 +1,9 @@
+// Package all registers every output format of this module, import it for its side effects
+package all
+
+import (
+	_ "github.com/JHeimbach/csvtomt940/export/camt053"
+	_ "github.com/JHeimbach/csvtomt940/export/journal"
+	_ "github.com/JHeimbach/csvtomt940/export/ofx"
+	_ "github.com/JHeimbach/csvtomt940/export/qif"
+)
@@ -0,0 +1,134 @@
+package camt053
+
+import "encoding/xml"
+
+// document is a camt.053 document, the elements that differ between the versions are chosen while the document is built
+type document struct {
+	XMLName     xml.Name    `xml:"Document"`
+	Namespace   string      `xml:"xmlns,attr"`
+	GroupHeader groupHeader `xml:"BkToCstmrStmt>GrpHdr"`
+	Statements  []statement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type groupHeader struct {
+	MessageID string `xml:"MsgId"`
+	Created   string `xml:"CreDtTm"`
+}
+
+type statement struct {
+	ID                   string    `xml:"Id"`
+	ElectronicSequenceNo int       `xml:"ElctrncSeqNb,omitempty"`
+	Created              string    `xml:"CreDtTm"`
+	From                 string    `xml:"FrToDt>FrDtTm"`
+	To                   string    `xml:"FrToDt>ToDtTm"`
+	Account              account   `xml:"Acct"`
+	Balances             []balance `xml:"Bal"`
+	Entries              []entry   `xml:"Ntry"`
+}
+
+type account struct {
+	ID       accountID `xml:"Id"`
+	Currency string    `xml:"Ccy,omitempty"`
+}
+
+// accountID is the IBAN or another account number
+type accountID struct {
+	IBAN  string `xml:"IBAN,omitempty"`
+	Other *other `xml:"Othr,omitempty"`
+}
+
+type other struct {
+	ID string `xml:"Id"`
+}
+
+type balance struct {
+	Code      string `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    amount `xml:"Amt"`
+	CdtDbtInd string `xml:"CdtDbtInd"`
+	Date      string `xml:"Dt>Dt"`
+}
+
+type amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type entry struct {
+	Amount              amount              `xml:"Amt"`
+	CdtDbtInd           string              `xml:"CdtDbtInd"`
+	Reversal            bool                `xml:"RvslInd,omitempty"`
+	Status              status              `xml:"Sts"`
+	BookingDate         string              `xml:"BookgDt>Dt"`
+	ValueDate           string              `xml:"ValDt>Dt"`
+	Reference           string              `xml:"AcctSvcrRef,omitempty"`
+	BankTransactionCode bankTransactionCode `xml:"BkTxCd"`
+	Details             *transactionDetails `xml:"NtryDtls>TxDtls,omitempty"`
+	AdditionalInfo      string              `xml:"AddtlNtryInf,omitempty"`
+}
+
+// status is the text of camt.053.001.02 and the code of later versions
+type status struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd,omitempty"`
+}
+
+// bankTransactionCode contains the ISO code if it is known and always the proprietary code with the GVC, e.g. NTRF+166
+type bankTransactionCode struct {
+	Domain      *domain `xml:"Domn,omitempty"`
+	Proprietary string  `xml:"Prtry>Cd"`
+	Issuer      string  `xml:"Prtry>Issr"`
+}
+
+type domain struct {
+	Code      string `xml:"Cd"`
+	Family    string `xml:"Fmly>Cd"`
+	SubFamily string `xml:"Fmly>SubFmlyCd"`
+}
+
+type transactionDetails struct {
+	References   *references `xml:"Refs,omitempty"`
+	Parties      *parties    `xml:"RltdPties,omitempty"`
+	Agents       *agents     `xml:"RltdAgts,omitempty"`
+	Unstructured []string    `xml:"RmtInf>Ustrd,omitempty"`
+}
+
+type references struct {
+	EndToEndID string `xml:"EndToEndId,omitempty"`
+	MandateID  string `xml:"MndtId,omitempty"`
+}
+
+type parties struct {
+	Debtor           *party       `xml:"Dbtr,omitempty"`
+	DebtorAccount    *cashAccount `xml:"DbtrAcct,omitempty"`
+	UltimateDebtor   *party       `xml:"UltmtDbtr,omitempty"`
+	Creditor         *party       `xml:"Cdtr,omitempty"`
+	CreditorAccount  *cashAccount `xml:"CdtrAcct,omitempty"`
+	UltimateCreditor *party       `xml:"UltmtCdtr,omitempty"`
+}
+
+// party is the party of camt.053.001.02, later versions wrap the debtor and creditor into Pty
+type party struct {
+	Name  string     `xml:"Nm,omitempty"`
+	ID    *privateID `xml:"Id>PrvtId,omitempty"`
+	Party *party     `xml:"Pty,omitempty"`
+}
+
+// privateID is the creditor id of a direct debit
+type privateID struct {
+	Other other `xml:"Othr"`
+}
+
+type cashAccount struct {
+	ID accountID `xml:"Id"`
+}
+
+type agents struct {
+	Debtor   *agent `xml:"DbtrAgt,omitempty"`
+	Creditor *agent `xml:"CdtrAgt,omitempty"`
+}
+
+// agent contains the BIC of camt.053.001.02 or the BICFI of later versions
+type agent struct {
+	BIC   string `xml:"FinInstnId>BIC,omitempty"`
+	BICFI string `xml:"FinInstnId>BICFI,omitempty"`
+}
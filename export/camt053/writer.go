@@ -0,0 +1,314 @@
+// Package camt053 writes the statements as ISO 20022 camt.053 document in the versions 001.02 and 001.08
+package camt053
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/export"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+// supported versions of the schema, DefaultVersion is used if no version is given
+const (
+	Version02      = "001.02"
+	Version08      = "001.08"
+	DefaultVersion = Version08
+)
+
+// messagePrefix is the beginning of the message id in the group header, it is followed by the creation time
+const messagePrefix = "CSVTOMT940"
+
+// maximum lengths of the text elements of the schema
+const (
+	maxIDLength             = 35
+	maxAccountLength        = 34
+	maxNameLength           = 140
+	maxUnstructuredLength   = 140
+	maxAdditionalInfoLength = 500
+)
+
+var (
+	ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[a-zA-Z0-9]{1,30}$`)
+	bicPattern  = regexp.MustCompile(`^[A-Z]{6}[A-Z2-9][A-NP-Z0-9]([A-Z0-9]{3})?$`)
+)
+
+func init() {
+	export.Register("camt053", func(opts export.Options) (export.Writer, error) {
+		return New(opts.CamtVersion)
+	})
+}
+
+// Writer writes camt.053 documents, all accounts are written as statements of one document
+type Writer struct {
+	version string
+	now     func() time.Time
+}
+
+// New creates a Writer for the version 001.02 or 001.08 of the schema, the short forms 02 and 08 are accepted too
+func New(version string) (*Writer, error) {
+	switch strings.TrimPrefix(version, "001.") {
+	case "":
+		version = DefaultVersion
+	case "02":
+		version = Version02
+	case "08":
+		version = Version08
+	default:
+		return nil, fmt.Errorf("camt.053 version %s not supported, use %s or %s", version, Version02, Version08)
+	}
+	return &Writer{version: version, now: time.Now}, nil
+}
+
+func (c *Writer) Extension() string {
+	return ".xml"
+}
+
+// Write writes all statements of the accounts into one camt.053 document
+func (c *Writer) Write(w io.Writer, accounts []*mt940.BankData) error {
+	created := c.now()
+	doc := document{
+		Namespace: "urn:iso:std:iso:20022:tech:xsd:camt.053." + c.version,
+		GroupHeader: groupHeader{
+			MessageID: messagePrefix + created.Format("20060102150405"),
+			Created:   created.Format("2006-01-02T15:04:05"),
+		},
+	}
+	for _, account := range accounts {
+		for _, s := range account.Statements() {
+			stmt, err := c.statement(s, created)
+			if err != nil {
+				return err
+			}
+			doc.Statements = append(doc.Statements, *stmt)
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("could not write camt document: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("could not write camt document: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// statement converts a statement of the BankData into a Stmt with the opening and closing balance
+func (c *Writer) statement(s *mt940.BankData, created time.Time) (*statement, error) {
+	opening, err := s.OpeningBalance()
+	if err != nil {
+		return nil, fmt.Errorf("could not create statement of %s: %w", s.AccountNumber, err)
+	}
+	closing, err := s.ClosingBalance()
+	if err != nil {
+		return nil, fmt.Errorf("could not create statement of %s: %w", s.AccountNumber, err)
+	}
+	reference, err := s.StatementReference()
+	if err != nil {
+		return nil, fmt.Errorf("could not create statement id: %w", err)
+	}
+
+	currency := s.Currency
+	if currency == "" {
+		currency = opening.Amount.Currency().Code
+	}
+
+	stmt := &statement{
+		ID:                   cut(reference, maxIDLength),
+		ElectronicSequenceNo: s.StatementNumber,
+		Created:              created.Format("2006-01-02T15:04:05"),
+		From:                 opening.Date.Format("2006-01-02") + "T00:00:00",
+		To:                   closing.Date.Format("2006-01-02") + "T23:59:59",
+		Account:              account{Currency: currency},
+		Balances: []balance{
+			newBalance("OPBD", opening.Amount, opening.Date),
+			newBalance("CLBD", closing.Amount, closing.Date),
+		},
+	}
+	if s.IBAN != "" {
+		stmt.Account.ID = newAccountID(s.IBAN)
+	} else {
+		stmt.Account.ID = newAccountID(s.AccountNumber)
+	}
+
+	for i, t := range s.Transactions {
+		line, err := mt940.ToStatementLine(t)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert transaction %d: %w", i, err)
+		}
+		stmt.Entries = append(stmt.Entries, c.entry(line, t))
+	}
+	return stmt, nil
+}
+
+// newBalance creates the balance with the given code, e.g. OPBD for the opening balance
+func newBalance(code string, m *money.Money, date time.Time) balance {
+	return balance{
+		Code:      code,
+		Amount:    newAmount(m),
+		CdtDbtInd: cdtDbtInd(m),
+		Date:      date.Format("2006-01-02"),
+	}
+}
+
+// newAmount formats the absolute value with a decimal point
+func newAmount(m *money.Money) amount {
+	c := m.Currency()
+	return amount{
+		Currency: c.Code,
+		Value:    money.NewFormatter(c.Fraction, ".", "", "", "1").Format(m.Absolute().Amount()),
+	}
+}
+
+// cdtDbtInd returns DBIT for negative and CRDT for other amounts
+func cdtDbtInd(m *money.Money) string {
+	if m.IsNegative() {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+// entry converts the statement line into a booked Ntry with one TxDtls, the counterparty and the references are taken
+// from the transaction because :86: cuts the name and splits the remittance information
+func (c *Writer) entry(l *mt940.StatementLine, t mt940.Transaction) entry {
+	e := entry{
+		Amount:      newAmount(l.Amount()),
+		CdtDbtInd:   cdtDbtInd(l.Amount()),
+		Reversal:    l.Sales.Reversal,
+		BookingDate: l.Date().Format("2006-01-02"),
+		ValueDate:   l.Sales.ValueDate.Format("2006-01-02"),
+		Reference:   cut(l.Sales.BankReference, maxIDLength),
+	}
+	if c.version == Version02 {
+		e.Status.Text = "BOOK"
+	} else {
+		e.Status.Code = "BOOK"
+	}
+
+	d := l.Details
+	gvc := d.GVC
+	if gvc == "" {
+		gvc = "999"
+	}
+	typeCode := l.Sales.TypeCode
+	if typeCode == "" {
+		typeCode = "NTRF"
+	}
+	e.BankTransactionCode.Proprietary = typeCode + "+" + gvc
+	if d.TextKeyAddition != "" {
+		e.BankTransactionCode.Proprietary += "+" + d.TextKeyAddition
+	}
+	e.BankTransactionCode.Issuer = "DK"
+	if code, ok := mt940.TransactionCodeForGVC(gvc); ok {
+		e.BankTransactionCode.Domain = &domain{Code: code.Domain, Family: code.Family, SubFamily: code.SubFamily}
+	}
+	e.AdditionalInfo = cut(d.BookingText, maxAdditionalInfoLength)
+
+	e.Details = c.details(t.References(), t.Counterparty(), l.Amount().IsNegative())
+	return e
+}
+
+// details creates the TxDtls with the references, the counterparty and the remittance information,
+// the counterparty is the debtor of a credit and the creditor of a debit, nil is returned if there are no details
+func (c *Writer) details(refs converter.SEPAReferences, counterparty mt940.Counterparty, debit bool) *transactionDetails {
+	var d transactionDetails
+	if endToEnd := refs.EndToEnd; (endToEnd != "" && endToEnd != "NOTPROVIDED") || refs.Mandate != "" {
+		d.References = &references{MandateID: cut(refs.Mandate, maxIDLength)}
+		if endToEnd != "NOTPROVIDED" {
+			d.References.EndToEndID = cut(endToEnd, maxIDLength)
+		}
+	}
+
+	name, accountNumber, bic := counterparty.Name, counterparty.Account.AccountNumber, counterparty.Account.BankCode
+	var account *cashAccount
+	if accountNumber != "" {
+		account = &cashAccount{ID: newAccountID(accountNumber)}
+	}
+	var fi *agent
+	if bicPattern.MatchString(bic) {
+		fi = &agent{BIC: bic}
+		if c.version != Version02 {
+			fi = &agent{BICFI: bic}
+		}
+	}
+
+	// the creditor id of a direct debit belongs to the creditor, even if a returned debit is booked as credit
+	var p parties
+	if debit {
+		p.Creditor, p.CreditorAccount = c.party(name, refs.Creditor), account
+		if fi != nil {
+			d.Agents = &agents{Creditor: fi}
+		}
+	} else {
+		p.Debtor, p.DebtorAccount, p.Creditor = c.party(name, ""), account, c.party("", refs.Creditor)
+		if fi != nil {
+			d.Agents = &agents{Debtor: fi}
+		}
+	}
+	if ultimate := refs.UltimateDebtor; ultimate != "" {
+		p.UltimateDebtor = &party{Name: cut(ultimate, maxNameLength)}
+	}
+	if ultimate := refs.UltimateCreditor; ultimate != "" {
+		p.UltimateCreditor = &party{Name: cut(ultimate, maxNameLength)}
+	}
+	if p != (parties{}) {
+		d.Parties = &p
+	}
+
+	// the remittance information is split into several Ustrd of 140 characters
+	for remittance := []rune(refs.Remittance); len(remittance) > 0; {
+		n := len(remittance)
+		if n > maxUnstructuredLength {
+			n = maxUnstructuredLength
+		}
+		d.Unstructured = append(d.Unstructured, string(remittance[:n]))
+		remittance = remittance[n:]
+	}
+
+	if d.References == nil && d.Parties == nil && d.Agents == nil && d.Unstructured == nil {
+		return nil
+	}
+	return &d
+}
+
+// party returns the party with name and creditor id, later versions than 001.02 wrap it into Pty,
+// nil is returned if both are empty
+func (c *Writer) party(name, id string) *party {
+	if name == "" && id == "" {
+		return nil
+	}
+	p := &party{Name: cut(name, maxNameLength)}
+	if id != "" {
+		p.ID = &privateID{Other: other{ID: cut(id, maxIDLength)}}
+	}
+	if c.version == Version02 {
+		return p
+	}
+	return &party{Party: p}
+}
+
+// newAccountID returns the IBAN or, if the account number is no IBAN, the other id of an account
+func newAccountID(accountNumber string) accountID {
+	if ibanPattern.MatchString(accountNumber) {
+		return accountID{IBAN: accountNumber}
+	}
+	return accountID{Other: &other{ID: cut(accountNumber, maxAccountLength)}}
+}
+
+// cut shortens s to at most max characters
+func cut(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if r := []rune(s); len(r) > max {
+		return string(r[:max])
+	}
+	return s
+}
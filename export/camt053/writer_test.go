@@ -0,0 +1,151 @@
+package camt053
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/banks/camt"
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+const testStatement = ":20:CSVTOMT940\r\n" +
+	":25:37040044/0532013000\r\n" +
+	":28C:3\r\n" +
+	":60F:C200106EUR1173,74\r\n" +
+	":61:2001060106C16,20NTRFE-456//REF-1\r\n" +
+	":86:166?00Gutschrift?20EREF+E-456?21SVWZ+Grass-roots systemic p?22ric\r\n" +
+	"ing structure?30INGDDEFFXXX?31DE02500105170137075030?32Yabox\r\n" +
+	":61:2001080109D1,62NDDTNONREF//REF-2\r\n" +
+	":86:105?00Lastschrift?20MREF+M-123?21CRED+DE98ZZZ09999999999?22SVWZ+R\r\n" +
+	"eactive full-range lo?23cal area network?30BYLADEM1001?31DE021203\r\n" +
+	"00000000202051?32Yabox\r\n" +
+	":61:2001090109RC2,00NTRFNONREF\r\n" +
+	":86:999?20SVWZ+Storno?32Test Tester\r\n" +
+	":62F:C200109EUR1186,32\r\n"
+
+func testData(t *testing.T) []*mt940.BankData {
+	data, err := mt940.Read(strings.NewReader(testStatement))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[0].IBAN = "DE89370400440532013000"
+	return data
+}
+
+func testWriter(t *testing.T, version string) *Writer {
+	w, err := New(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return time.Date(2020, 1, 10, 8, 0, 0, 0, time.UTC) }
+	return w
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	for _, version := range []string{Version02, Version08} {
+		t.Run(version, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := testWriter(t, version).Write(&buf, testData(t)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			data, err := camt.New().Parse(context.Background(), &buf)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var got bytes.Buffer
+			if err := data.ConvertToMT940(&got); err != nil {
+				t.Fatalf("ConvertToMT940() error = %v", err)
+			}
+			if got.String() != testStatement {
+				t.Errorf("round trip =\n%q\nwant\n%q", got.String(), testStatement)
+			}
+		})
+	}
+}
+
+func TestWriter_Write(t *testing.T) {
+	tests := []struct {
+		version string
+		want    []string
+	}{
+		{
+			version: Version02,
+			want: []string{
+				`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">`,
+				"<MsgId>CSVTOMT94020200110080000</MsgId>",
+				"<Id>CSVTOMT940</Id>\n      <ElctrncSeqNb>3</ElctrncSeqNb>",
+				"<Cd>OPBD</Cd>\n          </CdOrPrtry>\n        </Tp>\n        <Amt Ccy=\"EUR\">1173.74</Amt>",
+				"<Cd>CLBD</Cd>\n          </CdOrPrtry>\n        </Tp>\n        <Amt Ccy=\"EUR\">1186.32</Amt>",
+				"<Sts>BOOK</Sts>",
+				"<Cdtr>\n                <Nm>Yabox</Nm>\n                <Id>\n                  <PrvtId>\n                    <Othr>\n                      <Id>DE98ZZZ09999999999</Id>",
+				"<BIC>BYLADEM1001</BIC>",
+				"<Amt Ccy=\"EUR\">2.00</Amt>\n        <CdtDbtInd>DBIT</CdtDbtInd>\n        <RvslInd>true</RvslInd>",
+				"<BkTxCd>\n          <Prtry>\n            <Cd>NTRF+999</Cd>",
+			},
+		},
+		{
+			version: Version08,
+			want: []string{
+				`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">`,
+				"<Sts>\n          <Cd>BOOK</Cd>\n        </Sts>",
+				"<Dbtr>\n                <Pty>\n                  <Nm>Yabox</Nm>",
+				"<BICFI>INGDDEFFXXX</BICFI>",
+				"<Domn>\n            <Cd>PMNT</Cd>\n            <Fmly>\n              <Cd>RDDT</Cd>\n              <SubFmlyCd>ESDD</SubFmlyCd>",
+				"<Refs>\n              <MndtId>M-123</MndtId>\n            </Refs>",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := testWriter(t, tt.version).Write(&buf, testData(t)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("Write() does not contain %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestWriter_WriteErrors(t *testing.T) {
+	w := testWriter(t, "")
+	err := w.Write(&bytes.Buffer{}, []*mt940.BankData{{AccountNumber: "0532013000"}})
+	if err == nil || !strings.Contains(err.Error(), "no transactions found") {
+		t.Errorf("Write() error = %v", err)
+	}
+	if _, err := New("001.04"); err == nil {
+		t.Errorf("New() of unsupported version returned no error")
+	}
+}
+
+func TestWriter_SourceFields(t *testing.T) {
+	name := "Hausverwaltung Müller und Schmidt Immobilienbetreuung GmbH"
+	remittance := "Nebenkosten Wohnung zweites Obergeschoss"
+	line := &mt940.StatementLine{
+		Sales:   mt940.SalesLine{ValueDate: time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), Amount: money.New(-10000, "EUR")},
+		Details: mt940.Details{GVC: "020", BookingText: "Ueberweisung"},
+		Balance: money.New(-10000, "EUR"),
+	}
+	line.SetReferences(converter.SEPAReferences{Remittance: remittance}, 8)
+	line.SetCounterparty(mt940.Counterparty{Name: name})
+
+	var buf bytes.Buffer
+	data := []*mt940.BankData{{BankNumber: "37040044", AccountNumber: "0532013000", Transactions: []mt940.Transaction{line}}}
+	if err := testWriter(t, Version02).Write(&buf, data); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	for _, want := range []string{"<Nm>" + name + "</Nm>", "<Ustrd>" + remittance + "</Ustrd>"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Write() does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
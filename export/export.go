@@ -0,0 +1,103 @@
+// Package export contains the registry of the output formats, every format writes the parsed accounts into a single file
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+// DefaultFormat is the format that is written if no format is selected
+const DefaultFormat = "mt940"
+
+// Options are the settings from the command line, every format uses only the options it needs
+type Options struct {
+	// CamtVersion is the version of the camt.053 schema, e.g. 001.08
+	CamtVersion string
+	// OFXVersion is the version of OFX, 102 for SGML or 220 for XML
+	OFXVersion string
+	// QIFDateFormat is the locale of the QIF dates (us, uk, de or iso) or a go time layout
+	QIFDateFormat string
+	// JournalRules is the path of the .json or .yaml file with the accounts of the ledger, hledger and beancount journals
+	JournalRules string
+}
+
+// Writer writes the statements of all accounts in its format
+type Writer interface {
+	Write(w io.Writer, accounts []*mt940.BankData) error
+	// Extension is the file extension of the format including the dot, e.g. .sta
+	Extension() string
+}
+
+// Factory creates a new Writer with the given options
+type Factory func(opts Options) (Writer, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+func init() {
+	Register(DefaultFormat, func(opts Options) (Writer, error) {
+		return &mt940Writer{}, nil
+	})
+}
+
+// Register makes a format available under the given name, it is meant to be called from the init function of the format package
+// Register panics if it is called twice with the same name or if factory is nil
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("export: Register factory is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("export: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New creates the writer registered with name
+func New(name string, opts Options) (Writer, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("format \"%s\" not supported", name)
+	}
+	return factory(opts)
+}
+
+// Names returns the sorted names of all registered formats
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mt940Writer writes the accounts as MT940 statements
+type mt940Writer struct{}
+
+func (m *mt940Writer) Write(w io.Writer, accounts []*mt940.BankData) error {
+	for _, account := range accounts {
+		if err := account.ConvertToMT940(w); err != nil {
+			return fmt.Errorf("could not convert to MT940: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *mt940Writer) Extension() string {
+	return ".sta"
+}
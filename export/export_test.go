@@ -0,0 +1,67 @@
+package export
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+type mockWriter struct{}
+
+func (m *mockWriter) Write(w io.Writer, accounts []*mt940.BankData) error {
+	return nil
+}
+
+func (m *mockWriter) Extension() string {
+	return ".test"
+}
+
+func TestRegistry(t *testing.T) {
+	Register("test", func(opts Options) (Writer, error) {
+		return &mockWriter{}, nil
+	})
+
+	if got := Names(); !reflect.DeepEqual(got, []string{DefaultFormat, "test"}) {
+		t.Errorf("Names() = %v", got)
+	}
+	w, err := New("test", Options{})
+	if err != nil || w.Extension() != ".test" {
+		t.Errorf("New() = %v, %v", w, err)
+	}
+	if _, err := New("unknown", Options{}); err == nil {
+		t.Errorf("New() of unknown format returned no error")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register() twice did not panic")
+		}
+	}()
+	Register("test", func(opts Options) (Writer, error) {
+		return &mockWriter{}, nil
+	})
+}
+
+func TestMT940Writer(t *testing.T) {
+	data, err := mt940.Read(strings.NewReader(":20:CSVTOMT940\r\n:25:50010517/1234567895\r\n:28C:0\r\n" +
+		":60F:C200106EUR1173,74\r\n:61:2001060106C16,20NTRFNONREF\r\n:62F:C200106EUR1189,94\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := New(DefaultFormat, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := w.Write(&buf, append(data, data...)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if got := strings.Count(buf.String(), ":20:CSVTOMT940"); got != 2 || w.Extension() != ".sta" {
+		t.Errorf("Write() got %d statements, extension %s", got, w.Extension())
+	}
+}
@@ -0,0 +1,139 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"gopkg.in/yaml.v3"
+)
+
+// Rules map the bank accounts to asset accounts and the transactions to their counter accounts
+type Rules struct {
+	// Accounts maps the IBAN or <bank number>/<account number> of a bank account to its asset account
+	Accounts map[string]string `json:"accounts" yaml:"accounts"`
+	// AssetPrefix is the parent of the asset accounts that are not in Accounts, defaults to Assets:Bank
+	AssetPrefix string `json:"assetPrefix" yaml:"assetPrefix"`
+	// Payees are checked in their order before the categories, the first matching rule is used
+	Payees []PayeeRule `json:"payees" yaml:"payees"`
+	// Categories maps the category of the csv export to the counter account
+	Categories map[string]string `json:"categories" yaml:"categories"`
+	// Income is the counter account of credits without matching rule, defaults to Income:Unknown
+	Income string `json:"income" yaml:"income"`
+	// Expenses is the counter account of debits without matching rule, defaults to Expenses:Unknown
+	Expenses string `json:"expenses" yaml:"expenses"`
+}
+
+// PayeeRule books the transactions whose payee matches the regular expression Pattern on Account
+type PayeeRule struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Account string `json:"account" yaml:"account"`
+
+	re *regexp.Regexp
+}
+
+// LoadRules reads the rules from a .json, .yaml or .yml file
+func LoadRules(path string) (*Rules, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read journal rules: %w", err)
+	}
+
+	r := &Rules{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, r)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, r)
+	default:
+		return nil, fmt.Errorf("journal rules %s must be a .json, .yaml or .yml file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse journal rules %s: %w", path, err)
+	}
+
+	err = r.setDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid journal rules %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// setDefaults fills the optional fields of the rules and compiles the payee patterns
+func (r *Rules) setDefaults() error {
+	if r.AssetPrefix == "" {
+		r.AssetPrefix = "Assets:Bank"
+	}
+	if r.Income == "" {
+		r.Income = "Income:Unknown"
+	}
+	if r.Expenses == "" {
+		r.Expenses = "Expenses:Unknown"
+	}
+	for i := range r.Payees {
+		rule := &r.Payees[i]
+		if rule.Pattern == "" || rule.Account == "" {
+			return fmt.Errorf("payee rule %d needs a pattern and an account", i+1)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern of payee rule %d: %w", i+1, err)
+		}
+		rule.re = re
+	}
+	return nil
+}
+
+// assetAccount returns the asset account of the bank account, accounts that are not configured
+// get an account below AssetPrefix named after their IBAN or bank and account number
+func (r *Rules) assetAccount(s *mt940.BankData) string {
+	keys := []string{s.BankNumber + "/" + s.AccountNumber}
+	if s.IBAN != "" {
+		keys = append([]string{s.IBAN}, keys...)
+	}
+	for _, key := range keys {
+		if account, ok := r.Accounts[key]; ok {
+			return account
+		}
+	}
+	if s.IBAN != "" {
+		return r.AssetPrefix + ":" + accountComponent(s.IBAN)
+	}
+	return r.AssetPrefix + ":" + accountComponent(s.BankNumber) + ":" + accountComponent(s.AccountNumber)
+}
+
+// counterAccount returns the account of the first payee rule that matches, the account of the category
+// or the default income or expenses account
+func (r *Rules) counterAccount(payee, category string, debit bool) string {
+	for _, rule := range r.Payees {
+		if rule.re.MatchString(payee) {
+			return rule.Account
+		}
+	}
+	if account, ok := r.Categories[category]; ok && category != "" {
+		return account
+	}
+	if debit {
+		return r.Expenses
+	}
+	return r.Income
+}
+
+// accountComponent replaces the characters that are not allowed in an account name of beancount
+func accountComponent(s string) string {
+	s = strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-':
+			return c
+		}
+		return '-'
+	}, s)
+	if s == "" || (s[0] >= 'a' && s[0] <= 'z') || s[0] == '-' {
+		s = "X" + s
+	}
+	return s
+}
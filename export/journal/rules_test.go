@@ -0,0 +1,137 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+func writeRules(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		wantErr  string
+		validate func(t *testing.T, r *Rules)
+	}{
+		{
+			name: "yaml",
+			file: "rules.yaml",
+			content: `
+accounts:
+  DE89370400440532013000: Assets:Giro
+payees:
+  - pattern: (?i)rewe
+    account: Expenses:Groceries
+categories:
+  Gehalt und Rente: Income:Salary
+`,
+			validate: func(t *testing.T, r *Rules) {
+				if r.AssetPrefix != "Assets:Bank" || r.Income != "Income:Unknown" || r.Expenses != "Expenses:Unknown" {
+					t.Errorf("defaults not set: %+v", r)
+				}
+				if r.Accounts["DE89370400440532013000"] != "Assets:Giro" || r.Categories["Gehalt und Rente"] != "Income:Salary" {
+					t.Errorf("accounts = %v, categories = %v", r.Accounts, r.Categories)
+				}
+				if got := r.counterAccount("REWE Markt", "", true); got != "Expenses:Groceries" {
+					t.Errorf("counterAccount() = %s, want Expenses:Groceries", got)
+				}
+			},
+		},
+		{
+			name:    "json",
+			file:    "rules.json",
+			content: `{"assetPrefix": "Assets:Banks", "income": "Income:Other"}`,
+			validate: func(t *testing.T, r *Rules) {
+				if r.AssetPrefix != "Assets:Banks" || r.Income != "Income:Other" || r.Expenses != "Expenses:Unknown" {
+					t.Errorf("rules = %+v", r)
+				}
+			},
+		},
+		{
+			name:    "invalid pattern",
+			file:    "rules.yml",
+			content: "payees:\n  - pattern: \"(\"\n    account: Expenses:Food\n",
+			wantErr: "invalid pattern of payee rule 1",
+		},
+		{
+			name:    "missing account",
+			file:    "rules.yml",
+			content: "payees:\n  - pattern: rewe\n",
+			wantErr: "payee rule 1 needs a pattern and an account",
+		},
+		{
+			name:    "unknown extension",
+			file:    "rules.txt",
+			wantErr: "must be a .json, .yaml or .yml file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := LoadRules(writeRules(t, tt.file, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadRules() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadRules() error = %v", err)
+			}
+			tt.validate(t, r)
+		})
+	}
+}
+
+func TestRules_Accounts(t *testing.T) {
+	r := &Rules{
+		Accounts:   map[string]string{"37040044/0532013000": "Assets:Giro"},
+		Payees:     []PayeeRule{{Pattern: "^Yabox$", Account: "Income:Salary"}},
+		Categories: map[string]string{"Lebensmittel": "Expenses:Groceries"},
+	}
+	if err := r.setDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	assets := []struct {
+		data *mt940.BankData
+		want string
+	}{
+		{&mt940.BankData{BankNumber: "37040044", AccountNumber: "0532013000", IBAN: "DE89370400440532013000"}, "Assets:Giro"},
+		{&mt940.BankData{BankNumber: "10011001", AccountNumber: "1234", IBAN: "DE12100110010000001234"}, "Assets:Bank:DE12100110010000001234"},
+		{&mt940.BankData{BankNumber: "10011001", AccountNumber: "1234"}, "Assets:Bank:10011001:1234"},
+	}
+	for _, tt := range assets {
+		if got := r.assetAccount(tt.data); got != tt.want {
+			t.Errorf("assetAccount(%s) = %s, want %s", tt.data.AccountNumber, got, tt.want)
+		}
+	}
+
+	counters := []struct {
+		payee, category string
+		debit           bool
+		want            string
+	}{
+		{"Yabox", "Lebensmittel", false, "Income:Salary"},
+		{"Rewe", "Lebensmittel", true, "Expenses:Groceries"},
+		{"Rewe", "Unbekannt", true, "Expenses:Unknown"},
+		{"Yabox GmbH", "", false, "Income:Unknown"},
+	}
+	for _, tt := range counters {
+		if got := r.counterAccount(tt.payee, tt.category, tt.debit); got != tt.want {
+			t.Errorf("counterAccount(%s, %s) = %s, want %s", tt.payee, tt.category, got, tt.want)
+		}
+	}
+}
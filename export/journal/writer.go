@@ -0,0 +1,224 @@
+// Package journal writes the accounts as plain text accounting journals for ledger, hledger and beancount
+package journal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/export"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+// supported dialects of the journal
+const (
+	Ledger    = "ledger"
+	HLedger   = "hledger"
+	Beancount = "beancount"
+)
+
+var extensions = map[string]string{
+	Ledger:    ".ledger",
+	HLedger:   ".journal",
+	Beancount: ".beancount",
+}
+
+func init() {
+	for _, dialect := range []string{Ledger, HLedger, Beancount} {
+		dialect := dialect
+		export.Register(dialect, func(opts export.Options) (export.Writer, error) {
+			rules := &Rules{}
+			if opts.JournalRules != "" {
+				var err error
+				rules, err = LoadRules(opts.JournalRules)
+				if err != nil {
+					return nil, err
+				}
+			} else if err := rules.setDefaults(); err != nil {
+				return nil, err
+			}
+			return New(dialect, rules)
+		})
+	}
+}
+
+// Writer writes one journal entry per transaction with the asset account of the bank account
+// and the counter account from the rules
+type Writer struct {
+	dialect string
+	rules   *Rules
+}
+
+// New creates a Writer for ledger, hledger or beancount, rules must have been loaded with LoadRules
+func New(dialect string, rules *Rules) (*Writer, error) {
+	if _, ok := extensions[dialect]; !ok {
+		return nil, fmt.Errorf("journal dialect %s not supported, use %s, %s or %s", dialect, Ledger, HLedger, Beancount)
+	}
+	if rules == nil {
+		return nil, fmt.Errorf("no journal rules given")
+	}
+	return &Writer{dialect: dialect, rules: rules}, nil
+}
+
+func (j *Writer) Extension() string {
+	return extensions[j.dialect]
+}
+
+// entry is a transaction with its postings, assertion is the saldo after the last transaction of a day
+type entry struct {
+	date      time.Time
+	payee     string
+	note      string
+	asset     string
+	counter   string
+	amount    *money.Money
+	assertion *money.Money
+}
+
+// Write writes the entries of all accounts, beancount journals start with the open directives of all used accounts
+func (j *Writer) Write(w io.Writer, accounts []*mt940.BankData) error {
+	var entries []entry
+	for _, account := range accounts {
+		e, err := j.entries(account)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, e...)
+	}
+
+	var b strings.Builder
+	if j.dialect == Beancount {
+		j.writeOpen(&b, entries)
+	}
+	for _, e := range entries {
+		switch j.dialect {
+		case Ledger:
+			writeLedger(&b, e)
+		case HLedger:
+			writeHLedger(&b, e)
+		case Beancount:
+			writeBeancount(&b, e)
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("could not write journal: %w", err)
+	}
+	return nil
+}
+
+// entries converts the transactions of the account, the last transaction of every day gets the saldo as assertion
+func (j *Writer) entries(s *mt940.BankData) ([]entry, error) {
+	asset := j.rules.assetAccount(s)
+	entries := make([]entry, 0, len(s.Transactions))
+	for i, t := range s.Transactions {
+		line, err := mt940.ToStatementLine(t)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert transaction %d: %w", i, err)
+		}
+		// payee and note are taken from the transaction, :86: cuts the name to 54 characters and splits the purpose
+		payee := t.Counterparty().Name
+		if payee == "" {
+			payee = line.Details.BookingText
+		}
+		category := ""
+		if c, ok := t.(mt940.Categorized); ok {
+			category = c.Category()
+		}
+
+		if n := len(entries); n > 0 && entries[n-1].date.Equal(line.Date()) {
+			entries[n-1].assertion = nil
+		}
+		entries = append(entries, entry{
+			date:      line.Date(),
+			payee:     singleLine(payee),
+			note:      singleLine(t.References().Remittance),
+			asset:     asset,
+			counter:   j.rules.counterAccount(payee, category, line.Amount().IsNegative()),
+			amount:    line.Amount(),
+			assertion: t.Saldo(),
+		})
+	}
+	return entries, nil
+}
+
+// writeOpen writes the open directives of all accounts at the date of the first entry
+func (j *Writer) writeOpen(b *strings.Builder, entries []entry) {
+	if len(entries) == 0 {
+		return
+	}
+	first := entries[0].date
+	used := make(map[string]bool)
+	for _, e := range entries {
+		if e.date.Before(first) {
+			first = e.date
+		}
+		used[e.asset], used[e.counter] = true, true
+	}
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(first.Format("2006-01-02") + " open " + name + "\n")
+	}
+	b.WriteString("\n")
+}
+
+// writeLedger writes the entry with the note as comment and the assertion on the asset posting
+func writeLedger(b *strings.Builder, e entry) {
+	b.WriteString(e.date.Format("2006/01/02") + " * " + e.payee + "\n")
+	if e.note != "" {
+		b.WriteString("    ; " + e.note + "\n")
+	}
+	writePostings(b, e)
+}
+
+// writeHLedger writes the entry with payee and note separated by a pipe and the assertion on the asset posting
+func writeHLedger(b *strings.Builder, e entry) {
+	description := strings.ReplaceAll(e.payee, "|", "/")
+	if e.note != "" {
+		description += " | " + e.note
+	}
+	b.WriteString(e.date.Format("2006-01-02") + " * " + description + "\n")
+	writePostings(b, e)
+}
+
+// writePostings writes the asset posting with the amount and the counter posting without amount
+func writePostings(b *strings.Builder, e entry) {
+	b.WriteString("    " + e.asset + "  " + formatAmount(e.amount))
+	if e.assertion != nil {
+		b.WriteString(" = " + formatAmount(e.assertion))
+	}
+	b.WriteString("\n    " + e.counter + "\n\n")
+}
+
+// writeBeancount writes the entry with quoted payee and narration, the assertion is written as balance directive
+// on the next day because beancount checks the balance at the beginning of the day
+func writeBeancount(b *strings.Builder, e entry) {
+	b.WriteString(e.date.Format("2006-01-02") + " * " + quote(e.payee) + " " + quote(e.note) + "\n")
+	b.WriteString("  " + e.asset + "  " + formatAmount(e.amount) + "\n")
+	b.WriteString("  " + e.counter + "\n\n")
+	if e.assertion != nil {
+		b.WriteString(e.date.AddDate(0, 0, 1).Format("2006-01-02") + " balance " + e.asset + "  " + formatAmount(e.assertion) + "\n\n")
+	}
+}
+
+// formatAmount formats the amount with sign, a decimal point and the currency, e.g. -1.62 EUR
+func formatAmount(m *money.Money) string {
+	c := m.Currency()
+	return money.NewFormatter(c.Fraction, ".", "", "", "1").Format(m.Amount()) + " " + c.Code
+}
+
+// quote returns s as string of beancount
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// singleLine replaces line breaks, payee and note are written in a single line
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,156 @@
+package journal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+const testStatement = ":20:CSVTOMT940\r\n" +
+	":25:37040044/0532013000\r\n" +
+	":28C:0\r\n" +
+	":60F:C200106EUR1173,74\r\n" +
+	":61:2001060106C1188,32NTRFNONREF\r\n" +
+	":86:166?00Gutschrift?20EREF+E-456?21SVWZ+Gehalt Januar?32Yabox\r\n" +
+	":61:2001060106D10,00NTRFNONREF\r\n" +
+	":86:105?00Lastschrift?20SVWZ+Beitrag \"Januar\"?32Verein|Sport\r\n" +
+	":61:2001090109D1,62NTRFNONREF\r\n" +
+	":86:105?00Lastschrift\r\n" +
+	":62F:C200109EUR2350,44\r\n"
+
+// categorized is a transaction of a csv export with a category column
+type categorized struct {
+	*mt940.StatementLine
+	category string
+}
+
+func (c *categorized) Category() string {
+	return c.category
+}
+
+func testData(t *testing.T) []*mt940.BankData {
+	data, err := mt940.Read(strings.NewReader(testStatement))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[0].Transactions[2] = &categorized{StatementLine: data[0].Transactions[2].(*mt940.StatementLine), category: "Gebühren"}
+	return data
+}
+
+func testRules(t *testing.T) *Rules {
+	r := &Rules{
+		Payees:     []PayeeRule{{Pattern: "Yabox", Account: "Income:Salary"}},
+		Categories: map[string]string{"Gebühren": "Expenses:Fees"},
+	}
+	if err := r.setDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestWriter_Write(t *testing.T) {
+	tests := []struct {
+		dialect string
+		want    string
+	}{
+		{
+			dialect: Ledger,
+			want: "2020/01/06 * Yabox\n" +
+				"    ; Gehalt Januar\n" +
+				"    Assets:Bank:37040044:0532013000  1188.32 EUR\n" +
+				"    Income:Salary\n\n" +
+				"2020/01/06 * Verein|Sport\n" +
+				"    ; Beitrag \"Januar\"\n" +
+				"    Assets:Bank:37040044:0532013000  -10.00 EUR = 2352.06 EUR\n" +
+				"    Expenses:Unknown\n\n" +
+				"2020/01/09 * Lastschrift\n" +
+				"    Assets:Bank:37040044:0532013000  -1.62 EUR = 2350.44 EUR\n" +
+				"    Expenses:Fees\n\n",
+		},
+		{
+			dialect: HLedger,
+			want: "2020-01-06 * Yabox | Gehalt Januar\n" +
+				"    Assets:Bank:37040044:0532013000  1188.32 EUR\n" +
+				"    Income:Salary\n\n" +
+				"2020-01-06 * Verein/Sport | Beitrag \"Januar\"\n" +
+				"    Assets:Bank:37040044:0532013000  -10.00 EUR = 2352.06 EUR\n" +
+				"    Expenses:Unknown\n\n" +
+				"2020-01-09 * Lastschrift\n" +
+				"    Assets:Bank:37040044:0532013000  -1.62 EUR = 2350.44 EUR\n" +
+				"    Expenses:Fees\n\n",
+		},
+		{
+			dialect: Beancount,
+			want: "2020-01-06 open Assets:Bank:37040044:0532013000\n" +
+				"2020-01-06 open Expenses:Fees\n" +
+				"2020-01-06 open Expenses:Unknown\n" +
+				"2020-01-06 open Income:Salary\n\n" +
+				"2020-01-06 * \"Yabox\" \"Gehalt Januar\"\n" +
+				"  Assets:Bank:37040044:0532013000  1188.32 EUR\n" +
+				"  Income:Salary\n\n" +
+				"2020-01-06 * \"Verein|Sport\" \"Beitrag \\\"Januar\\\"\"\n" +
+				"  Assets:Bank:37040044:0532013000  -10.00 EUR\n" +
+				"  Expenses:Unknown\n\n" +
+				"2020-01-07 balance Assets:Bank:37040044:0532013000  2352.06 EUR\n\n" +
+				"2020-01-09 * \"Lastschrift\" \"\"\n" +
+				"  Assets:Bank:37040044:0532013000  -1.62 EUR\n" +
+				"  Expenses:Fees\n\n" +
+				"2020-01-10 balance Assets:Bank:37040044:0532013000  2350.44 EUR\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			w, err := New(tt.dialect, testRules(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := w.Write(&buf, testData(t)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Write() =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("gnucash", testRules(t)); err == nil {
+		t.Errorf("New() with unknown dialect returned no error")
+	}
+	if _, err := New(Ledger, nil); err == nil {
+		t.Errorf("New() without rules returned no error")
+	}
+}
+
+func TestWriter_SourceFields(t *testing.T) {
+	payee := "Hausverwaltung Mueller und Schmidt Immobilienbetreuung GmbH"
+	// the subfields of :86: are split after "Nebenkosten Wohnung zweites" and lose the space
+	note := "Nebenkosten Wohnung zweites Obergeschoss"
+	line := &mt940.StatementLine{
+		Sales:   mt940.SalesLine{ValueDate: time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), Amount: money.New(-10000, "EUR")},
+		Details: mt940.Details{GVC: "020", BookingText: "Ueberweisung"},
+		Balance: money.New(-10000, "EUR"),
+	}
+	line.SetReferences(converter.SEPAReferences{Remittance: note}, 8)
+	line.SetCounterparty(mt940.Counterparty{Name: payee})
+
+	w, err := New(HLedger, testRules(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	data := []*mt940.BankData{{BankNumber: "37040044", AccountNumber: "0532013000", Transactions: []mt940.Transaction{line}}}
+	if err := w.Write(&buf, data); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if want := "2020-01-06 * " + payee + " | " + note + "\n"; !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("Write() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
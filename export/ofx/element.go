@@ -0,0 +1,41 @@
+package ofx
+
+import "strings"
+
+// escaper escapes the characters that are not allowed in the content of OFX elements
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// element is an aggregate with children or an element with a value
+type element struct {
+	name     string
+	value    string
+	children []*element
+}
+
+// leaf returns an element with the value
+func leaf(name, value string) *element {
+	return &element{name: name, value: value}
+}
+
+// add appends the child to the aggregate
+func (e *element) add(child *element) {
+	e.children = append(e.children, child)
+}
+
+// write writes the element indented by depth, the elements with a value are not closed in SGML
+func (e *element) write(b *strings.Builder, depth int, sgml bool) {
+	indent := strings.Repeat("  ", depth)
+	if e.children == nil {
+		b.WriteString(indent + "<" + e.name + ">" + escaper.Replace(e.value))
+		if !sgml {
+			b.WriteString("</" + e.name + ">")
+		}
+		b.WriteString("\n")
+		return
+	}
+	b.WriteString(indent + "<" + e.name + ">\n")
+	for _, c := range e.children {
+		c.write(b, depth+1, sgml)
+	}
+	b.WriteString(indent + "</" + e.name + ">\n")
+}
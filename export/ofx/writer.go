@@ -0,0 +1,261 @@
+// Package ofx writes the accounts as OFX bank statements, version 2.2 as XML and version 1.0.2 as SGML
+package ofx
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/export"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// supported versions of OFX, DefaultVersion is used if no version is given
+const (
+	Version102     = "102"
+	Version220     = "220"
+	DefaultVersion = Version220
+)
+
+// maximum lengths of the text elements
+const (
+	maxNameLength   = 32
+	maxMemoLength   = 255
+	maxRefNumLength = 32
+	// fitIDLength is the number of hex characters of the hash that is used as FITID
+	fitIDLength = 32
+)
+
+// transactionTypes maps the GVC codes to the TRNTYPE, other transactions are a CREDIT or DEBIT
+var transactionTypes = map[string]string{
+	"083": "ATM",
+	"082": "DEP",
+	"106": "POS",
+	"004": "POS",
+	"105": "DIRECTDEBIT",
+	"104": "DIRECTDEBIT",
+	"109": "DIRECTDEBIT",
+	"005": "DIRECTDEBIT",
+	"152": "REPEATPMT",
+	"117": "REPEATPMT",
+	"008": "REPEATPMT",
+	"052": "REPEATPMT",
+	"153": "DIRECTDEP",
+	"053": "DIRECTDEP",
+	"805": "INT",
+	"808": "SRVCHG",
+}
+
+func init() {
+	export.Register("ofx", func(opts export.Options) (export.Writer, error) {
+		return New(opts.OFXVersion)
+	})
+}
+
+// Writer writes all accounts into one OFX file with a statement response for every account
+type Writer struct {
+	version string
+	now     func() time.Time
+}
+
+// New creates a Writer for OFX 1.0.2 (SGML) or 2.2 (XML)
+func New(version string) (*Writer, error) {
+	switch version {
+	case "":
+		version = DefaultVersion
+	case Version102, Version220:
+	default:
+		return nil, fmt.Errorf("ofx version %s not supported, use %s or %s", version, Version102, Version220)
+	}
+	return &Writer{version: version, now: time.Now}, nil
+}
+
+func (o *Writer) Extension() string {
+	return ".ofx"
+}
+
+// Write writes the header and the statements of all accounts
+func (o *Writer) Write(w io.Writer, accounts []*mt940.BankData) error {
+	bank := &element{name: "BANKMSGSRSV1"}
+	for i, account := range accounts {
+		statement, err := o.statement(account)
+		if err != nil {
+			return err
+		}
+		bank.add(&element{name: "STMTTRNRS", children: []*element{
+			leaf("TRNUID", fmt.Sprintf("%d", i+1)),
+			status(),
+			statement,
+		}})
+	}
+	root := &element{name: "OFX", children: []*element{
+		{name: "SIGNONMSGSRSV1", children: []*element{
+			{name: "SONRS", children: []*element{
+				status(),
+				leaf("DTSERVER", o.now().Format("20060102150405")),
+				leaf("LANGUAGE", "GER"),
+			}},
+		}},
+		bank,
+	}}
+
+	var b strings.Builder
+	if o.version == Version102 {
+		b.WriteString("OFXHEADER:100\r\nDATA:OFXSGML\r\nVERSION:102\r\nSECURITY:NONE\r\nENCODING:USASCII\r\n" +
+			"CHARSET:1252\r\nCOMPRESSION:NONE\r\nOLDFILEUID:NONE\r\nNEWFILEUID:NONE\r\n\r\n")
+	} else {
+		b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n" +
+			"<?OFX OFXHEADER=\"200\" VERSION=\"" + o.version + "\" SECURITY=\"NONE\" OLDFILEUID=\"NONE\" NEWFILEUID=\"NONE\"?>\n")
+	}
+	root.write(&b, 0, o.version == Version102)
+
+	content := b.String()
+	if o.version == Version102 {
+		// OFX 1.x is written in windows-1252 as announced in the header
+		var err error
+		content, err = encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder()).String(content)
+		if err != nil {
+			return fmt.Errorf("could not encode ofx: %w", err)
+		}
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		return fmt.Errorf("could not write ofx: %w", err)
+	}
+	return nil
+}
+
+// status returns the STATUS aggregate of a successful response
+func status() *element {
+	return &element{name: "STATUS", children: []*element{leaf("CODE", "0"), leaf("SEVERITY", "INFO")}}
+}
+
+// statement creates the STMTRS of the account with all transactions and the closing balance as LEDGERBAL
+func (o *Writer) statement(s *mt940.BankData) (*element, error) {
+	opening, err := s.OpeningBalance()
+	if err != nil {
+		return nil, fmt.Errorf("could not create ofx statement of %s: %w", s.AccountNumber, err)
+	}
+	closing, err := s.ClosingBalance()
+	if err != nil {
+		return nil, fmt.Errorf("could not create ofx statement of %s: %w", s.AccountNumber, err)
+	}
+	currency := s.Currency
+	if currency == "" {
+		currency = closing.Amount.Currency().Code
+	}
+
+	list := &element{name: "BANKTRANLIST", children: []*element{
+		leaf("DTSTART", opening.Date.Format("20060102")),
+		leaf("DTEND", closing.Date.Format("20060102")),
+	}}
+	// identical transactions get a counter so that their FITID differs
+	seen := make(map[string]int)
+	for i, t := range s.Transactions {
+		line, err := mt940.ToStatementLine(t)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert transaction %d: %w", i, err)
+		}
+		content := transactionContent(s, line, t)
+		list.add(transaction(line, t, fitID(content, seen[content])))
+		seen[content]++
+	}
+
+	return &element{name: "STMTRS", children: []*element{
+		leaf("CURDEF", currency),
+		{name: "BANKACCTFROM", children: []*element{
+			leaf("BANKID", s.BankNumber),
+			leaf("ACCTID", s.AccountNumber),
+			leaf("ACCTTYPE", "CHECKING"),
+		}},
+		list,
+		{name: "LEDGERBAL", children: []*element{
+			leaf("BALAMT", formatAmount(closing.Amount)),
+			leaf("DTASOF", closing.Date.Format("20060102")),
+		}},
+	}}, nil
+}
+
+// transaction creates the STMTTRN of the statement line, the name is the counterparty or the booking text
+// and the memo is the remittance information, both are taken from the transaction because :86: cuts them
+func transaction(l *mt940.StatementLine, t mt940.Transaction, id string) *element {
+	d := l.Details
+	name := t.Counterparty().Name
+	if name == "" {
+		name = d.BookingText
+	}
+	memo := t.References().Remittance
+	if memo == "" {
+		memo = d.BookingText
+	}
+
+	typ, ok := transactionTypes[d.GVC]
+	if !ok {
+		typ = "CREDIT"
+		if l.Amount().IsNegative() {
+			typ = "DEBIT"
+		}
+	}
+	trn := &element{name: "STMTTRN", children: []*element{
+		leaf("TRNTYPE", typ),
+		leaf("DTPOSTED", l.Date().Format("20060102")),
+		leaf("DTUSER", l.Sales.ValueDate.Format("20060102")),
+		leaf("TRNAMT", formatAmount(l.Amount())),
+		leaf("FITID", id),
+	}}
+	if l.Sales.BankReference != "" {
+		trn.add(leaf("REFNUM", cut(l.Sales.BankReference, maxRefNumLength)))
+	}
+	if name != "" {
+		trn.add(leaf("NAME", cut(name, maxNameLength)))
+	}
+	if memo != "" {
+		trn.add(leaf("MEMO", cut(memo, maxMemoLength)))
+	}
+	return trn
+}
+
+// transactionContent returns the fields of the transaction that identify it, the saldo is not part of it
+// because it depends on the start saldo of csv exports without saldo, the layout of :86: and the BIC are not part of it
+// because they change with the converter and the bank code directory
+func transactionContent(s *mt940.BankData, l *mt940.StatementLine, t mt940.Transaction) string {
+	references := t.References()
+	return strings.Join([]string{
+		s.BankNumber,
+		s.AccountNumber,
+		l.Date().Format("20060102"),
+		l.Sales.ValueDate.Format("20060102"),
+		formatAmount(l.Amount()),
+		l.Amount().Currency().Code,
+		l.Sales.BankReference,
+		t.Counterparty().Account.AccountNumber,
+		references.EndToEnd,
+		references.Remittance,
+	}, "\n")
+}
+
+// fitID returns the hash of the content and the number of identical transactions before it,
+// the same transaction gets the same FITID in every export so that overlapping imports do not create duplicates
+func fitID(content string, occurrence int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%d", content, occurrence)))
+	return fmt.Sprintf("%x", sum)[:fitIDLength]
+}
+
+// formatAmount formats the amount with sign and a decimal point, e.g. -1.62
+func formatAmount(m *money.Money) string {
+	c := m.Currency()
+	return money.NewFormatter(c.Fraction, ".", "", "", "1").Format(m.Amount())
+}
+
+// cut shortens s to at most max characters
+func cut(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if r := []rune(s); len(r) > max {
+		return string(r[:max])
+	}
+	return s
+}
@@ -0,0 +1,175 @@
+package ofx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+const testStatement = ":20:CSVTOMT940\r\n" +
+	":25:37040044/0532013000\r\n" +
+	":28C:0\r\n" +
+	":60F:C200106EUR1173,74\r\n" +
+	":61:2001060106C16,20NTRFNONREF//REF-1\r\n" +
+	":86:166?00Gutschrift?20EREF+E-456?21SVWZ+Rechnung 42 & 43?32Jörg Müller\r\n" +
+	":61:2001090109D1,62NTRFNONREF\r\n" +
+	":86:105?00Lastschrift?20SVWZ+Reactive full-range lo?21cal area network?32Yabox\r\n" +
+	":61:2001090109D1,62NTRFNONREF\r\n" +
+	":86:105?00Lastschrift?20SVWZ+Reactive full-range lo?21cal area network?32Yabox\r\n" +
+	":62F:C200109EUR1186,70\r\n"
+
+func testData(t *testing.T, statement string) []*mt940.BankData {
+	data, err := mt940.Read(strings.NewReader(statement))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func testWriter(t *testing.T, version string) *Writer {
+	w, err := New(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return time.Date(2020, 1, 10, 8, 0, 0, 0, time.UTC) }
+	return w
+}
+
+func TestWriter_Write(t *testing.T) {
+	tests := []struct {
+		version string
+		want    []string
+	}{
+		{
+			version: Version220,
+			want: []string{
+				"<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n<?OFX OFXHEADER=\"200\" VERSION=\"220\"",
+				"<DTSERVER>20200110080000</DTSERVER>",
+				"<BANKACCTFROM>\n          <BANKID>37040044</BANKID>\n          <ACCTID>0532013000</ACCTID>\n          <ACCTTYPE>CHECKING</ACCTTYPE>",
+				"<DTSTART>20200106</DTSTART>\n          <DTEND>20200109</DTEND>",
+				"<TRNTYPE>CREDIT</TRNTYPE>\n            <DTPOSTED>20200106</DTPOSTED>\n            <DTUSER>20200106</DTUSER>\n            <TRNAMT>16.20</TRNAMT>",
+				"<REFNUM>REF-1</REFNUM>\n            <NAME>Jörg Müller</NAME>\n            <MEMO>Rechnung 42 &amp; 43</MEMO>",
+				"<TRNTYPE>DIRECTDEBIT</TRNTYPE>",
+				"<TRNAMT>-1.62</TRNAMT>",
+				"<MEMO>Reactive full-range local area network</MEMO>",
+				"<LEDGERBAL>\n          <BALAMT>1186.70</BALAMT>\n          <DTASOF>20200109</DTASOF>\n        </LEDGERBAL>",
+			},
+		},
+		{
+			version: Version102,
+			want: []string{
+				"OFXHEADER:100\r\nDATA:OFXSGML\r\nVERSION:102\r\nSECURITY:NONE\r\nENCODING:USASCII\r\nCHARSET:1252\r\n",
+				"<CURDEF>EUR\n",
+				"<NAME>J\xf6rg M\xfcller\n",
+				"<TRNAMT>-1.62\n",
+				"</STMTTRN>",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := testWriter(t, tt.version).Write(&buf, testData(t, testStatement)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("Write() does not contain %q:\n%s", want, buf.String())
+				}
+			}
+			if tt.version != Version220 {
+				return
+			}
+			dec := xml.NewDecoder(&buf)
+			for {
+				_, err := dec.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Write() is not valid xml: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestWriter_FITID(t *testing.T) {
+	fitIDs := func(statement string) []string {
+		var buf bytes.Buffer
+		if err := testWriter(t, "").Write(&buf, testData(t, statement)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		var ids []string
+		for _, m := range regexp.MustCompile(`<FITID>([0-9a-f]+)</FITID>`).FindAllStringSubmatch(buf.String(), -1) {
+			ids = append(ids, m[1])
+		}
+		return ids
+	}
+
+	ids := fitIDs(testStatement)
+	if len(ids) != 3 || ids[1] == ids[2] {
+		t.Fatalf("FITIDs %v, want 3 different ids", ids)
+	}
+	// the same transactions with another start saldo and without the first one
+	overlap := strings.Replace(testStatement, ":60F:C200106EUR1173,74", ":60F:C200106EUR100,00", 1)
+	overlap = strings.Replace(overlap, ":62F:C200109EUR1186,70", ":62F:C200109EUR96,76", 1)
+	overlap = overlap[:strings.Index(overlap, ":61:2001060106")] + overlap[strings.Index(overlap, ":61:2001090109"):]
+	if got := fitIDs(overlap); len(got) != 2 || got[0] != ids[1] || got[1] != ids[2] {
+		t.Errorf("FITIDs of overlapping statement = %v, want %v", got, ids[1:])
+	}
+}
+
+func TestWriter_WriteErrors(t *testing.T) {
+	w := testWriter(t, "")
+	err := w.Write(&bytes.Buffer{}, []*mt940.BankData{{AccountNumber: "0532013000"}})
+	if err == nil || !strings.Contains(err.Error(), "no transactions found") {
+		t.Errorf("Write() error = %v", err)
+	}
+	if _, err := New("211"); err == nil {
+		t.Errorf("New() of unsupported version returned no error")
+	}
+}
+
+func TestWriter_SourceFields(t *testing.T) {
+	remittance := "Rechnung 42 " + strings.Repeat("Position ", 20)
+	line := func(bic string, maxPurpose int) *mt940.StatementLine {
+		l := &mt940.StatementLine{
+			Sales:   mt940.SalesLine{ValueDate: time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), Amount: money.New(1620, "EUR")},
+			Details: mt940.Details{GVC: "166", BookingText: "Gutschrift"},
+			Balance: money.New(1620, "EUR"),
+		}
+		l.SetReferences(converter.SEPAReferences{EndToEnd: "E-456", Remittance: strings.TrimSpace(remittance)}, maxPurpose)
+		l.SetCounterparty(mt940.Counterparty{Name: "Jörg Müller", Account: mt940.NewCounterpartyAccount("DE02500105170137075030", bic)})
+		return l
+	}
+	write := func(l *mt940.StatementLine) string {
+		var buf bytes.Buffer
+		data := []*mt940.BankData{{BankNumber: "37040044", AccountNumber: "0532013000", Transactions: []mt940.Transaction{l}}}
+		if err := testWriter(t, "").Write(&buf, data); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		return buf.String()
+	}
+
+	got := write(line("INGDDEFFXXX", 8))
+	for _, want := range []string{"<NAME>Jörg Müller</NAME>", "<MEMO>" + strings.TrimSpace(remittance) + "</MEMO>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Write() does not contain %q:\n%s", want, got)
+		}
+	}
+	// the FITID does not change with the BIC and the layout of :86:
+	fitID := regexp.MustCompile(`<FITID>([0-9a-f]+)</FITID>`)
+	other := write(line("", 2))
+	if a, b := fitID.FindStringSubmatch(got), fitID.FindStringSubmatch(other); a == nil || b == nil || a[1] != b[1] {
+		t.Errorf("FITID = %v, want %v", b, a)
+	}
+}
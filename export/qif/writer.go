@@ -0,0 +1,125 @@
+// Package qif writes the accounts as QIF bank transactions
+package qif
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/JHeimbach/csvtomt940/export"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+// dateFormats are the date formats of the locales, DefaultDateFormat is used if no format is given
+var dateFormats = map[string]string{
+	"us":  "01/02/2006",
+	"uk":  "02/01/2006",
+	"de":  "02.01.2006",
+	"iso": "2006-01-02",
+}
+
+// DefaultDateFormat is the locale of the dates if no date format is given
+const DefaultDateFormat = "us"
+
+func init() {
+	export.Register("qif", func(opts export.Options) (export.Writer, error) {
+		return New(opts.QIFDateFormat)
+	})
+}
+
+// Writer writes QIF files with a !Type:Bank section for every account
+type Writer struct {
+	dateLayout string
+}
+
+// New creates a Writer with the date format of a locale (us, uk, de or iso) or a go time layout, e.g. 02.01.06
+func New(dateFormat string) (*Writer, error) {
+	if dateFormat == "" {
+		dateFormat = DefaultDateFormat
+	}
+	layout, ok := dateFormats[dateFormat]
+	if !ok {
+		if !strings.Contains(dateFormat, "01") || !strings.Contains(dateFormat, "02") || !strings.Contains(dateFormat, "06") {
+			return nil, fmt.Errorf("qif date format %s is neither a locale (us, uk, de, iso) nor a go time layout", dateFormat)
+		}
+		layout = dateFormat
+	}
+	return &Writer{dateLayout: layout}, nil
+}
+
+func (q *Writer) Extension() string {
+	return ".qif"
+}
+
+// Write writes the transactions of all accounts, if there is more than one account every section starts with
+// an !Account block so that the transactions are imported into the right account
+func (q *Writer) Write(w io.Writer, accounts []*mt940.BankData) error {
+	var b strings.Builder
+	for _, account := range accounts {
+		if len(accounts) > 1 {
+			b.WriteString("!Account\nN" + accountName(account) + "\nTBank\n^\n")
+		}
+		b.WriteString("!Type:Bank\n")
+		for i, t := range account.Transactions {
+			if err := q.writeTransaction(&b, t); err != nil {
+				return fmt.Errorf("could not convert transaction %d: %w", i, err)
+			}
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("could not write qif: %w", err)
+	}
+	return nil
+}
+
+// writeTransaction writes the date, amount, payee, memo and category of the transaction, payee and memo are the counterparty
+// and the remittance information of the transaction that :86: cuts, the category is taken from the csv if it has one
+func (q *Writer) writeTransaction(b *strings.Builder, t mt940.Transaction) error {
+	line, err := mt940.ToStatementLine(t)
+	if err != nil {
+		return err
+	}
+	payee := t.Counterparty().Name
+	if payee == "" {
+		payee = line.Details.BookingText
+	}
+	memo := t.References().Remittance
+
+	b.WriteString("D" + line.Date().Format(q.dateLayout) + "\n")
+	b.WriteString("T" + formatAmount(line.Amount()) + "\n")
+	if payee != "" {
+		b.WriteString("P" + singleLine(payee) + "\n")
+	}
+	if memo != "" {
+		b.WriteString("M" + singleLine(memo) + "\n")
+	}
+	if c, ok := t.(mt940.Categorized); ok && c.Category() != "" {
+		b.WriteString("L" + singleLine(c.Category()) + "\n")
+	}
+	b.WriteString("^\n")
+	return nil
+}
+
+// accountName returns the IBAN or the account number with the bank number and the currency if the account has several
+func accountName(s *mt940.BankData) string {
+	name := s.IBAN
+	if name == "" {
+		name = s.BankNumber + "/" + s.AccountNumber
+	}
+	if s.Currency != "" && !strings.HasSuffix(name, s.Currency) {
+		name += " " + s.Currency
+	}
+	return name
+}
+
+// formatAmount formats the amount with sign and a decimal point, e.g. -1.62
+func formatAmount(m *money.Money) string {
+	c := m.Currency()
+	return money.NewFormatter(c.Fraction, ".", "", "", "1").Format(m.Amount())
+}
+
+// singleLine replaces line breaks, every field of QIF is a single line
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
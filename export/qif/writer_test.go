@@ -0,0 +1,127 @@
+package qif
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+const testStatement = ":20:CSVTOMT940\r\n" +
+	":25:37040044/0532013000\r\n" +
+	":28C:0\r\n" +
+	":60F:C200106EUR1173,74\r\n" +
+	":61:2001060106C1188,32NTRFNONREF\r\n" +
+	":86:166?00Gutschrift?20EREF+E-456?21SVWZ+Gehalt Januar?32Yabox\r\n" +
+	":61:2001090109D1,62NTRFNONREF\r\n" +
+	":86:105?00Lastschrift\r\n" +
+	":62F:C200109EUR2360,44\r\n"
+
+// categorized is a transaction of a csv export with a category column
+type categorized struct {
+	*mt940.StatementLine
+	category string
+}
+
+func (c *categorized) Category() string {
+	return c.category
+}
+
+func testData(t *testing.T) []*mt940.BankData {
+	data, err := mt940.Read(strings.NewReader(testStatement))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[0].Transactions[0] = &categorized{StatementLine: data[0].Transactions[0].(*mt940.StatementLine), category: "Salary"}
+	return data
+}
+
+func TestWriter_Write(t *testing.T) {
+	tests := []struct {
+		name       string
+		dateFormat string
+		accounts   func(data []*mt940.BankData) []*mt940.BankData
+		want       string
+	}{
+		{
+			name: "default date format",
+			want: "!Type:Bank\n" +
+				"D01/06/2020\nT1188.32\nPYabox\nMGehalt Januar\nLSalary\n^\n" +
+				"D01/09/2020\nT-1.62\nPLastschrift\n^\n",
+		},
+		{
+			name:       "locale",
+			dateFormat: "de",
+			want: "!Type:Bank\n" +
+				"D06.01.2020\nT1188.32\nPYabox\nMGehalt Januar\nLSalary\n^\n" +
+				"D09.01.2020\nT-1.62\nPLastschrift\n^\n",
+		},
+		{
+			name:       "layout and several accounts",
+			dateFormat: "02.01.06",
+			accounts: func(data []*mt940.BankData) []*mt940.BankData {
+				usd := *data[0]
+				usd.IBAN, usd.Currency, usd.Transactions = "DE89370400440532013000", "USD", usd.Transactions[1:]
+				return append(data, &usd)
+			},
+			want: "!Account\nN37040044/0532013000\nTBank\n^\n!Type:Bank\n" +
+				"D06.01.20\nT1188.32\nPYabox\nMGehalt Januar\nLSalary\n^\n" +
+				"D09.01.20\nT-1.62\nPLastschrift\n^\n" +
+				"!Account\nNDE89370400440532013000 USD\nTBank\n^\n!Type:Bank\n" +
+				"D09.01.20\nT-1.62\nPLastschrift\n^\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := New(tt.dateFormat)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data := testData(t)
+			if tt.accounts != nil {
+				data = tt.accounts(data)
+			}
+			var buf bytes.Buffer
+			if err := w.Write(&buf, data); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Write() =\n%q\nwant\n%q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("fr"); err == nil {
+		t.Errorf("New() with unknown date format returned no error")
+	}
+}
+
+func TestWriter_SourceFields(t *testing.T) {
+	name := "Jörg Müller Hausverwaltung und Immobilienbetreuung GmbH & Co. KG"
+	remittance := "Nebenkostenabrechnung 2019 fuer die Wohnung im zweiten Obergeschoss links"
+	line := &mt940.StatementLine{
+		Sales:   mt940.SalesLine{ValueDate: time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), Amount: money.New(-10000, "EUR")},
+		Details: mt940.Details{GVC: "020", BookingText: "Ueberweisung"},
+		Balance: money.New(-10000, "EUR"),
+	}
+	line.SetReferences(converter.SEPAReferences{Remittance: remittance}, 2)
+	line.SetCounterparty(mt940.Counterparty{Name: name})
+
+	w, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := w.Write(&buf, []*mt940.BankData{{Transactions: []mt940.Transaction{line}}}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if want := "!Type:Bank\nD01/06/2020\nT-100.00\nP" + name + "\nM" + remittance + "\n^\n"; buf.String() != want {
+		t.Errorf("Write() =\n%q\nwant\n%q", buf.String(), want)
+	}
+}
@@ -1,10 +1,39 @@
 package formatter
 
-import "github.com/Rhymond/go-money"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Rhymond/go-money"
+)
 
 // swiftMoneyFormatter formats money values according the specification for amount values in MT940
 var swiftMoneyFormatter = money.NewFormatter(2, ",", "", "", "1")
 
+// ConvertMoneyToString formats the money value without sign for the use in MT940 amount fields
 func ConvertMoneyToString(m *money.Money) string {
 	return swiftMoneyFormatter.Format(m.Amount())
 }
+
+// ConvertStringToMoney parses an amount value formatted according the specification for amount values in MT940,
+// it is the counterpart to ConvertMoneyToString and returns always a positive value
+func ConvertStringToMoney(s string, currency string) (*money.Money, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, fmt.Errorf("amount %q must contain exactly one decimal comma", s)
+	}
+	if len(parts[1]) > 2 {
+		return nil, fmt.Errorf("amount %q has more than 2 decimal places", s)
+	}
+	for _, c := range parts[0] + parts[1] {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("amount %q contains invalid character %q", s, c)
+		}
+	}
+	cents, err := strconv.ParseInt(parts[0]+parts[1]+strings.Repeat("0", 2-len(parts[1])), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse amount %q: %w", s, err)
+	}
+	return money.New(cents, currency), nil
+}
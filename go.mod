@@ -1,8 +1,9 @@
 module github.com/JHeimbach/csvtomt940
 
-go 1.14
+go 1.17
 
 require (
 	github.com/Rhymond/go-money v1.0.1
 	golang.org/x/text v0.3.8
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,109 @@
+// Package iban validates IBANs and splits them into bank code and account number by the BBAN structure of their country
+package iban
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// bban describes the structure of the BBAN of a country, the positions are zero based within the BBAN
+// and the end is exclusive, the account number includes national check digits so that no information is lost
+type bban struct {
+	length                   int
+	bankStart, bankEnd       int
+	accountStart, accountEnd int
+}
+
+// structures are the lengths of the IBANs and the positions of bank code and account number in the BBAN
+var structures = map[string]bban{
+	"AT": {20, 0, 5, 5, 16},
+	"BE": {16, 0, 3, 3, 12},
+	"CH": {21, 0, 5, 5, 17},
+	"CZ": {24, 0, 4, 4, 20},
+	"DE": {22, 0, 8, 8, 18},
+	"DK": {18, 0, 4, 4, 14},
+	"EE": {20, 0, 2, 2, 16},
+	"ES": {24, 0, 8, 8, 20},
+	"FI": {18, 0, 3, 3, 14},
+	"FR": {27, 0, 10, 10, 23},
+	"GB": {22, 0, 10, 10, 18},
+	"GR": {27, 0, 7, 7, 23},
+	"IE": {22, 0, 10, 10, 18},
+	"IT": {27, 1, 11, 11, 23},
+	"LI": {21, 0, 5, 5, 17},
+	"LT": {20, 0, 5, 5, 16},
+	"LU": {20, 0, 3, 3, 16},
+	"LV": {21, 0, 4, 4, 17},
+	"NL": {18, 0, 4, 4, 14},
+	"NO": {15, 0, 4, 4, 11},
+	"PL": {28, 0, 8, 8, 24},
+	"PT": {25, 0, 8, 8, 21},
+	"SE": {24, 0, 3, 3, 20},
+	"SK": {24, 0, 4, 4, 20},
+}
+
+// minimum and maximum length of IBANs of countries without known structure
+const (
+	minLength = 15
+	maxLength = 34
+)
+
+// Normalize removes the spaces and converts the IBAN to upper case
+func Normalize(iban string) string {
+	return strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+}
+
+// Validate checks the characters, the length of the country and the mod-97 checksum of the IBAN
+func Validate(iban string) error {
+	iban = Normalize(iban)
+	if len(iban) < 4 {
+		return fmt.Errorf("iban %s is too short", iban)
+	}
+	for i, c := range iban {
+		letter, digit := c >= 'A' && c <= 'Z', c >= '0' && c <= '9'
+		if (i < 2 && !letter) || (i >= 2 && i < 4 && !digit) || (!letter && !digit) {
+			return fmt.Errorf("iban %s contains an invalid character at position %d", iban, i+1)
+		}
+	}
+	if s, ok := structures[iban[:2]]; ok {
+		if len(iban) != s.length {
+			return fmt.Errorf("iban %s must have %d characters, got %d", iban, s.length, len(iban))
+		}
+	} else if len(iban) < minLength || len(iban) > maxLength {
+		return fmt.Errorf("iban %s must have %d to %d characters, got %d", iban, minLength, maxLength, len(iban))
+	}
+	if checksum(iban) != 1 {
+		return fmt.Errorf("iban %s has an invalid checksum", iban)
+	}
+	return nil
+}
+
+// checksum returns the remainder of the IBAN as number modulo 97, it is 1 for valid IBANs
+func checksum(iban string) int64 {
+	var digits strings.Builder
+	for _, c := range iban[4:] + iban[:4] {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(fmt.Sprint(c - 'A' + 10))
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64()
+}
+
+// Split validates the IBAN and returns the bank code and the account number of its BBAN,
+// both are empty for countries whose BBAN structure is unknown
+func Split(iban string) (string, string, error) {
+	if err := Validate(iban); err != nil {
+		return "", "", err
+	}
+	iban = Normalize(iban)
+	s, ok := structures[iban[:2]]
+	if !ok {
+		return "", "", nil
+	}
+	b := iban[4:]
+	return b[s.bankStart:s.bankEnd], b[s.accountStart:s.accountEnd], nil
+}
@@ -0,0 +1,75 @@
+package iban
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		iban    string
+		wantErr string
+	}{
+		{iban: "DE89 3704 0044 0532 0130 00"},
+		{iban: "at611904300234573201"},
+		{iban: "ES9121000418450200051332"},
+		{iban: "GB29NWBK60161331926819"},
+		{iban: "MT84MALT011000012345MTLCAST001S"},
+		{iban: "DE88370400440532013000", wantErr: "invalid checksum"},
+		{iban: "DE8937040044053201300", wantErr: "must have 22 characters, got 21"},
+		{iban: "XX12", wantErr: "must have 15 to 34 characters, got 4"},
+		{iban: "D189370400440532013000", wantErr: "invalid character at position 2"},
+		{iban: "DE89-3704-0044-0532-0130-00", wantErr: "invalid character at position 5"},
+		{iban: "DE", wantErr: "too short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.iban, func(t *testing.T) {
+			err := Validate(tt.iban)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		iban          string
+		bankCode      string
+		accountNumber string
+		wantErr       bool
+	}{
+		{"DE89370400440532013000", "37040044", "0532013000", false},
+		{"AT611904300234573201", "19043", "00234573201", false},
+		{"ES9121000418450200051332", "21000418", "450200051332", false},
+		{"IT60X0542811101000000123456", "0542811101", "000000123456", false},
+		{"GB29NWBK60161331926819", "NWBK601613", "31926819", false},
+		{"MT84MALT011000012345MTLCAST001S", "", "", false},
+		{"DE00370400440532013000", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.iban, func(t *testing.T) {
+			bankCode, accountNumber, err := Split(tt.iban)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Split() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if bankCode != tt.bankCode || accountNumber != tt.accountNumber {
+				t.Errorf("Split() = %s, %s, want %s, %s", bankCode, accountNumber, tt.bankCode, tt.accountNumber)
+			}
+		})
+	}
+}
+
+func TestStructures(t *testing.T) {
+	for country, s := range structures {
+		if s.bankStart >= s.bankEnd || s.bankEnd > s.accountStart || s.accountStart >= s.accountEnd || s.accountEnd > s.length-4 {
+			t.Errorf("structure of %s is invalid: %+v", country, s)
+		}
+	}
+}
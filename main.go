@@ -1,32 +1,63 @@
 package main
 
 import (
-	"errors"
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
-	"github.com/JHeimbach/csvtomt940/banks/ing"
-	"github.com/JHeimbach/csvtomt940/banks/n26"
+	"github.com/JHeimbach/csvtomt940/banks"
+	_ "github.com/JHeimbach/csvtomt940/banks/all"
+	"github.com/JHeimbach/csvtomt940/blz"
+	"github.com/JHeimbach/csvtomt940/export"
+	_ "github.com/JHeimbach/csvtomt940/export/all"
 	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/JHeimbach/csvtomt940/state"
 )
 
+// peekSize is the number of bytes that are used to detect the bank of a csv file
+const peekSize = 4096
+
 func usage(programName string) string {
-	return fmt.Sprintf("USAGE:\n\t %s <transactions.csv>", programName)
+	return fmt.Sprintf("USAGE:\n\t %s <transactions.csv>\n\t %s validate <statement.sta>", programName, programName)
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[0], os.Args[2:]))
+	}
+
 	var ingHasCategory = flag.Bool("ing-has-category", true, "[DEPRECATED - use has-category instead] Set to false when ing csv has no category column")
 	var hasCategory = flag.Bool("has-category", true, "Set to false when csv has no category column")
-	var bankType = flag.String("bank-type", "ing", "Which converter should be used (available options: ing, n26")
+	var bankType = flag.String("bank-type", "", fmt.Sprintf("Which converter should be used, detected from the csv if empty (available options: %s)", strings.Join(banks.Names(), ", ")))
 	var n26Iban = flag.String("n26-iban", "", "N26 does not save iban in csv export, you have to provide it yourself")
+	var iban = flag.String("iban", "", "Iban of the account if the csv export does not contain it")
 	var n26StartSaldo = flag.Int64("n26-start-saldo", 0, "N26 does not save saldo infos in csv export, you have to provide the startsaldo yourself, in cents e.g. 10,45€ = 1045")
+	var startSaldo = flag.Int64("start-saldo", 0, "Saldo before the first transaction if the csv export does not contain it, in cents e.g. 10,45€ = 1045")
+	var reference = flag.String("reference", "", "Template for the statement reference in :20: (placeholders: {account}, {bank}, {start}, {end}, {counter}, {hash}), defaults to CSVTOMT940")
+	var split = flag.String("split", "none", "Write one statement per booking day or month (available options: none, day, month)")
+	var useState = flag.Bool("state", false, "Remember statement number and closing balance per iban between runs in the state file")
+	var stateFileName = flag.String("state-file", "", "Path of the state file, enables -state (default ~/.config/csvtomt940/state.json)")
+	var lenient = flag.Bool("lenient", false, "Log inconsistent saldos in the csv as warnings instead of failing")
+	var profile = flag.String("profile", "", "YAML or JSON profile that describes the csv for the generic converter, sets -bank-type to generic")
+	var fixOrder = flag.Bool("fix-order", false, "Sort transactions of the same day so that every saldo is the previous saldo plus the amount")
+	var format = flag.String("format", export.DefaultFormat, fmt.Sprintf("Format of the output file (available options: %s)", strings.Join(export.Names(), ", ")))
+	var camtVersion = flag.String("camt-version", "", "Version of the camt.053 schema for -format camt053 (available options: 001.02, 001.08), defaults to 001.08")
+	var ofxVersion = flag.String("ofx-version", "", "Version of OFX for -format ofx (available options: 102 for SGML, 220 for XML), defaults to 220")
+	var qifDateFormat = flag.String("qif-date-format", "", "Date format for -format qif, a locale (available options: us, uk, de, iso) or a go time layout, defaults to us")
+	var accountFormat = flag.String("account-format", "blz", "Format of the account in :25: (available options: blz, iban, iban-currency)")
+	var blzFile = flag.String("blz-file", "", "Bankleitzahlendatei of the Deutsche Bundesbank in its fixed-width format that replaces the embedded one")
+	var journalRules = flag.String("journal-rules", "", "YAML or JSON file with the accounts for -format ledger, hledger and beancount")
 
 	flag.Parse()
 
+	setFlags := map[string]bool{}
 	flag.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
 		if f.Name == "ing-has-category" {
 			log.Println("[DEPRECATED] flag \"ing-has-category\" is deprecated, use \"has-category\" instead")
 			hasCategory = ingHasCategory
@@ -41,51 +72,153 @@ func main() {
 	csvFileName := flag.Arg(0)
 	csvFile, err := os.Open(csvFileName)
 	if err != nil {
-		log.Printf("Could not open file %s", csvFileName)
+		log.Fatalf("could not open file %s: %v", csvFileName, err)
 	}
 	defer csvFile.Close()
 
-	bank, err := getBank(*bankType, *hasCategory, *n26Iban, *n26StartSaldo)
+	if *blzFile != "" {
+		directory, err := blz.Load(*blzFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		blz.SetDefault(directory)
+	}
+
+	stateFile, err := loadState(*useState, *stateFileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := banks.Options{
+		HasCategory:       *hasCategory,
+		Iban:              *iban,
+		StartSaldo:        *startSaldo,
+		Lenient:           *lenient,
+		FixOrder:          *fixOrder,
+		Profile:           *profile,
+		ReferenceTemplate: *reference,
+	}
+	if *n26Iban != "" {
+		opts.Iban = *n26Iban
+	}
+	if setFlags["n26-start-saldo"] {
+		opts.StartSaldo = *n26StartSaldo
+	}
+	if *profile != "" && *bankType == "" {
+		*bankType = "generic"
+	}
+	// continue with the closing balance of the previous run if no start saldo is given
+	if stateFile != nil && opts.Iban != "" && !setFlags["n26-start-saldo"] && !setFlags["start-saldo"] {
+		// only banks with a single currency use the start saldo, so the account has no currency pockets
+		if account, ok := stateFile.Lookup(opts.Iban, ""); ok {
+			log.Printf("using closing balance of previous run from %s as start saldo", account.LastBookingDate)
+			opts.StartSaldo = account.ClosingBalance
+		}
+	}
+
+	writer, err := export.New(*format, export.Options{
+		CamtVersion:   *camtVersion,
+		OFXVersion:    *ofxVersion,
+		QIFDateFormat: *qifDateFormat,
+		JournalRules:  *journalRules,
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
-	bankInfos := bank.ParseCsv(csvFile)
 
-	// create sta file
-	staFileName := strings.ReplaceAll(csvFileName, ".csv", ".sta")
-	staFile, err := os.Create(staFileName)
+	csvReader := bufio.NewReaderSize(csvFile, peekSize)
+	bank, err := getBank(*bankType, csvReader, opts)
 	if err != nil {
-		log.Fatalf("could not create file: %s: %v ", staFileName, err)
+		log.Fatal(err)
+	}
+	// some exports contain more than one account, every account is written as its own statement
+	accounts, err := mt940.ParseAll(context.Background(), bank, csvReader)
+	if err != nil {
+		log.Fatalf("could not parse %s: %v", csvFileName, err)
 	}
 
-	err = bankInfos.ConvertToMT940(staFile)
+	splitMode, err := mt940.ParseSplitMode(*split)
 	if err != nil {
-		log.Fatalf("could not convert to MT940: %v", err)
+		log.Fatal(err)
 	}
-	// close the sta file
-	err = staFile.Close()
+	accountLine, err := mt940.ParseAccountFormat(*accountFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, bankInfos := range accounts {
+		bankInfos.ReferenceTemplate = *reference
+		bankInfos.Split = splitMode
+		bankInfos.AccountFormat = accountLine
+		if stateFile != nil {
+			for _, warning := range stateFile.Continue(bankInfos) {
+				log.Printf("WARNING: %s", warning)
+			}
+		}
+	}
+
+	// create the output file, the extension of the source is replaced, e.g. transactions.csv becomes transactions.sta
+	outFileName := strings.TrimSuffix(csvFileName, filepath.Ext(csvFileName)) + writer.Extension()
+	if outFileName == csvFileName {
+		log.Fatalf("output file %s would overwrite the source file", outFileName)
+	}
+	outFile, err := os.Create(outFileName)
+	if err != nil {
+		log.Fatalf("could not create file: %s: %v ", outFileName, err)
+	}
+
+	err = writer.Write(outFile, accounts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// close the output file
+	err = outFile.Close()
 	if err != nil {
 		log.Fatalf("could close file: %v", err)
 	}
+
+	if stateFile != nil {
+		for _, bankInfos := range accounts {
+			stateFile.Record(bankInfos)
+		}
+		err = stateFile.Save()
+		if err != nil {
+			log.Fatalf("could not save state: %v", err)
+		}
+	}
+	// german ibans whose bank is not in the bank code file keep the bank code in ?30 instead of the BIC
+	if codes := blz.Misses(); len(codes) > 0 {
+		log.Printf("WARNING: no BIC found for the bank codes %s, ?30 contains the bank code instead; "+
+			"embed the Bankleitzahlendatei with go generate ./blz or pass it with -blz-file", strings.Join(codes, ", "))
+	}
 	log.Println("done")
 }
 
-func getBank(bankType string, hasCategory bool, iban string, saldo int64) (mt940.Bank, error) {
-	switch bankType {
-	case "ing":
-		{
-			return ing.New(hasCategory), nil
+// loadState loads the state file if it is enabled, it returns nil if the state is not used
+func loadState(useState bool, fileName string) (*state.File, error) {
+	if !useState && fileName == "" {
+		return nil, nil
+	}
+	if fileName == "" {
+		var err error
+		fileName, err = state.DefaultPath()
+		if err != nil {
+			return nil, err
 		}
-	case "n26":
-		{
-			if iban == "" {
-				return nil, errors.New("parser for N26 needs iban provided")
-			}
-			if saldo == 0 {
-				log.Println("WARNING: N26 has no Saldo in its transaction statements, do you mean to start with saldo = 0?")
-			}
-			return n26.New(iban, saldo, hasCategory), nil
+	}
+	return state.Load(fileName)
+}
+
+// getBank returns the bank registered as bankType, if bankType is empty the bank is detected from the beginning of the csv
+func getBank(bankType string, r *bufio.Reader, opts banks.Options) (mt940.Bank, error) {
+	if bankType == "" {
+		// Peek returns an error if the file is shorter than peekSize, the detection works on what we got
+		peek, _ := r.Peek(peekSize)
+		name, confidence := banks.Detect(peek)
+		if confidence == 0 {
+			return nil, fmt.Errorf("could not detect bank from csv, please provide it with -bank-type")
 		}
+		log.Printf("detected bank %s (confidence %.2f)", name, confidence)
+		bankType = name
 	}
-	return nil, fmt.Errorf("bank \"%s\" not supported", bankType)
+	return banks.New(bankType, opts)
 }
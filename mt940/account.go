@@ -0,0 +1,74 @@
+package mt940
+
+import "fmt"
+
+// AccountFormat defines how the account is written to :25:
+type AccountFormat int
+
+const (
+	// AccountBankCode writes <bank number>/<account number>, accounts without them are written with their IBAN
+	AccountBankCode AccountFormat = iota
+	// AccountIBAN writes the IBAN
+	AccountIBAN
+	// AccountIBANCurrency writes the IBAN followed by the currency, e.g. DE89370400440532013000EUR
+	AccountIBANCurrency
+)
+
+// maxAccountLength is the maximum length of the account identification in :25:
+const maxAccountLength = 35
+
+// ParseAccountFormat returns the AccountFormat for blz, iban or iban-currency
+func ParseAccountFormat(s string) (AccountFormat, error) {
+	switch s {
+	case "", "blz":
+		return AccountBankCode, nil
+	case "iban":
+		return AccountIBAN, nil
+	case "iban-currency":
+		return AccountIBANCurrency, nil
+	}
+	return AccountBankCode, fmt.Errorf("account format \"%s\" not supported (available options: blz, iban, iban-currency)", s)
+}
+
+// String returns the name of the account format
+func (f AccountFormat) String() string {
+	switch f {
+	case AccountIBAN:
+		return "iban"
+	case AccountIBANCurrency:
+		return "iban-currency"
+	}
+	return "blz"
+}
+
+// accountIdentification returns the content of :25: in the AccountFormat of the statement
+func (s *BankData) accountIdentification() (string, error) {
+	if s.AccountFormat == AccountBankCode && (s.BankNumber != "" || s.AccountNumber != "" || s.IBAN == "") {
+		if s.BankNumber == "" {
+			return "", fmt.Errorf("could not create account line with empty bankNumber")
+		}
+		if s.AccountNumber == "" {
+			return "", fmt.Errorf("could not create account line with empty accountNumber")
+		}
+		return s.BankNumber + "/" + s.AccountNumber, nil
+	}
+
+	if s.IBAN == "" {
+		return "", fmt.Errorf("could not create account line with empty iban")
+	}
+	account := s.IBAN
+	if s.AccountFormat == AccountIBANCurrency {
+		currency := s.Currency
+		if currency == "" && len(s.Transactions) > 0 {
+			currency = s.Transactions[0].Amount().Currency().Code
+		}
+		if currency == "" {
+			return "", fmt.Errorf("could not create account line without currency")
+		}
+		account += currency
+	}
+	if len(account) > maxAccountLength {
+		return "", fmt.Errorf("account identification %s is longer than %d characters", account, maxAccountLength)
+	}
+	return account, nil
+}
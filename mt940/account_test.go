@@ -0,0 +1,83 @@
+package mt940
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+func TestParseAccountFormat(t *testing.T) {
+	for _, name := range []string{"blz", "iban", "iban-currency"} {
+		f, err := ParseAccountFormat(name)
+		if err != nil || f.String() != name {
+			t.Errorf("ParseAccountFormat(%s) = %s, %v", name, f, err)
+		}
+	}
+	if f, err := ParseAccountFormat(""); err != nil || f != AccountBankCode {
+		t.Errorf("ParseAccountFormat() = %s, %v, want blz", f, err)
+	}
+	if _, err := ParseAccountFormat("bic"); err == nil {
+		t.Errorf("ParseAccountFormat(bic) returned no error")
+	}
+}
+
+func TestBankData_accountIdentification(t *testing.T) {
+	transaction := &StatementLine{
+		Sales:   SalesLine{ValueDate: time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), Amount: money.New(100, "EUR")},
+		Balance: money.New(100, "EUR"),
+	}
+	tests := []struct {
+		name    string
+		data    BankData
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "bank code",
+			data: BankData{IBAN: "DE89370400440532013000", BankNumber: "37040044", AccountNumber: "0532013000"},
+			want: "37040044/0532013000",
+		},
+		{
+			name: "iban without bank code",
+			data: BankData{IBAN: "MT84MALT011000012345MTLCAST001S"},
+			want: "MT84MALT011000012345MTLCAST001S",
+		},
+		{
+			name: "iban",
+			data: BankData{IBAN: "DE89370400440532013000", BankNumber: "37040044", AccountNumber: "0532013000", AccountFormat: AccountIBAN},
+			want: "DE89370400440532013000",
+		},
+		{
+			name: "iban with currency of the account",
+			data: BankData{IBAN: "BE11967012345678", Currency: "USD", AccountFormat: AccountIBANCurrency},
+			want: "BE11967012345678USD",
+		},
+		{
+			name: "iban with currency of the transactions",
+			data: BankData{IBAN: "DE89370400440532013000", AccountFormat: AccountIBANCurrency, Transactions: []Transaction{transaction}},
+			want: "DE89370400440532013000EUR",
+		},
+		{
+			name:    "iban with currency is too long",
+			data:    BankData{IBAN: "RU0204452560040702810412345678901", AccountFormat: AccountIBANCurrency, Currency: "EUR"},
+			wantErr: true,
+		},
+		{
+			name:    "no iban",
+			data:    BankData{BankNumber: "37040044", AccountNumber: "0532013000", AccountFormat: AccountIBAN},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.data.accountIdentification()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("accountIdentification() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("accountIdentification() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package mt940
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/formatter"
+	"github.com/Rhymond/go-money"
+)
+
+// Balance is the opening or closing balance of a statement, the content of :60F: and :62F:
+type Balance struct {
+	Date   time.Time
+	Amount *money.Money
+}
+
+// format returns the balance as <DebitOrCredit><YYMMDD><Currency><Amount>
+func (b *Balance) format() string {
+	return fmt.Sprintf("%s%s%s%s",
+		converter.IsCreditOrDebit(b.Amount),
+		b.Date.Format("060102"),
+		b.Amount.Currency().Code,
+		formatter.ConvertMoneyToString(b.Amount.Absolute()),
+	)
+}
+
+// OpeningBalance returns Opening or the saldo before the first transaction at the date of the first transaction
+func (s *BankData) OpeningBalance() (*Balance, error) {
+	if s.Opening != nil {
+		return s.Opening, nil
+	}
+	if len(s.Transactions) == 0 {
+		return nil, fmt.Errorf("no transactions found, could not calculate opening balance")
+	}
+	first := s.Transactions[0]
+	amount, err := first.Saldo().Subtract(first.Amount())
+	if err != nil {
+		return nil, fmt.Errorf("could not calculate opening balance: %w", err)
+	}
+	return &Balance{Date: first.Date(), Amount: amount}, nil
+}
+
+// ClosingBalance returns Closing or the saldo and the date of the last transaction
+func (s *BankData) ClosingBalance() (*Balance, error) {
+	if s.Closing != nil {
+		return s.Closing, nil
+	}
+	if len(s.Transactions) == 0 {
+		return nil, fmt.Errorf("no transactions found, could not calculate closing balance")
+	}
+	last := s.Transactions[len(s.Transactions)-1]
+	return &Balance{Date: last.Date(), Amount: last.Saldo()}, nil
+}
+
+// BalanceBreak is a transaction whose saldo is not the saldo of the previous transaction plus its amount,
+// this happens if rows of the csv are missing, duplicated or in the wrong order
+type BalanceBreak struct {
+	// Index is the position of the transaction in the checked transactions
+	Index    int
+	Date     string
+	Amount   *money.Money
+	Saldo    *money.Money
+	Expected *money.Money
+	// Duplicate is true if the transaction has the same date, amount and saldo as the previous one
+	Duplicate bool
+}
+
+// String returns a description of the balance break
+func (b BalanceBreak) String() string {
+	if b.Duplicate {
+		return fmt.Sprintf("transaction from %s with amount %s and saldo %s is a duplicate of the previous transaction",
+			b.Date, b.Amount.Display(), b.Saldo.Display())
+	}
+	return fmt.Sprintf("saldo %s of transaction from %s is not the previous saldo plus amount %s = %s",
+		b.Saldo.Display(), b.Date, b.Amount.Display(), b.Expected.Display())
+}
+
+// VerifyBalances checks that the saldo of every transaction is the saldo of the previous transaction plus its amount,
+// the transactions have to be in ascending order, it returns nil if the balance chain is consistent
+func VerifyBalances(transactions []Transaction) []BalanceBreak {
+	var breaks []BalanceBreak
+	for i := 1; i < len(transactions); i++ {
+		prev, t := transactions[i-1], transactions[i]
+		expected, err := prev.Saldo().Add(t.Amount())
+		if err != nil {
+			// a currency mismatch can never be consistent
+			expected = prev.Saldo()
+		} else if ok, _ := expected.Equals(t.Saldo()); ok {
+			continue
+		}
+		breaks = append(breaks, BalanceBreak{
+			Index:     i,
+			Date:      t.Date().Format("02.01.2006"),
+			Amount:    t.Amount(),
+			Saldo:     t.Saldo(),
+			Expected:  expected,
+			Duplicate: isDuplicate(prev, t),
+		})
+	}
+	return breaks
+}
+
+// VerifyBalances checks the balance chain of the transactions, see VerifyBalances
+func (s *BankData) VerifyBalances() []BalanceBreak {
+	return VerifyBalances(s.Transactions)
+}
+
+// isDuplicate returns true if both transactions have the same date, amount and saldo
+func isDuplicate(a, b Transaction) bool {
+	if !a.Date().Equal(b.Date()) {
+		return false
+	}
+	sameAmount, err := a.Amount().Equals(b.Amount())
+	if err != nil || !sameAmount {
+		return false
+	}
+	sameSaldo, err := a.Saldo().Equals(b.Saldo())
+	return err == nil && sameSaldo
+}
+
+// ReorderSameDay sorts the transactions of every booking day whose balance chain is broken into an order
+// where every saldo is the previous saldo plus the amount, days without such an order are left untouched.
+// The transactions are reordered in place, the returned slice contains the previous index of every transaction
+// or nil if nothing was changed.
+func ReorderSameDay(transactions []Transaction) []int {
+	order := make([]int, len(transactions))
+	for i := range order {
+		order[i] = i
+	}
+
+	changed := false
+	var saldo *money.Money
+	for start := 0; start < len(transactions); {
+		end := start + 1
+		for end < len(transactions) && SplitDay.key(transactions[start]) == SplitDay.key(transactions[end]) {
+			end++
+		}
+
+		day := transactions[start:end]
+		if fixed := chainDay(day, saldo); fixed != nil {
+			reordered := make([]Transaction, len(day))
+			indexes := make([]int, len(day))
+			for i, j := range fixed {
+				reordered[i] = day[j]
+				indexes[i] = order[start+j]
+			}
+			copy(day, reordered)
+			copy(order[start:end], indexes)
+			changed = true
+		}
+		saldo = transactions[end-1].Saldo()
+		start = end
+	}
+
+	if !changed {
+		return nil
+	}
+	return order
+}
+
+// ReorderSameDay sorts the transactions of every booking day into a consistent balance chain, see ReorderSameDay
+func (s *BankData) ReorderSameDay() []int {
+	return ReorderSameDay(s.Transactions)
+}
+
+// chainDay returns the order of the transactions of a single day that continues the balance chain from saldo,
+// saldo is nil for the first day of the export. It returns nil if the day is already consistent or if there is no such order.
+// Every transaction is an edge from its previous saldo to its saldo, the order is an Eulerian trail through these edges
+// which is found in linear time with Hierholzer's algorithm.
+func chainDay(day []Transaction, saldo *money.Money) []int {
+	if len(day) < 2 || isChained(day, saldo) {
+		return nil
+	}
+
+	from := make([]int64, len(day))
+	edges := make(map[int64][]int)
+	degree := make(map[int64]int)
+	for i, t := range day {
+		previous, err := t.Saldo().Subtract(t.Amount())
+		if err != nil {
+			return nil
+		}
+		from[i] = previous.Amount()
+		edges[from[i]] = append(edges[from[i]], i)
+		degree[from[i]]++
+		degree[t.Saldo().Amount()]--
+	}
+
+	var start int64
+	if saldo != nil {
+		start = saldo.Amount()
+	} else {
+		// without a previous saldo the trail starts where more transactions begin than end
+		start = from[0]
+		for node, d := range degree {
+			if d == 1 {
+				start = node
+				break
+			}
+		}
+	}
+
+	type step struct {
+		node int64
+		edge int
+	}
+	next := make(map[int64]int)
+	stack := []step{{node: start, edge: -1}}
+	order := make([]int, 0, len(day))
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if i := next[top.node]; i < len(edges[top.node]) {
+			next[top.node]++
+			edge := edges[top.node][i]
+			stack = append(stack, step{node: day[edge].Saldo().Amount(), edge: edge})
+			continue
+		}
+		stack = stack[:len(stack)-1]
+		if top.edge >= 0 {
+			order = append(order, top.edge)
+		}
+	}
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+
+	// the trail is incomplete or broken if there is no order that uses every transaction
+	if len(order) != len(day) {
+		return nil
+	}
+	chained := make([]Transaction, len(order))
+	for i, j := range order {
+		chained[i] = day[j]
+	}
+	if !isChained(chained, saldo) {
+		return nil
+	}
+	return order
+}
+
+// isChained returns true if the transactions continue the balance chain from saldo in their current order
+func isChained(transactions []Transaction, saldo *money.Money) bool {
+	for _, t := range transactions {
+		if !continues(saldo, t) {
+			return false
+		}
+		saldo = t.Saldo()
+	}
+	return true
+}
+
+// continues returns true if t follows a transaction with the given saldo, every transaction continues a nil saldo
+func continues(saldo *money.Money, t Transaction) bool {
+	if saldo == nil {
+		return true
+	}
+	expected, err := saldo.Add(t.Amount())
+	if err != nil {
+		return false
+	}
+	ok, err := expected.Equals(t.Saldo())
+	return err == nil && ok
+}
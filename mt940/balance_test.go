@@ -0,0 +1,166 @@
+package mt940
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVerifyBalances(t *testing.T) {
+	tests := []struct {
+		name          string
+		transactions  []Transaction
+		wantIndexes   []int
+		wantDuplicate bool
+	}{
+		{
+			name: "consistent",
+			transactions: []Transaction{
+				testLine(1, time.January, -100, 900),
+				testLine(1, time.January, -100, 800),
+				testLine(2, time.January, 200, 1000),
+			},
+		},
+		{
+			name: "missing row",
+			transactions: []Transaction{
+				testLine(1, time.January, -100, 900),
+				testLine(2, time.January, 200, 1000),
+			},
+			wantIndexes: []int{1},
+		},
+		{
+			name: "duplicated row",
+			transactions: []Transaction{
+				testLine(1, time.January, -100, 900),
+				testLine(1, time.January, -100, 900),
+				testLine(2, time.January, 200, 1100),
+			},
+			wantIndexes:   []int{1},
+			wantDuplicate: true,
+		},
+		{
+			name: "reordered rows",
+			transactions: []Transaction{
+				testLine(1, time.January, -100, 800),
+				testLine(1, time.January, -100, 900),
+				testLine(2, time.January, 200, 1000),
+			},
+			wantIndexes: []int{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := VerifyBalances(tt.transactions)
+			var indexes []int
+			for _, b := range got {
+				indexes = append(indexes, b.Index)
+			}
+			if !reflect.DeepEqual(indexes, tt.wantIndexes) {
+				t.Errorf("VerifyBalances() indexes = %v, want %v", indexes, tt.wantIndexes)
+			}
+			if len(got) > 0 && got[0].Duplicate != tt.wantDuplicate {
+				t.Errorf("VerifyBalances() duplicate = %v, want %v", got[0].Duplicate, tt.wantDuplicate)
+			}
+		})
+	}
+}
+
+func TestBalanceBreak_String(t *testing.T) {
+	breaks := VerifyBalances([]Transaction{
+		testLine(1, time.January, -100, 900),
+		testLine(2, time.January, 200, 1000),
+	})
+	want := "saldo €10.00 of transaction from 02.01.2020 is not the previous saldo plus amount €2.00 = €11.00"
+	if len(breaks) != 1 || breaks[0].String() != want {
+		t.Errorf("String() = %v, want %s", breaks, want)
+	}
+}
+
+func TestReorderSameDay(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions []Transaction
+		wantOrder    []int
+		wantSaldos   []int64
+	}{
+		{
+			name: "consistent order is kept",
+			transactions: []Transaction{
+				testLine(1, time.January, -100, 900),
+				testLine(1, time.January, -100, 800),
+				testLine(2, time.January, 200, 1000),
+			},
+			wantSaldos: []int64{900, 800, 1000},
+		},
+		{
+			name: "first day is reordered",
+			transactions: []Transaction{
+				testLine(1, time.January, 50, 850),
+				testLine(1, time.January, -200, 800),
+				testLine(2, time.January, 200, 1050),
+			},
+			wantOrder:  []int{1, 0, 2},
+			wantSaldos: []int64{800, 850, 1050},
+		},
+		{
+			name: "later day is reordered",
+			transactions: []Transaction{
+				testLine(1, time.January, -100, 900),
+				testLine(2, time.January, 100, 1100),
+				testLine(2, time.January, -100, 800),
+				testLine(2, time.January, 200, 1000),
+			},
+			wantOrder:  []int{0, 2, 3, 1},
+			wantSaldos: []int64{900, 800, 1000, 1100},
+		},
+		{
+			name: "missing row can not be fixed",
+			transactions: []Transaction{
+				testLine(1, time.January, -100, 900),
+				testLine(2, time.January, 300, 1100),
+				testLine(2, time.January, -100, 700),
+			},
+			wantSaldos: []int64{900, 1100, 700},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ReorderSameDay(tt.transactions)
+			if !reflect.DeepEqual(got, tt.wantOrder) {
+				t.Errorf("ReorderSameDay() = %v, want %v", got, tt.wantOrder)
+			}
+			var saldos []int64
+			for _, tr := range tt.transactions {
+				saldos = append(saldos, tr.Saldo().Amount())
+			}
+			if !reflect.DeepEqual(saldos, tt.wantSaldos) {
+				t.Errorf("ReorderSameDay() saldos = %v, want %v", saldos, tt.wantSaldos)
+			}
+		})
+	}
+}
+
+func TestReorderSameDay_ManyTransactions(t *testing.T) {
+	// card payments and refunds that alternate between two saldos, every permutation of them continues the chain
+	day := []Transaction{testLine(1, time.January, 0, 1000)}
+	for i := 0; i < 30; i++ {
+		day = append(day, testLine(2, time.January, 100, 1100), testLine(2, time.January, -100, 1000))
+	}
+	// a row is missing, so there is no order of the day
+	broken := append(append([]Transaction{}, day...), testLine(2, time.January, -50, 500))
+	if got := ReorderSameDay(broken); got != nil {
+		t.Errorf("ReorderSameDay() = %v, want nil", got)
+	}
+
+	// the same transactions in reverse order can be fixed
+	for i, j := 1, len(day)-1; i < j; i, j = i+1, j-1 {
+		day[i], day[j] = day[j], day[i]
+	}
+	if got := ReorderSameDay(day); got == nil {
+		t.Fatalf("ReorderSameDay() did not reorder the day")
+	}
+	if breaks := VerifyBalances(day); breaks != nil {
+		t.Errorf("ReorderSameDay() left balance breaks %v", breaks)
+	}
+}
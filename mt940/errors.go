@@ -0,0 +1,25 @@
+package mt940
+
+import "fmt"
+
+// ParseError is returned by a Bank if a csv entry could not be converted and by Read if a MT940 field is invalid,
+// it contains the line of the entry in the file, the name of the column or field and the raw value that failed
+type ParseError struct {
+	Line   int
+	Column string
+	Value  string
+	Err    error
+}
+
+// Error returns the error message with line, column and value
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: could not parse %s from %q: %v", e.Line, e.Column, e.Value, e.Err)
+	}
+	return fmt.Sprintf("could not parse %s from %q: %v", e.Column, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
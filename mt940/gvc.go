@@ -0,0 +1,122 @@
+package mt940
+
+// BankTransactionCode is the ISO 20022 bank transaction code of camt documents, e.g. PMNT/RCDT/ESCT
+type BankTransactionCode struct {
+	Domain    string
+	Family    string
+	SubFamily string
+}
+
+// gvcTransactionCodes maps the GVC codes to the bank transaction codes, the first entry of a code is used for both directions,
+// note this list is not complete
+var gvcTransactionCodes = []struct {
+	gvc  string
+	code BankTransactionCode
+}{
+	{"166", BankTransactionCode{"PMNT", "RCDT", "ESCT"}},
+	{"152", BankTransactionCode{"PMNT", "RCDT", "STDO"}},
+	{"153", BankTransactionCode{"PMNT", "RCDT", "SALA"}},
+	{"159", BankTransactionCode{"PMNT", "RCDT", "RRTN"}},
+	{"116", BankTransactionCode{"PMNT", "ICDT", "ESCT"}},
+	{"117", BankTransactionCode{"PMNT", "ICDT", "STDO"}},
+	{"118", BankTransactionCode{"PMNT", "ICDT", "SDVA"}},
+	{"105", BankTransactionCode{"PMNT", "RDDT", "ESDD"}},
+	{"104", BankTransactionCode{"PMNT", "RDDT", "BBDD"}},
+	{"109", BankTransactionCode{"PMNT", "RDDT", "UPDD"}},
+	{"171", BankTransactionCode{"PMNT", "IDDT", "ESDD"}},
+	{"174", BankTransactionCode{"PMNT", "IDDT", "BBDD"}},
+	{"106", BankTransactionCode{"PMNT", "CCRD", "POSD"}},
+	{"083", BankTransactionCode{"PMNT", "CCRD", "CWDL"}},
+	{"082", BankTransactionCode{"PMNT", "CNTR", "CDPT"}},
+	{"805", BankTransactionCode{"ACMT", "MDOP", "INTR"}},
+	{"808", BankTransactionCode{"ACMT", "MDOP", "CHRG"}},
+	// the gvc codes of the csv converters
+	{"051", BankTransactionCode{"PMNT", "RCDT", "DMCT"}},
+	{"052", BankTransactionCode{"PMNT", "RCDT", "STDO"}},
+	{"053", BankTransactionCode{"PMNT", "RCDT", "SALA"}},
+	{"020", BankTransactionCode{"PMNT", "ICDT", "DMCT"}},
+	{"008", BankTransactionCode{"PMNT", "ICDT", "STDO"}},
+	{"005", BankTransactionCode{"PMNT", "RDDT", "ESDD"}},
+	{"004", BankTransactionCode{"PMNT", "CCRD", "POSD"}},
+}
+
+// familyGVC is used for bank transaction codes whose sub family is unknown
+var familyGVC = map[string]string{
+	"RCDT": "166",
+	"ICDT": "116",
+	"RDDT": "105",
+	"IDDT": "171",
+	"CCRD": "106",
+	"MDOP": "808",
+}
+
+// unknownGVC is the gvc code of transactions without a known transaction code
+const unknownGVC = "999"
+
+// GVCForTransactionCode returns the GVC code of the bank transaction code, unknown codes return 999
+func GVCForTransactionCode(c BankTransactionCode) string {
+	for _, m := range gvcTransactionCodes {
+		if m.code == c {
+			return m.gvc
+		}
+	}
+	if gvc, ok := familyGVC[c.Family]; ok {
+		return gvc
+	}
+	return unknownGVC
+}
+
+// TransactionCodeForGVC returns the bank transaction code of the GVC code, the second value is false for unknown codes
+func TransactionCodeForGVC(gvc string) (BankTransactionCode, bool) {
+	for _, m := range gvcTransactionCodes {
+		if m.gvc == gvc {
+			return m.code, true
+		}
+	}
+	return BankTransactionCode{}, false
+}
+
+// gvcTypeCodes maps the GVC codes to the transaction type identification codes of :61:, other codes are written as NTRF
+var gvcTypeCodes = map[string]string{
+	"001": "NCHK",
+	"002": "NCHK",
+	"003": "NCHK",
+	"004": "NMSC",
+	"005": "NDDT",
+	"008": "NSTO",
+	"052": "NSTO",
+	"053": "NSAL",
+	"082": "NMSC",
+	"083": "NMSC",
+	"104": "NDDT",
+	"105": "NDDT",
+	"106": "NMSC",
+	"109": "NDDT",
+	"117": "NSTO",
+	"152": "NSTO",
+	"153": "NSAL",
+	"171": "NDDT",
+	"174": "NDDT",
+	"805": "NINT",
+	"808": "NCHG",
+}
+
+// reversalGVCs are the GVC codes of returned debits and credits, they are written with the RC or RD mark
+var reversalGVCs = map[string]bool{
+	"059": true,
+	"109": true,
+	"159": true,
+}
+
+// TypeCodeForGVC returns the transaction type identification code of :61: for the GVC code, e.g. NDDT for direct debits
+func TypeCodeForGVC(gvc string) string {
+	if code, ok := gvcTypeCodes[gvc]; ok {
+		return code
+	}
+	return "NTRF"
+}
+
+// IsReversalGVC returns true if the GVC code belongs to a returned debit or credit
+func IsReversalGVC(gvc string) bool {
+	return reversalGVCs[gvc]
+}
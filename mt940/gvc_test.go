@@ -0,0 +1,72 @@
+package mt940
+
+import "testing"
+
+func TestGVCForTransactionCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code BankTransactionCode
+		want string
+	}{
+		{name: "sepa credit", code: BankTransactionCode{"PMNT", "RCDT", "ESCT"}, want: "166"},
+		{name: "sepa direct debit", code: BankTransactionCode{"PMNT", "RDDT", "ESDD"}, want: "105"},
+		{name: "salary prefers sepa code", code: BankTransactionCode{"PMNT", "RCDT", "SALA"}, want: "153"},
+		{name: "unknown sub family", code: BankTransactionCode{"PMNT", "ICDT", "XBCT"}, want: "116"},
+		{name: "unknown", code: BankTransactionCode{"XTND", "NTAV", "NTAV"}, want: "999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GVCForTransactionCode(tt.code); got != tt.want {
+				t.Errorf("GVCForTransactionCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionCodeForGVC(t *testing.T) {
+	tests := []struct {
+		gvc    string
+		want   BankTransactionCode
+		wantOk bool
+	}{
+		{gvc: "116", want: BankTransactionCode{"PMNT", "ICDT", "ESCT"}, wantOk: true},
+		{gvc: "020", want: BankTransactionCode{"PMNT", "ICDT", "DMCT"}, wantOk: true},
+		{gvc: "808", want: BankTransactionCode{"ACMT", "MDOP", "CHRG"}, wantOk: true},
+		{gvc: "999", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.gvc, func(t *testing.T) {
+			got, ok := TransactionCodeForGVC(tt.gvc)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("TransactionCodeForGVC() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestTypeCodeForGVC(t *testing.T) {
+	tests := []struct {
+		gvc          string
+		want         string
+		wantReversal bool
+	}{
+		{gvc: "020", want: "NTRF"},
+		{gvc: "005", want: "NDDT"},
+		{gvc: "002", want: "NCHK"},
+		{gvc: "808", want: "NCHG"},
+		{gvc: "004", want: "NMSC"},
+		{gvc: "109", want: "NDDT", wantReversal: true},
+		{gvc: "059", want: "NTRF", wantReversal: true},
+		{gvc: "999", want: "NTRF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.gvc, func(t *testing.T) {
+			if got := TypeCodeForGVC(tt.gvc); got != tt.want {
+				t.Errorf("TypeCodeForGVC() = %v, want %v", got, tt.want)
+			}
+			if got := IsReversalGVC(tt.gvc); got != tt.wantReversal {
+				t.Errorf("IsReversalGVC() = %v, want %v", got, tt.wantReversal)
+			}
+		})
+	}
+}
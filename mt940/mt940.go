@@ -1,12 +1,9 @@
 package mt940
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"os"
-
-	"github.com/JHeimbach/csvtomt940/converter"
-	"github.com/JHeimbach/csvtomt940/formatter"
 )
 
 // Converter converts csv transactions into the MT940 format
@@ -14,21 +11,84 @@ type Converter interface {
 	ConvertToMT940(writer io.Writer) error
 }
 
+// Bank parses the csv export of a bank into BankData
+// it returns a *ParseError if an entry of the csv could not be converted
 type Bank interface {
-	ParseCsv(csvFile *os.File) *BankData
+	Parse(ctx context.Context, r io.Reader) (*BankData, error)
+}
+
+// MultiBank is implemented by banks whose csv export contains more than one account,
+// ParseAll returns a BankData for every account while Parse only returns the first one
+type MultiBank interface {
+	Bank
+	ParseAll(ctx context.Context, r io.Reader) ([]*BankData, error)
 }
 
+// ParseAll parses all accounts of the csv export if the bank is a MultiBank and only the single account otherwise
+func ParseAll(ctx context.Context, b Bank, r io.Reader) ([]*BankData, error) {
+	if mb, ok := b.(MultiBank); ok {
+		return mb.ParseAll(ctx, r)
+	}
+	data, err := b.Parse(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return []*BankData{data}, nil
+}
+
+// defaultReference is written to the headerline (:20:) if no reference is set
+const defaultReference = "CSVTOMT940"
+
 // swiftTransactions creates a MT940 statement from given transactions
-// accountNumber and bankNumber are required for the accountLine (:25:)
+// accountNumber and bankNumber are required for the accountLine (:25:), IBAN is optional and identifies the account
+// Reference, StatementNumber and SequenceNumber are optional and written to :20: and :28C:
+// ReferenceTemplate is used to generate the reference if no Reference is set, see expandReference for the placeholders
+// Split defines if the transactions are written into multiple statements, see Statements
+// Currency is optional and distinguishes the currency pockets of an account that holds several currencies under one IBAN
+// AccountFormat defines if :25: contains the bank and account number or the IBAN
+// Opening and Closing are optional, they are written to :60F: and :62F: instead of the balances calculated from the transactions
+// so that statements without transactions can be written
 type BankData struct {
-	AccountNumber string
-	BankNumber    string
-	Transactions  []Transaction
+	IBAN              string
+	Currency          string
+	AccountNumber     string
+	BankNumber        string
+	Reference         string
+	ReferenceTemplate string
+	StatementNumber   int
+	SequenceNumber    int
+	Split             SplitMode
+	AccountFormat     AccountFormat
+	Opening           *Balance
+	Closing           *Balance
+	Transactions      []Transaction
 }
 
-// createHeaderLine writes a headerline to the writer, it is static and returns always :20:CSVTOMT940
+// StatementReference returns the Reference of the statement, if no Reference is set it is generated from ReferenceTemplate
+// and defaults to CSVTOMT940
+func (s *BankData) StatementReference() (string, error) {
+	reference := s.Reference
+	if reference == "" && s.ReferenceTemplate != "" {
+		var err error
+		reference, err = s.expandReference(s.ReferenceTemplate)
+		if err != nil {
+			return "", err
+		}
+	}
+	if reference == "" {
+		reference = defaultReference
+	}
+	return reference, nil
+}
+
+// createHeaderLine writes the headerline :20: with the reference to the writer,
+// if no Reference is set it is generated from ReferenceTemplate and defaults to :20:CSVTOMT940
 func (s *BankData) createHeaderLine(writer io.Writer) error {
-	_, err := writer.Write([]byte(":20:CSVTOMT940\r\n"))
+	reference, err := s.StatementReference()
+	if err != nil {
+		return fmt.Errorf("could not create headerline: %w", err)
+	}
+	_, err = writer.Write([]byte(fmt.Sprintf(":20:%s\r\n", reference)))
 
 	if err != nil {
 		return fmt.Errorf("could not create headerline: %w", err)
@@ -36,35 +96,28 @@ func (s *BankData) createHeaderLine(writer io.Writer) error {
 	return nil
 }
 
-// createAccountLine creates account line :25: with BankNumber and AccountNumber
+// createAccountLine creates account line :25: with BankNumber and AccountNumber or the IBAN, see AccountFormat
 func (s *BankData) createAccountLine(writer io.Writer) error {
-	if s.BankNumber == "" {
-		return fmt.Errorf("could not create account line with empty bankNumber")
-	}
-	if s.AccountNumber == "" {
-		return fmt.Errorf("could not create account line with empty accountNumber")
+	account, err := s.accountIdentification()
+	if err != nil {
+		return err
 	}
 
-	// :25:<BankNumber><AccountNumber>
-	_, err := writer.Write(
-		[]byte(
-			fmt.Sprintf(
-				":25:%s/%s\r\n",
-				s.BankNumber,
-				s.AccountNumber,
-			),
-		),
-	)
-
+	// :25:<BankNumber>/<AccountNumber> or :25:<IBAN>[<Currency>]
+	_, err = writer.Write([]byte(fmt.Sprintf(":25:%s\r\n", account)))
 	if err != nil {
 		return fmt.Errorf("could not create account line: %w", err)
 	}
 	return nil
 }
 
-// createStatementLine writes the statementline :28:0 to the writer, it is static and does not change
+// createStatementLine writes the statementline :28C: with statement number and optional sequence number to the writer
 func (s *BankData) createStatementLine(writer io.Writer) error {
-	_, err := writer.Write([]byte(":28C:0\r\n"))
+	statement := fmt.Sprintf("%d", s.StatementNumber)
+	if s.SequenceNumber > 0 {
+		statement = fmt.Sprintf("%s/%d", statement, s.SequenceNumber)
+	}
+	_, err := writer.Write([]byte(fmt.Sprintf(":28C:%s\r\n", statement)))
 
 	if err != nil {
 		return fmt.Errorf("could not create statement line: %w", err)
@@ -72,68 +125,49 @@ func (s *BankData) createStatementLine(writer io.Writer) error {
 	return nil
 }
 
-// createStartSaldoLine creates the start saldo line :60F: with help of the first transaction
+// createStartSaldoLine creates the start saldo line :60F: from the opening balance
 func (s *BankData) createStartSaldoLine(writer io.Writer) error {
-	if len(s.Transactions) <= 0 {
-		return fmt.Errorf("no transactions found, could not create start saldo line")
-	}
-	// get the first ingTransaction to calculate start saldo
-	fTransaction := s.Transactions[0]
-
-	// subtract the amount from saldo to get the startSaldo
-	startSaldo, err := fTransaction.Saldo().Subtract(fTransaction.Amount())
+	opening, err := s.OpeningBalance()
 	if err != nil {
-		return fmt.Errorf("could not calculate beginsaldo: %w", err)
+		return fmt.Errorf("could not create start saldo line: %w", err)
 	}
 
 	// :60F:<DebitOrCredit><Date><Currency><Amount>
-	_, err = writer.Write(
-		[]byte(
-			fmt.Sprintf(
-				":60F:%s%s%s%s\r\n",
-				converter.IsCreditOrDebit(startSaldo),
-				fTransaction.Date().Format("060102"),
-				startSaldo.Currency().Code,
-				formatter.ConvertMoneyToString(startSaldo.Absolute()),
-			),
-		),
-	)
+	_, err = writer.Write([]byte(":60F:" + opening.format() + "\r\n"))
 	if err != nil {
 		return fmt.Errorf("could not create begin startSaldo line: %w", err)
 	}
 	return nil
 }
 
-// createEndSaldoLine creates end saldo line :62F: with help of the last transaction
+// createEndSaldoLine creates end saldo line :62F: from the closing balance
 func (s *BankData) createEndSaldoLine(writer io.Writer) error {
-	if len(s.Transactions) <= 0 {
-		return fmt.Errorf("no transactions found, could not create end saldo line")
+	closing, err := s.ClosingBalance()
+	if err != nil {
+		return fmt.Errorf("could not create end saldo line: %w", err)
 	}
-	lTransaction := s.Transactions[len(s.Transactions)-1]
-
-	endSaldo := lTransaction.Saldo()
 
 	// :62F:<DebitOrCredit><Date><Currency><Amount>
-	_, err := writer.Write(
-		[]byte(
-			fmt.Sprintf(
-				":62F:%s%s%s%s",
-				converter.IsCreditOrDebit(endSaldo),
-				lTransaction.Date().Format("060102"),
-				endSaldo.Currency().Code,
-				formatter.ConvertMoneyToString(endSaldo.Absolute()),
-			),
-		),
-	)
-
+	_, err = writer.Write([]byte(":62F:" + closing.format()))
 	if err != nil {
 		return fmt.Errorf("could not create end saldo line: %w", err)
 	}
 	return nil
 }
 
-// ConvertToMT940 calls all line creation functions and writes a complete MT940 statement to the given writer
+// ConvertToMT940 writes all statements returned by Statements to the given writer
 func (s *BankData) ConvertToMT940(w io.Writer) error {
+	for _, statement := range s.Statements() {
+		err := statement.writeStatement(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeStatement calls all line creation functions and writes a complete MT940 statement to the given writer
+func (s *BankData) writeStatement(w io.Writer) error {
 	err := s.createHeaderLine(w)
 	if err != nil {
 		return err
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/JHeimbach/csvtomt940/converter"
 	"github.com/Rhymond/go-money"
 )
 
@@ -32,6 +33,14 @@ func (m *mockTransaction) Date() time.Time {
 	return m.date()
 }
 
+func (m *mockTransaction) Counterparty() Counterparty {
+	return Counterparty{}
+}
+
+func (m *mockTransaction) References() converter.SEPAReferences {
+	return converter.SEPAReferences{}
+}
+
 func Test_SwiftTransactions_ConvertToMT940(t *testing.T) {
 	type fields struct {
 		accountNumber string
@@ -0,0 +1,363 @@
+package mt940
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/formatter"
+	"github.com/Rhymond/go-money"
+)
+
+var (
+	// fieldPattern matches the beginning of a field, e.g. :20: or :60F:
+	fieldPattern = regexp.MustCompile(`^:(\d{2}[A-Z]?):`)
+	// balancePattern matches the content of :60F:, :60M:, :62F: and :62M:
+	balancePattern = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})([0-9,]+)$`)
+	// salesLinePattern matches the first line of :61:
+	salesLinePattern = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z]?)([0-9,]+)([NFS][A-Z0-9]{3})(.*)$`)
+	// subfieldPattern matches the control characters of :86: subfields, e.g. ?20
+	subfieldPattern = regexp.MustCompile(`\?(\d{2})`)
+)
+
+// field is a tagged field of a MT940 statement with all of its lines
+type field struct {
+	tag   string
+	line  int
+	lines []string
+}
+
+// value returns the lines of the field joined without separator
+func (f *field) value() string {
+	return strings.Join(f.lines, "")
+}
+
+// Read parses MT940 statements from r, every statement is returned as BankData with a StatementLine for each :61: field
+// The balance of each StatementLine is calculated from the opening balance in :60F: or :60M:, it has to match
+// the closing balance in :62F: or :62M:
+func Read(r io.Reader) ([]*BankData, error) {
+	statements, err := readStatements(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*BankData, 0, len(statements))
+	for _, fields := range statements {
+		data, err := parseStatement(fields)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+// readStatements splits the content of r into statements and fields,
+// statements are separated by a line containing only "-" or by a new :20: field
+func readStatements(r io.Reader) ([][]*field, error) {
+	var statements [][]*field
+	var current []*field
+
+	endStatement := func() {
+		if len(current) > 0 {
+			statements = append(statements, current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			continue
+		case line == "-" || line == "-}":
+			endStatement()
+			continue
+		case strings.HasPrefix(line, "{"):
+			// swift block headers are not part of the statement
+			continue
+		}
+
+		match := fieldPattern.FindStringSubmatch(line)
+		if match == nil {
+			if len(current) == 0 {
+				return nil, fmt.Errorf("line %d: content %q does not belong to a field", lineNumber, line)
+			}
+			last := current[len(current)-1]
+			last.lines = append(last.lines, line)
+			continue
+		}
+		if match[1] == "20" {
+			endStatement()
+		}
+		current = append(current, &field{
+			tag:   match[1],
+			line:  lineNumber,
+			lines: []string{line[len(match[0]):]},
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read statement: %w", err)
+	}
+	endStatement()
+
+	return statements, nil
+}
+
+// parseStatement converts the fields of a single statement into BankData
+func parseStatement(fields []*field) (*BankData, error) {
+	data := &BankData{}
+	var saldo *money.Money
+	var current *StatementLine
+
+	for _, f := range fields {
+		switch f.tag {
+		case "20":
+			data.Reference = f.value()
+		case "25":
+			data.BankNumber, data.AccountNumber = parseAccount(f.value())
+		case "28C":
+			statement, sequence, err := parseStatementNumber(f.value())
+			if err != nil {
+				return nil, newFieldError(f, err)
+			}
+			data.StatementNumber, data.SequenceNumber = statement, sequence
+		case "60F", "60M":
+			b, err := parseBalance(f.value())
+			if err != nil {
+				return nil, newFieldError(f, err)
+			}
+			data.Opening = b
+			saldo = b.Amount
+		case "61":
+			if saldo == nil {
+				return nil, newFieldError(f, fmt.Errorf("statement line without opening balance"))
+			}
+			sales, err := parseSalesLine(f.lines, saldo.Currency().Code)
+			if err != nil {
+				return nil, newFieldError(f, err)
+			}
+			saldo, err = saldo.Add(sales.Amount)
+			if err != nil {
+				return nil, newFieldError(f, err)
+			}
+			current = &StatementLine{Sales: *sales, Balance: saldo}
+			data.Transactions = append(data.Transactions, current)
+		case "86":
+			if current == nil {
+				return nil, newFieldError(f, fmt.Errorf("multipurpose line without statement line"))
+			}
+			current.Details = parseDetails(f.value())
+		case "62F", "62M":
+			b, err := parseBalance(f.value())
+			if err != nil {
+				return nil, newFieldError(f, err)
+			}
+			if saldo == nil {
+				return nil, newFieldError(f, fmt.Errorf("closing balance without opening balance"))
+			}
+			if ok, err := saldo.Equals(b.Amount); err != nil || !ok {
+				return nil, newFieldError(f, fmt.Errorf("closing balance %s does not match opening balance plus statement lines %s",
+					b.Amount.Display(), saldo.Display()))
+			}
+			data.Closing = b
+		}
+	}
+	return data, nil
+}
+
+// newFieldError wraps err into a ParseError for the field
+func newFieldError(f *field, err error) *ParseError {
+	return &ParseError{
+		Line:   f.line,
+		Column: ":" + f.tag + ":",
+		Value:  strings.Join(f.lines, "\n"),
+		Err:    err,
+	}
+}
+
+// parseAccount splits the content of :25: into bank number and account number,
+// if it contains no slash (e.g. an iban) the whole value is returned as account number
+func parseAccount(s string) (string, string) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) < 2 {
+		return "", s
+	}
+	return parts[0], parts[1]
+}
+
+// parseStatementNumber parses the content of :28C: which is <statement>[/<sequence>]
+func parseStatementNumber(s string) (int, int, error) {
+	parts := strings.SplitN(s, "/", 2)
+	statement, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid statement number: %w", err)
+	}
+	if len(parts) < 2 {
+		return statement, 0, nil
+	}
+	sequence, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid sequence number: %w", err)
+	}
+	return statement, sequence, nil
+}
+
+// parseBalance parses the content of :60F:, :60M:, :62F: and :62M:
+func parseBalance(s string) (*Balance, error) {
+	match := balancePattern.FindStringSubmatch(s)
+	if match == nil {
+		return nil, fmt.Errorf("balance does not match <C/D><YYMMDD><Currency><Amount>")
+	}
+	date, err := time.Parse("060102", match[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid balance date: %w", err)
+	}
+	amount, err := formatter.ConvertStringToMoney(match[4], match[3])
+	if err != nil {
+		return nil, err
+	}
+	if match[1] == "D" {
+		amount = amount.Negative()
+	}
+	return &Balance{Date: date, Amount: amount}, nil
+}
+
+// parseSalesLine parses the lines of :61:, the optional second line contains the supplementary details
+func parseSalesLine(lines []string, currency string) (*SalesLine, error) {
+	match := salesLinePattern.FindStringSubmatch(lines[0])
+	if match == nil {
+		return nil, fmt.Errorf("statement line does not match <YYMMDD>[MMDD]<Mark><Amount><TypeCode><Reference>")
+	}
+	valueDate, err := time.Parse("060102", match[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value date: %w", err)
+	}
+	var entryDate time.Time
+	if match[2] != "" {
+		entryDate, err = parseEntryDate(match[2], valueDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	amount, err := formatter.ConvertStringToMoney(match[5], currency)
+	if err != nil {
+		return nil, err
+	}
+	mark := match[3]
+	if mark == "D" || mark == "RC" {
+		amount = amount.Negative()
+	}
+
+	customerReference := match[7]
+	bankReference := ""
+	if i := strings.Index(customerReference, "//"); i >= 0 {
+		customerReference, bankReference = customerReference[:i], customerReference[i+2:]
+	}
+
+	sales := &SalesLine{
+		ValueDate:         valueDate,
+		EntryDate:         entryDate,
+		Reversal:          strings.HasPrefix(mark, "R"),
+		FundsCode:         match[4],
+		Amount:            amount,
+		TypeCode:          match[6],
+		CustomerReference: customerReference,
+		BankReference:     bankReference,
+	}
+	if len(lines) > 1 {
+		sales.SupplementaryDetails = strings.Join(lines[1:], "")
+	}
+	return sales, nil
+}
+
+// parseEntryDate parses the entry date MMDD, the year is taken from the value date,
+// an entry date in december for a value date in january belongs to the previous year and the other way around
+func parseEntryDate(s string, valueDate time.Time) (time.Time, error) {
+	entryDate, err := time.Parse("0102", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid entry date: %w", err)
+	}
+	year := valueDate.Year()
+	switch {
+	case entryDate.Month() == time.December && valueDate.Month() == time.January:
+		year--
+	case entryDate.Month() == time.January && valueDate.Month() == time.December:
+		year++
+	}
+	return time.Date(year, entryDate.Month(), entryDate.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// parseDetails splits the content of :86: into its subfields,
+// content without the structure <GVC>?<subfields> is returned as unstructured
+func parseDetails(s string) Details {
+	if len(s) < 3 || !isDigits(s[:3]) || (len(s) > 3 && s[3] != '?') {
+		return Details{Unstructured: s}
+	}
+
+	d := Details{GVC: s[:3]}
+	for _, sf := range splitSubfields(s) {
+		switch {
+		case sf.control == 0:
+			d.BookingText = sf.value
+		case sf.control == 10:
+			d.PrimaNota = sf.value
+		case isPurposeControl(sf.control):
+			d.Purpose = append(d.Purpose, sf.value)
+		case sf.control == 30:
+			d.BankCode = sf.value
+		case sf.control == 31:
+			d.AccountNumber = sf.value
+		case sf.control == 32 || sf.control == 33:
+			d.Name = append(d.Name, sf.value)
+		case sf.control == 34:
+			d.TextKeyAddition = sf.value
+		}
+	}
+	return d
+}
+
+// subfield is a single subfield of :86:, offset is the position of the control character in the content
+type subfield struct {
+	control int
+	offset  int
+	value   string
+}
+
+// splitSubfields returns all subfields of the content of :86: in the order of their appearance
+func splitSubfields(s string) []subfield {
+	positions := subfieldPattern.FindAllStringSubmatchIndex(s, -1)
+	result := make([]subfield, 0, len(positions))
+	for i, pos := range positions {
+		end := len(s)
+		if i+1 < len(positions) {
+			end = positions[i+1][0]
+		}
+		control, _ := strconv.Atoi(s[pos[2]:pos[3]])
+		result = append(result, subfield{control: control, offset: pos[0], value: s[pos[1]:end]})
+	}
+	return result
+}
+
+// isPurposeControl returns true for the control numbers of the purpose subfields ?20 to ?29 and ?60 to ?63
+func isPurposeControl(control int) bool {
+	return (control >= 20 && control <= 29) || (control >= 60 && control <= 63)
+}
+
+// isDigits returns true if s only contains the characters 0-9
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,229 @@
+package mt940
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+const testStatement = ":20:CSVTOMT940\r\n" +
+	":25:50010517/1234567895\r\n" +
+	":28C:0\r\n" +
+	":60F:C200106EUR1173,74\r\n" +
+	":61:2001060106C16,20NTRFNONREF\r\n" +
+	":86:051?00Gutschrift?20SVWZ+Grass-roots systemic p?21ricing structure\r\n" +
+	"?22KREF+NONREF?32Yabox\r\n" +
+	":61:2001090109D1,62NTRFNONREF\r\n" +
+	":86:005?00Lastschrift?20SVWZ+Reactive full-range lo?21cal area networ\r\n" +
+	"k?22KREF+NONREF?32Yabox\r\n" +
+	":62F:C200109EUR1188,32\r\n"
+
+func TestRead(t *testing.T) {
+	data, err := Read(strings.NewReader(testStatement))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(data) != 1 {
+		t.Fatalf("Read() got %d statements, want 1", len(data))
+	}
+	s := data[0]
+	if s.Reference != "CSVTOMT940" || s.BankNumber != "50010517" || s.AccountNumber != "1234567895" {
+		t.Errorf("Read() got reference %s, bank %s, account %s", s.Reference, s.BankNumber, s.AccountNumber)
+	}
+	if len(s.Transactions) != 2 {
+		t.Fatalf("Read() got %d transactions, want 2", len(s.Transactions))
+	}
+
+	first := s.Transactions[0].(*StatementLine)
+	wantDetails := Details{
+		GVC:         "051",
+		BookingText: "Gutschrift",
+		Purpose:     []string{"SVWZ+Grass-roots systemic p", "ricing structure", "KREF+NONREF"},
+		Name:        []string{"Yabox"},
+	}
+	if !reflect.DeepEqual(first.Details, wantDetails) {
+		t.Errorf("Read() details = %#v, want %#v", first.Details, wantDetails)
+	}
+	if !first.Date().Equal(time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Read() date = %s", first.Date())
+	}
+	if first.Amount().Amount() != 1620 || first.Saldo().Amount() != 118994 {
+		t.Errorf("Read() amount = %d, saldo = %d", first.Amount().Amount(), first.Saldo().Amount())
+	}
+	last := s.Transactions[1]
+	if last.Amount().Amount() != -162 || last.Saldo().Amount() != 118832 {
+		t.Errorf("Read() amount = %d, saldo = %d", last.Amount().Amount(), last.Saldo().Amount())
+	}
+}
+
+func TestRead_RoundTrip(t *testing.T) {
+	data, err := Read(strings.NewReader(testStatement))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	w := &bytes.Buffer{}
+	if err := data[0].ConvertToMT940(w); err != nil {
+		t.Fatalf("ConvertToMT940() error = %v", err)
+	}
+	if got := w.String(); got != testStatement {
+		t.Errorf("ConvertToMT940() got = %#v, want %#v", got, testStatement)
+	}
+}
+
+func TestRead_WithoutStatementLines(t *testing.T) {
+	statement := ":20:CSVTOMT940\r\n" +
+		":25:50010517/1234567895\r\n" +
+		":28C:7\r\n" +
+		":60F:C200108EUR1188,32\r\n" +
+		":62F:C200108EUR1188,32\r\n"
+	data, err := Read(strings.NewReader(statement))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(data[0].Transactions) != 0 || data[0].Opening == nil || data[0].Closing == nil {
+		t.Fatalf("Read() = %+v, want opening and closing balance without transactions", data[0])
+	}
+	w := &bytes.Buffer{}
+	if err := data[0].ConvertToMT940(w); err != nil {
+		t.Fatalf("ConvertToMT940() error = %v", err)
+	}
+	if got := w.String(); got != statement {
+		t.Errorf("ConvertToMT940() got = %#v, want %#v", got, statement)
+	}
+}
+
+func TestRead_Variants(t *testing.T) {
+	lf := strings.ReplaceAll(testStatement, "\r\n", "\n")
+	tests := []struct {
+		name           string
+		input          string
+		wantStatements int
+		wantErr        bool
+	}{
+		{name: "lf line endings", input: lf, wantStatements: 1},
+		{name: "dash separated statements", input: lf + "-\n" + lf + "-\n", wantStatements: 2},
+		{name: "statements without separator", input: testStatement + testStatement, wantStatements: 2},
+		{name: "swift block header", input: "{1:F01TESTDEFFAXXX0000000000}{2:I940TESTDEFFXXXXN}{4:\n" + lf + "-}", wantStatements: 1},
+		{name: "content before first field", input: "abc\n" + lf, wantErr: true},
+		{name: "invalid balance", input: strings.Replace(lf, ":60F:C200106", ":60F:X200106", 1), wantErr: true},
+		{name: "invalid statement line", input: strings.Replace(lf, ":61:2001060106C16,20", ":61:2001060106C16.20", 1), wantErr: true},
+		{name: "closing balance does not match", input: strings.Replace(lf, ":62F:C200109EUR1188,32", ":62F:C200109EUR1186,70", 1), wantErr: true},
+		{name: "closing balance without opening balance", input: ":20:CSVTOMT940\n:62F:C200109EUR1188,32\n", wantErr: true},
+		{name: "intermediate closing balance", input: strings.Replace(lf, ":62F:", ":62M:", 1), wantStatements: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.wantStatements {
+				t.Errorf("Read() got %d statements, want %d", len(got), tt.wantStatements)
+			}
+		})
+	}
+}
+
+func Test_parseSalesLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		want    *SalesLine
+		wantErr bool
+	}{
+		{
+			name:  "minimal",
+			lines: []string{"200106C16,20NTRFNONREF"},
+			want: &SalesLine{
+				ValueDate:         time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC),
+				Amount:            money.New(1620, "EUR"),
+				TypeCode:          "NTRF",
+				CustomerReference: "NONREF",
+			},
+		},
+		{
+			name:  "all fields",
+			lines: []string{"1912311231RCR16,NDDTMREF123//BANKREF", "Supplementary"},
+			want: &SalesLine{
+				ValueDate:            time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC),
+				EntryDate:            time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC),
+				Reversal:             true,
+				FundsCode:            "R",
+				Amount:               money.New(-1600, "EUR"),
+				TypeCode:             "NDDT",
+				CustomerReference:    "MREF123",
+				BankReference:        "BANKREF",
+				SupplementaryDetails: "Supplementary",
+			},
+		},
+		{
+			name:  "entry date in previous year",
+			lines: []string{"2001021231D1,5NTRFNONREF"},
+			want: &SalesLine{
+				ValueDate:         time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+				EntryDate:         time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC),
+				Amount:            money.New(-150, "EUR"),
+				TypeCode:          "NTRF",
+				CustomerReference: "NONREF",
+			},
+		},
+		{
+			name:    "invalid amount",
+			lines:   []string{"200106C16,205NTRFNONREF"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSalesLine(tt.lines, "EUR")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSalesLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSalesLine() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseDetails(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Details
+	}{
+		{
+			name:  "unstructured",
+			input: "Miete Januar",
+			want:  Details{Unstructured: "Miete Januar"},
+		},
+		{
+			name:  "all subfields",
+			input: "166?00SEPA-UEBERWEISUNG?109310?20EREF+123?21SVWZ+Miete?30GENODEF1S04?31DE02120300000000202051?32Max Mustermann?34997?60Extra",
+			want: Details{
+				GVC:             "166",
+				BookingText:     "SEPA-UEBERWEISUNG",
+				PrimaNota:       "9310",
+				Purpose:         []string{"EREF+123", "SVWZ+Miete", "Extra"},
+				BankCode:        "GENODEF1S04",
+				AccountNumber:   "DE02120300000000202051",
+				Name:            []string{"Max Mustermann"},
+				TextKeyAddition: "997",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseDetails(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDetails() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package mt940
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/JHeimbach/csvtomt940/converter"
+)
+
+// maxReferenceLength is the maximum length of the reference in :20:
+const maxReferenceLength = 16
+
+// referencePlaceholderPattern matches the placeholders of a reference template, e.g. {account}
+var referencePlaceholderPattern = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// expandReference replaces the placeholders of the template with the values of the statement:
+//
+//	{account} account number
+//	{bank}    bank number
+//	{start}   date of the first transaction as YYMMDD
+//	{end}     date of the last transaction as YYMMDD
+//	{counter} statement number
+//	{hash}    first 8 characters of a hash over all transactions
+//
+// characters that are not in the SWIFT character set are removed and the result is cut to 16 characters
+func (s *BankData) expandReference(template string) (string, error) {
+	var err error
+	reference := referencePlaceholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		switch name {
+		case "account":
+			return s.AccountNumber
+		case "bank":
+			return s.BankNumber
+		case "start":
+			if len(s.Transactions) > 0 {
+				return s.Transactions[0].Date().Format("060102")
+			}
+		case "end":
+			if len(s.Transactions) > 0 {
+				return s.Transactions[len(s.Transactions)-1].Date().Format("060102")
+			}
+		case "counter":
+			return fmt.Sprintf("%d", s.StatementNumber)
+		case "hash":
+			return s.transactionsHash()[:8]
+		default:
+			err = fmt.Errorf("unknown placeholder %s in reference template", placeholder)
+		}
+		return ""
+	})
+	if err != nil {
+		return "", err
+	}
+
+	reference = SanitizeReference(reference)
+	if reference == "" {
+		return "", fmt.Errorf("reference template %q results in an empty reference", template)
+	}
+	return reference, nil
+}
+
+// SanitizeReference removes all characters that are not allowed in :20: and in the references of :61:
+// and cuts the reference to 16 characters,
+// a reference must not start or end with a slash and must not contain two consecutive slashes
+func SanitizeReference(reference string) string {
+	reference = strings.Map(func(c rune) rune {
+		if isSwiftCharacter(c) && c != ' ' {
+			return c
+		}
+		return -1
+	}, converter.ConvertUmlauts(reference))
+
+	for strings.Contains(reference, "//") {
+		reference = strings.ReplaceAll(reference, "//", "/")
+	}
+	reference = strings.TrimLeft(reference, "/")
+	if len(reference) > maxReferenceLength {
+		reference = reference[:maxReferenceLength]
+	}
+	return strings.TrimRight(reference, "/")
+}
+
+// transactionsHash returns a hex encoded hash over date, amount and saldo of all transactions
+func (s *BankData) transactionsHash() string {
+	h := sha256.New()
+	for _, t := range s.Transactions {
+		_, _ = fmt.Fprintf(h, "%s|%d|%s", t.Date().Format("20060102"), t.Amount().Amount(), t.Amount().Currency().Code)
+		if saldo := t.Saldo(); saldo != nil {
+			_, _ = fmt.Fprintf(h, "|%d", saldo.Amount())
+		}
+		_, _ = h.Write([]byte("\n"))
+	}
+	return fmt.Sprintf("%X", h.Sum(nil))
+}
@@ -0,0 +1,86 @@
+package mt940
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestBankData_expandReference(t *testing.T) {
+	s := &BankData{
+		AccountNumber:   "1234567895",
+		BankNumber:      "50010517",
+		StatementNumber: 12,
+		Transactions: []Transaction{
+			testLine(31, time.January, -100, 900),
+			testLine(1, time.February, -500, 400),
+		},
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{name: "static text", template: "STATEMENT", want: "STATEMENT"},
+		{name: "account and counter", template: "{account}-{counter}", want: "1234567895-12"},
+		{name: "period", template: "{start}{end}", want: "200131200201"},
+		{name: "hash", template: "H{hash}", want: "H" + s.transactionsHash()[:8]},
+		{name: "cut to 16 characters", template: "{bank}/{account}", want: "50010517/1234567"},
+		{name: "invalid characters are removed", template: "Übersicht_{counter}", want: "UEbersicht12"},
+		{name: "leading and double slashes are removed", template: "//a//b", want: "a/b"},
+		{name: "unknown placeholder", template: "{iban}", wantErr: true},
+		{name: "empty result", template: "___", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.expandReference(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expandReference() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBankData_createHeaderLine_Template(t *testing.T) {
+	s := &BankData{
+		ReferenceTemplate: "CSV{counter}",
+		Split:             SplitDay,
+		Transactions: []Transaction{
+			testLine(31, time.January, -100, 900),
+			testLine(1, time.February, -500, 400),
+		},
+	}
+	var got []string
+	for _, statement := range s.Statements() {
+		w := &bytes.Buffer{}
+		if err := statement.createHeaderLine(w); err != nil {
+			t.Fatalf("createHeaderLine() error = %v", err)
+		}
+		got = append(got, w.String())
+	}
+	if len(got) != 2 || got[0] != ":20:CSV1\r\n" || got[1] != ":20:CSV2\r\n" {
+		t.Errorf("createHeaderLine() got = %#v", got)
+	}
+}
+
+func TestSanitizeReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		want      string
+	}{
+		{reference: "3H2C21S2A1B2C3D4/1", want: "3H2C21S2A1B2C3D4"},
+		{reference: "AB_12 3", want: "AB123"},
+		{reference: "/Überweisung//1/", want: "UEberweisung/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			if got := SanitizeReference(tt.reference); got != tt.want {
+				t.Errorf("SanitizeReference() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package mt940
+
+import "fmt"
+
+// SplitMode defines how the transactions of BankData are split into statements
+type SplitMode int
+
+const (
+	// SplitNone writes all transactions into a single statement
+	SplitNone SplitMode = iota
+	// SplitDay writes one statement per booking day
+	SplitDay
+	// SplitMonth writes one statement per booking month
+	SplitMonth
+)
+
+// ParseSplitMode returns the SplitMode for none, day or month
+func ParseSplitMode(s string) (SplitMode, error) {
+	switch s {
+	case "", "none":
+		return SplitNone, nil
+	case "day":
+		return SplitDay, nil
+	case "month":
+		return SplitMonth, nil
+	}
+	return SplitNone, fmt.Errorf("split mode \"%s\" not supported (available options: none, day, month)", s)
+}
+
+// String returns the name of the split mode
+func (m SplitMode) String() string {
+	switch m {
+	case SplitDay:
+		return "day"
+	case SplitMonth:
+		return "month"
+	}
+	return "none"
+}
+
+// key returns the group key of the transaction for the split mode
+func (m SplitMode) key(t Transaction) string {
+	switch m {
+	case SplitDay:
+		return t.Date().Format("2006-01-02")
+	case SplitMonth:
+		return t.Date().Format("2006-01")
+	}
+	return ""
+}
+
+// Statements splits the transactions into statements according to Split,
+// each statement gets the next statement number beginning with StatementNumber (or 1 if it is not set) and sequence number 1.
+// The transactions have to be sorted by date, the closing balance of a statement is the opening balance of the next one,
+// Opening belongs to the first and Closing to the last statement.
+// With SplitNone or without transactions the BankData itself is returned as the only statement.
+func (s *BankData) Statements() []*BankData {
+	if s.Split == SplitNone || len(s.Transactions) == 0 {
+		return []*BankData{s}
+	}
+
+	number := s.StatementNumber
+	if number == 0 {
+		number = 1
+	}
+
+	var statements []*BankData
+	var current *BankData
+	var currentKey string
+	for _, t := range s.Transactions {
+		if key := s.Split.key(t); current == nil || key != currentKey {
+			current = &BankData{
+				IBAN:              s.IBAN,
+				Currency:          s.Currency,
+				AccountNumber:     s.AccountNumber,
+				BankNumber:        s.BankNumber,
+				Reference:         s.Reference,
+				ReferenceTemplate: s.ReferenceTemplate,
+				AccountFormat:     s.AccountFormat,
+				StatementNumber:   number + len(statements),
+				SequenceNumber:    1,
+			}
+			currentKey = key
+			statements = append(statements, current)
+		}
+		current.Transactions = append(current.Transactions, t)
+	}
+	statements[0].Opening = s.Opening
+	statements[len(statements)-1].Closing = s.Closing
+	return statements
+}
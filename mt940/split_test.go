@@ -0,0 +1,100 @@
+package mt940
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+func testLine(day int, month time.Month, amount int64, saldo int64) *StatementLine {
+	date := time.Date(2020, month, day, 0, 0, 0, 0, time.UTC)
+	return &StatementLine{
+		Sales:   SalesLine{ValueDate: date, EntryDate: date, Amount: money.New(amount, "EUR")},
+		Details: Details{GVC: "005", BookingText: "Lastschrift"},
+		Balance: money.New(saldo, "EUR"),
+	}
+}
+
+func TestParseSplitMode(t *testing.T) {
+	for _, mode := range []SplitMode{SplitNone, SplitDay, SplitMonth} {
+		got, err := ParseSplitMode(mode.String())
+		if err != nil || got != mode {
+			t.Errorf("ParseSplitMode(%s) = %v, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseSplitMode("week"); err == nil {
+		t.Errorf("ParseSplitMode(week) expected error")
+	}
+}
+
+func TestBankData_Statements(t *testing.T) {
+	transactions := []Transaction{
+		testLine(1, time.January, -100, 900),
+		testLine(1, time.January, -100, 800),
+		testLine(2, time.January, 200, 1000),
+		testLine(1, time.February, -500, 500),
+	}
+	tests := []struct {
+		name            string
+		split           SplitMode
+		statementNumber int
+		wantCounts      []int
+		wantNumbers     []int
+	}{
+		{name: "no split", split: SplitNone, wantCounts: []int{4}, wantNumbers: []int{0}},
+		{name: "split by day", split: SplitDay, wantCounts: []int{2, 1, 1}, wantNumbers: []int{1, 2, 3}},
+		{name: "split by month", split: SplitMonth, wantCounts: []int{3, 1}, wantNumbers: []int{1, 2}},
+		{name: "split with start number", split: SplitMonth, statementNumber: 7, wantCounts: []int{3, 1}, wantNumbers: []int{7, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &BankData{
+				AccountNumber:   "0000000000",
+				BankNumber:      "11111111",
+				StatementNumber: tt.statementNumber,
+				Split:           tt.split,
+				Transactions:    transactions,
+			}
+			got := s.Statements()
+			if len(got) != len(tt.wantCounts) {
+				t.Fatalf("Statements() got %d statements, want %d", len(got), len(tt.wantCounts))
+			}
+			for i, statement := range got {
+				if len(statement.Transactions) != tt.wantCounts[i] {
+					t.Errorf("Statements() statement %d has %d transactions, want %d", i, len(statement.Transactions), tt.wantCounts[i])
+				}
+				if statement.StatementNumber != tt.wantNumbers[i] {
+					t.Errorf("Statements() statement %d has number %d, want %d", i, statement.StatementNumber, tt.wantNumbers[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBankData_ConvertToMT940_Split(t *testing.T) {
+	s := &BankData{
+		AccountNumber: "0000000000",
+		BankNumber:    "11111111",
+		Split:         SplitMonth,
+		Transactions: []Transaction{
+			testLine(31, time.January, -100, 900),
+			testLine(1, time.February, -500, 400),
+		},
+	}
+	w := &bytes.Buffer{}
+	if err := s.ConvertToMT940(w); err != nil {
+		t.Fatalf("ConvertToMT940() error = %v", err)
+	}
+	want := ":20:CSVTOMT940\r\n:25:11111111/0000000000\r\n:28C:1/1\r\n:60F:C200131EUR10,00\r\n" +
+		":61:2001310131D1,00NTRFNONREF\r\n:86:005?00Lastschrift\r\n:62F:C200131EUR9,00\r\n" +
+		":20:CSVTOMT940\r\n:25:11111111/0000000000\r\n:28C:2/1\r\n:60F:C200201EUR9,00\r\n" +
+		":61:2002010201D5,00NTRFNONREF\r\n:86:005?00Lastschrift\r\n:62F:C200201EUR4,00\r\n"
+	if got := w.String(); got != want {
+		t.Errorf("ConvertToMT940() got = %#v, want %#v", got, want)
+	}
+	if issues := Validate(bytes.NewReader(w.Bytes())); issues != nil {
+		t.Errorf("Validate() got issues %v", issues)
+	}
+}
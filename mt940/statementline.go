@@ -0,0 +1,310 @@
+package mt940
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/JHeimbach/csvtomt940/formatter"
+	"github.com/Rhymond/go-money"
+)
+
+// SalesLine contains the fields of a :61: statement line
+type SalesLine struct {
+	ValueDate time.Time
+	// EntryDate is optional, it is omitted if it is zero
+	EntryDate time.Time
+	// Reversal marks a cancellation, a negative amount is written as RC and a positive amount as RD
+	Reversal bool
+	// FundsCode is the optional third character of the currency code
+	FundsCode string
+	// Amount is negative for debit and positive for credit entries
+	Amount *money.Money
+	// TypeCode is the transaction type identification code, defaults to NTRF
+	TypeCode string
+	// CustomerReference defaults to NONREF
+	CustomerReference    string
+	BankReference        string
+	SupplementaryDetails string
+}
+
+// Details contains the subfields of a :86: multipurpose line
+type Details struct {
+	GVC         string
+	BookingText string
+	PrimaNota   string
+	// Purpose holds the subfields ?20 to ?29 and ?60 to ?63
+	Purpose       []string
+	BankCode      string
+	AccountNumber string
+	// Name holds the subfields ?32 and ?33
+	Name            []string
+	TextKeyAddition string
+	// Unstructured is set instead of the other fields if the :86: line has no subfields
+	Unstructured string
+}
+
+// StatementLine is a Transaction with structured :61: and :86: fields, it is created by reading MT940 statements
+// and by the converters. Party and SEPA are the untruncated counterparty and references of the export,
+// they are empty for lines read from MT940.
+type StatementLine struct {
+	Sales   SalesLine
+	Details Details
+	Balance *money.Money
+	Party   Counterparty
+	SEPA    converter.SEPAReferences
+}
+
+// Saldo returns the balance after the transaction
+func (l *StatementLine) Saldo() *money.Money {
+	return l.Balance
+}
+
+// Amount returns the amount of the sales line
+func (l *StatementLine) Amount() *money.Money {
+	return l.Sales.Amount
+}
+
+// Date returns the entry date or the value date if no entry date is set
+func (l *StatementLine) Date() time.Time {
+	if l.Sales.EntryDate.IsZero() {
+		return l.Sales.ValueDate
+	}
+	return l.Sales.EntryDate
+}
+
+// Counterparty returns the counterparty of the export, for lines read from MT940 it is taken from ?30 to ?33
+func (l *StatementLine) Counterparty() Counterparty {
+	if l.Party != (Counterparty{}) {
+		return l.Party
+	}
+	return Counterparty{
+		Name:    strings.Join(l.Details.Name, ""),
+		Account: CounterpartyAccount{AccountNumber: l.Details.AccountNumber, BankCode: l.Details.BankCode},
+	}
+}
+
+// SetCounterparty sets the counterparty and writes its account to ?30 and ?31 and its name to ?32 and ?33
+func (l *StatementLine) SetCounterparty(c Counterparty) {
+	c.Name = strings.TrimSpace(c.Name)
+	l.Party = c
+	l.Details.BankCode = c.Account.BankCode
+	l.Details.AccountNumber = c.Account.AccountNumber
+	l.Details.Name = converter.SplitSubfields(c.Name, 2)
+}
+
+// References returns the SEPA references of the export, for lines read from MT940 they are parsed from the purpose
+func (l *StatementLine) References() converter.SEPAReferences {
+	if l.SEPA != (converter.SEPAReferences{}) {
+		return l.SEPA
+	}
+	purpose := strings.Join(l.Details.Purpose, "")
+	if l.Details.Unstructured != "" {
+		purpose = l.Details.Unstructured
+	}
+	return converter.ParseSEPA(purpose)
+}
+
+// SetReferences sets the references and writes them to at most max subfields of the purpose
+func (l *StatementLine) SetReferences(r converter.SEPAReferences, max int) {
+	l.SEPA = r
+	l.Details.Purpose = r.LimitedFields(max)
+}
+
+// ConvertToMT940 writes the :61: and :86: lines of the statement line
+func (l *StatementLine) ConvertToMT940(writer io.Writer) error {
+	err := l.Sales.Write(writer)
+	if err != nil {
+		return err
+	}
+	return l.Details.write(writer)
+}
+
+// ToStatementLine returns the transaction as StatementLine, transactions of other types are converted to MT940 and read back
+// so that their :61: and :86: fields can be used by other output formats
+func ToStatementLine(t Transaction) (*StatementLine, error) {
+	if l, ok := t.(*StatementLine); ok {
+		return l, nil
+	}
+	var buf bytes.Buffer
+	if err := t.ConvertToMT940(&buf); err != nil {
+		return nil, err
+	}
+	statements, err := readStatements(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	line := &StatementLine{Balance: t.Saldo()}
+	for _, fields := range statements {
+		for _, f := range fields {
+			switch f.tag {
+			case "61":
+				sales, err := parseSalesLine(f.lines, t.Amount().Currency().Code)
+				if err != nil {
+					return nil, newFieldError(f, err)
+				}
+				line.Sales = *sales
+			case "86":
+				line.Details = parseDetails(f.value())
+			}
+		}
+	}
+	if line.Sales.Amount == nil {
+		return nil, fmt.Errorf("transaction of %s has no statement line", t.Date().Format("2006-01-02"))
+	}
+	return line, nil
+}
+
+// mark returns the debit/credit mark of the sales line
+func (s *SalesLine) mark() string {
+	mark := converter.IsCreditOrDebit(s.Amount)
+	if !s.Reversal {
+		return mark
+	}
+	// a reversed credit is booked as debit and the other way around
+	if mark == "D" {
+		return "RC"
+	}
+	return "RD"
+}
+
+// maxSupplementaryDetailsLength is the maximum length of the supplementary details of :61:
+const maxSupplementaryDetailsLength = 34
+
+// NewSalesLine returns the sales line of a transaction of a converter, the type code and the reversal mark are taken
+// from the GVC code, the customer reference is the end to end reference of the transaction
+func NewSalesLine(valueDate, entryDate time.Time, amount *money.Money, gvc, customerReference string) SalesLine {
+	return SalesLine{
+		ValueDate:         valueDate,
+		EntryDate:         entryDate,
+		Reversal:          IsReversalGVC(gvc),
+		Amount:            amount,
+		TypeCode:          TypeCodeForGVC(gvc),
+		CustomerReference: customerReference,
+	}
+}
+
+// customerReference returns the customer reference of the sales line, references that are not provided are written as NONREF
+func (s *SalesLine) customerReference() string {
+	customerReference := SanitizeReference(s.CustomerReference)
+	if customerReference == "" || strings.EqualFold(customerReference, "NOTPROVIDED") {
+		return "NONREF"
+	}
+	return customerReference
+}
+
+// Write writes the :61: line and its supplementary details to the writer
+func (s *SalesLine) Write(writer io.Writer) error {
+	if s.Amount == nil {
+		return fmt.Errorf("could not create sales line without amount")
+	}
+	typeCode := s.TypeCode
+	if typeCode == "" {
+		typeCode = "NTRF"
+	}
+	if len(typeCode) != 4 {
+		return fmt.Errorf("could not create sales line with type code %s, it must have 4 characters", typeCode)
+	}
+	if len(s.FundsCode) > 1 {
+		return fmt.Errorf("could not create sales line with funds code %s, it must be a single letter", s.FundsCode)
+	}
+	entryDate := ""
+	if !s.EntryDate.IsZero() {
+		entryDate = s.EntryDate.Format("0102")
+	}
+
+	// :61:<ValueDate>[<EntryDate>]<Mark>[<FundsCode>]<Amount><TypeCode><CustomerReference>[//<BankReference>]
+	line := fmt.Sprintf(":61:%s%s%s%s%s%s%s",
+		s.ValueDate.Format("060102"),
+		entryDate,
+		s.mark(),
+		s.FundsCode,
+		formatter.ConvertMoneyToString(s.Amount.Absolute()),
+		typeCode,
+		s.customerReference(),
+	)
+	if bankReference := SanitizeReference(s.BankReference); bankReference != "" {
+		line += "//" + bankReference
+	}
+	line += "\r\n"
+	if s.SupplementaryDetails != "" {
+		details := s.SupplementaryDetails
+		if len(details) > maxSupplementaryDetailsLength {
+			details = details[:maxSupplementaryDetailsLength]
+		}
+		line += details + "\r\n"
+	}
+
+	_, err := writer.Write([]byte(line))
+	if err != nil {
+		return fmt.Errorf("could not create sales line: %w", err)
+	}
+	return nil
+}
+
+// String joins the subfields to the content of the :86: line
+func (d *Details) String() string {
+	if d.Unstructured != "" {
+		return d.Unstructured
+	}
+	if d.GVC == "" {
+		return ""
+	}
+
+	result := d.GVC
+	if d.BookingText != "" {
+		result += "?00" + d.BookingText
+	}
+	if d.PrimaNota != "" {
+		result += "?10" + d.PrimaNota
+	}
+	for i, p := range d.Purpose {
+		control := 20 + i
+		if i >= 10 {
+			control = 60 + i - 10
+		}
+		result += fmt.Sprintf("?%d%s", control, p)
+	}
+	if d.BankCode != "" {
+		result += "?30" + d.BankCode
+	}
+	if d.AccountNumber != "" {
+		result += "?31" + d.AccountNumber
+	}
+	for i, n := range d.Name {
+		result += fmt.Sprintf("?%d%s", 32+i, n)
+	}
+	if d.TextKeyAddition != "" {
+		result += "?34" + d.TextKeyAddition
+	}
+	return result
+}
+
+// write writes the :86: line to the writer, nothing is written if the details are empty
+func (d *Details) write(writer io.Writer) error {
+	lineStr := d.String()
+	if lineStr == "" {
+		return nil
+	}
+	if len(lineStr) > 390 {
+		return fmt.Errorf("mulitpurpose line is too long")
+	}
+	lineParts := converter.SplitStringInParts(lineStr, 65, false)
+
+	_, err := writer.Write(
+		[]byte(
+			fmt.Sprintf(
+				":86:%s\r\n",
+				strings.Join(lineParts, "\r\n"),
+			),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("could not create multipurpose line: %w", err)
+	}
+	return nil
+}
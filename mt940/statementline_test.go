@@ -0,0 +1,176 @@
+package mt940
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/converter"
+	"github.com/Rhymond/go-money"
+)
+
+func TestSalesLine_Write(t *testing.T) {
+	date := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		line    SalesLine
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			line: SalesLine{ValueDate: date, Amount: money.New(1050, "EUR")},
+			want: ":61:210301C10,50NTRFNONREF\r\n",
+		},
+		{
+			name: "all fields",
+			line: SalesLine{
+				ValueDate:            date,
+				EntryDate:            date.AddDate(0, 0, 1),
+				FundsCode:            "R",
+				Amount:               money.New(-1050, "EUR"),
+				TypeCode:             "NDDT",
+				CustomerReference:    "INV-2021-03",
+				BankReference:        "0123456789",
+				SupplementaryDetails: "/OCMT/USD12,50/",
+			},
+			want: ":61:2103010302DR10,50NDDTINV-2021-03//0123456789\r\n/OCMT/USD12,50/\r\n",
+		},
+		{
+			name: "reversed debit",
+			line: SalesLine{ValueDate: date, Reversal: true, Amount: money.New(1050, "EUR")},
+			want: ":61:210301RD10,50NTRFNONREF\r\n",
+		},
+		{
+			name: "reversed credit",
+			line: SalesLine{ValueDate: date, Reversal: true, Amount: money.New(-1050, "EUR")},
+			want: ":61:210301RC10,50NTRFNONREF\r\n",
+		},
+		{
+			name: "references are sanitized and cut",
+			line: SalesLine{
+				ValueDate:         date,
+				Amount:            money.New(1050, "EUR"),
+				CustomerReference: "/Rechnung Nr. 2021//0001234/",
+				BankReference:     "NOTPROVIDED",
+			},
+			want: ":61:210301C10,50NTRFRechnungNr.2021//NOTPROVIDED\r\n",
+		},
+		{
+			name: "not provided end to end reference",
+			line: SalesLine{ValueDate: date, Amount: money.New(1050, "EUR"), CustomerReference: "NOTPROVIDED"},
+			want: ":61:210301C10,50NTRFNONREF\r\n",
+		},
+		{
+			name:    "invalid type code",
+			line:    SalesLine{ValueDate: date, Amount: money.New(1050, "EUR"), TypeCode: "TRF"},
+			wantErr: true,
+		},
+		{
+			name:    "missing amount",
+			line:    SalesLine{ValueDate: date},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tt.line.Write(&buf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Write() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSalesLine(t *testing.T) {
+	date := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	got := NewSalesLine(date, date, money.New(-1050, "EUR"), "109", "E2E-1")
+	if got.TypeCode != "NDDT" || !got.Reversal || got.CustomerReference != "E2E-1" {
+		t.Errorf("NewSalesLine() = %+v", got)
+	}
+}
+
+func TestToStatementLine(t *testing.T) {
+	saldo := money.New(118832, "EUR")
+	transaction := &mockTransaction{
+		convert: func(writer io.Writer) error {
+			_, err := writer.Write([]byte(":61:2001090109D1,62NTRFNONREF//REF-2\r\n" +
+				":86:005?00Lastschrift?20SVWZ+Reactive full-range lo?21cal area networ\r\nk?32Yabox\r\n"))
+			return err
+		},
+		saldo:  func() *money.Money { return saldo },
+		amount: func() *money.Money { return money.New(-162, "EUR") },
+		date:   func() time.Time { return time.Date(2020, 1, 9, 0, 0, 0, 0, time.UTC) },
+	}
+
+	got, err := ToStatementLine(transaction)
+	if err != nil {
+		t.Fatalf("ToStatementLine() error = %v", err)
+	}
+	if got.Balance != saldo || got.Amount().Amount() != -162 || got.Sales.BankReference != "REF-2" {
+		t.Errorf("ToStatementLine() sales = %+v, balance %v", got.Sales, got.Balance)
+	}
+	wantDetails := Details{
+		GVC:         "005",
+		BookingText: "Lastschrift",
+		Purpose:     []string{"SVWZ+Reactive full-range lo", "cal area network"},
+		Name:        []string{"Yabox"},
+	}
+	if !reflect.DeepEqual(got.Details, wantDetails) {
+		t.Errorf("ToStatementLine() details = %#v, want %#v", got.Details, wantDetails)
+	}
+
+	line := &StatementLine{}
+	if same, _ := ToStatementLine(line); same != line {
+		t.Errorf("ToStatementLine() did not return the StatementLine itself")
+	}
+	transaction.convert = func(writer io.Writer) error { return nil }
+	if _, err := ToStatementLine(transaction); err == nil {
+		t.Errorf("ToStatementLine() without :61: returned no error")
+	}
+}
+
+func TestStatementLine_References(t *testing.T) {
+	tests := []struct {
+		name    string
+		details Details
+		want    map[string]string
+	}{
+		{name: "empty", details: Details{GVC: "166"}, want: map[string]string{}},
+		{name: "without markers", details: Details{Purpose: []string{"Rechnung 42"}}, want: map[string]string{"SVWZ": "Rechnung 42"}},
+		{
+			name:    "markers",
+			details: Details{Purpose: []string{"EREF+E-456MREF+M-123", "CRED+DE98ZZZ09999999999", "SVWZ+Rechnung 42 KREF+NONREF"}},
+			want:    map[string]string{"EREF": "E-456", "MREF": "M-123", "CRED": "DE98ZZZ09999999999", "SVWZ": "Rechnung 42", "KREF": "NONREF"},
+		},
+		{name: "text before markers", details: Details{Unstructured: "Miete ABWA+Test Tester"}, want: map[string]string{"SVWZ": "Miete", "ABWA": "Test Tester"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := &StatementLine{Details: tt.details}
+			if got := line.References().Tags(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("References() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatementLine_SetReferences(t *testing.T) {
+	remittance := strings.TrimSpace(strings.Repeat("Rechnung 42 ", 5))
+	line := &StatementLine{}
+	line.SetReferences(converter.SEPAReferences{EndToEnd: "E-456", Remittance: remittance}, 2)
+
+	if want := []string{"EREF+E-456", "SVWZ+Rechnung 42 Rechnung 4"}; !reflect.DeepEqual(line.Details.Purpose, want) {
+		t.Errorf("SetReferences() purpose = %q, want %q", line.Details.Purpose, want)
+	}
+	if got := line.References(); got.Remittance != remittance || got.EndToEnd != "E-456" {
+		t.Errorf("References() = %+v, want the complete remittance information", got)
+	}
+}
@@ -1,8 +1,11 @@
 package mt940
 
 import (
+	"strings"
 	"time"
 
+	"github.com/JHeimbach/csvtomt940/blz"
+	"github.com/JHeimbach/csvtomt940/converter"
 	"github.com/Rhymond/go-money"
 )
 
@@ -13,4 +16,57 @@ type Transaction interface {
 	Saldo() *money.Money
 	Amount() *money.Money
 	Date() time.Time
+	// Counterparty returns the other party of the transaction as it is given in the export
+	Counterparty() Counterparty
+	// References returns the SEPA references of the transaction as they are given in the export
+	References() converter.SEPAReferences
+}
+
+// Categorized is implemented by transactions whose csv export contains a category, e.g. ING and N26,
+// the category is not part of MT940 but of other output formats
+type Categorized interface {
+	Category() string
+}
+
+// Counterparty is the other party of a transaction, the name is not cut to the 54 characters of ?32 and ?33
+type Counterparty struct {
+	Name    string
+	Account CounterpartyAccount
+}
+
+// CounterpartyAccount is the account of the other party of a transaction, it is written to ?30 and ?31 of :86:
+type CounterpartyAccount struct {
+	// AccountNumber is the IBAN or the account number
+	AccountNumber string
+	// BankCode is the BIC or the bank code (BLZ)
+	BankCode string
+}
+
+// NewCounterpartyAccount removes the spaces of the account number, if no bank code is given the BIC of a german IBAN
+// is looked up in the bank code directory, the bank code of the IBAN is used for banks that are not in the directory
+func NewCounterpartyAccount(accountNumber, bankCode string) CounterpartyAccount {
+	a := CounterpartyAccount{
+		AccountNumber: strings.ReplaceAll(accountNumber, " ", ""),
+		BankCode:      strings.ToUpper(strings.ReplaceAll(bankCode, " ", "")),
+	}
+	if a.BankCode == "" {
+		if bic, ok := blz.BICForIBAN(a.AccountNumber); ok {
+			a.BankCode = bic
+		} else if code, ok := blz.FromIBAN(a.AccountNumber); ok {
+			a.BankCode = code
+		}
+	}
+	return a
+}
+
+// Subfields returns the subfields ?30 and ?31 of :86:, empty values are omitted
+func (a CounterpartyAccount) Subfields() string {
+	result := ""
+	if a.BankCode != "" && len(a.BankCode) <= 11 {
+		result += "?30" + a.BankCode
+	}
+	if a.AccountNumber != "" && len(a.AccountNumber) <= 34 {
+		result += "?31" + a.AccountNumber
+	}
+	return result
 }
@@ -0,0 +1,68 @@
+package mt940
+
+import (
+	"os"
+	"testing"
+
+	"github.com/JHeimbach/csvtomt940/blz"
+)
+
+// TestMain uses the excerpt of the bank code file because the embedded file is only complete after go generate
+func TestMain(m *testing.M) {
+	d, err := blz.Load("../blz/testdata/blz.txt")
+	if err != nil {
+		panic(err)
+	}
+	blz.SetDefault(d)
+	os.Exit(m.Run())
+}
+
+func TestNewCounterpartyAccount(t *testing.T) {
+	tests := []struct {
+		name          string
+		accountNumber string
+		bankCode      string
+		want          CounterpartyAccount
+		wantSubfields string
+	}{
+		{
+			name:          "german iban",
+			accountNumber: "DE89 3704 0044 0532 0130 00",
+			want:          CounterpartyAccount{AccountNumber: "DE89370400440532013000", BankCode: "COBADEFFXXX"},
+			wantSubfields: "?30COBADEFFXXX?31DE89370400440532013000",
+		},
+		{
+			name:          "german iban of unknown bank",
+			accountNumber: "DE00111111110000000000",
+			want:          CounterpartyAccount{AccountNumber: "DE00111111110000000000", BankCode: "11111111"},
+			wantSubfields: "?3011111111?31DE00111111110000000000",
+		},
+		{
+			name:          "bic is kept",
+			accountNumber: "DE89370400440532013000",
+			bankCode:      "cobadeffxxx",
+			want:          CounterpartyAccount{AccountNumber: "DE89370400440532013000", BankCode: "COBADEFFXXX"},
+			wantSubfields: "?30COBADEFFXXX?31DE89370400440532013000",
+		},
+		{
+			name:          "foreign iban",
+			accountNumber: "GB29NWBK60161331926819",
+			want:          CounterpartyAccount{AccountNumber: "GB29NWBK60161331926819"},
+			wantSubfields: "?31GB29NWBK60161331926819",
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewCounterpartyAccount(tt.accountNumber, tt.bankCode)
+			if got != tt.want {
+				t.Errorf("NewCounterpartyAccount() = %+v, want %+v", got, tt.want)
+			}
+			if s := got.Subfields(); s != tt.wantSubfields {
+				t.Errorf("Subfields() = %s, want %s", s, tt.wantSubfields)
+			}
+		})
+	}
+}
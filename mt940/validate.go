@@ -0,0 +1,260 @@
+package mt940
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Rhymond/go-money"
+)
+
+const (
+	// maxLineLength is the maximum length of every line in a MT940 statement
+	maxLineLength = 65
+	// maxMultipurposeLines is the maximum number of lines of the :86: field
+	maxMultipurposeLines = 6
+	// maxSubfieldLength is the maximum length of the text subfields of :86:
+	maxSubfieldLength = 27
+)
+
+var (
+	referencePattern       = regexp.MustCompile(`^[^/].{0,15}$`)
+	statementNumberPattern = regexp.MustCompile(`^\d{1,5}(/\d{1,5})?$`)
+	gvcPattern             = regexp.MustCompile(`^\d{3}$`)
+)
+
+// Issue is a violation of the MT940 rules found by Validate, Line and Column start at 1
+type Issue struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+// String returns the issue with its position
+func (i Issue) String() string {
+	return fmt.Sprintf("line %d, column %d: %s", i.Line, i.Column, i.Message)
+}
+
+// Validate checks the MT940 statements from r against the SWIFT and DFUE rules,
+// it returns all issues that were found or nil if the statements are valid
+func Validate(r io.Reader) []Issue {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return []Issue{{Message: fmt.Sprintf("could not read statements: %v", err)}}
+	}
+
+	issues := validateLines(content)
+
+	statements, err := readStatements(bytes.NewReader(content))
+	if err != nil {
+		return append(issues, Issue{Line: 1, Column: 1, Message: err.Error()})
+	}
+	if len(statements) == 0 {
+		return append(issues, Issue{Line: 1, Column: 1, Message: "no statement found"})
+	}
+	for _, fields := range statements {
+		issues = append(issues, validateStatement(fields)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line == issues[j].Line {
+			return issues[i].Column < issues[j].Column
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues
+}
+
+// isSwiftCharacter returns true if c is part of the SWIFT X character set
+func isSwiftCharacter(c rune) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.ContainsRune("/-?:().,'+{} ", c)
+}
+
+// validateLines checks the length and the character set of every line
+func validateLines(content []byte) []Issue {
+	var issues []Issue
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		// the field tag is not part of the 65 characters
+		tagLength := len(fieldPattern.FindString(line))
+		if length := utf8.RuneCountInString(line) - tagLength; length > maxLineLength {
+			issues = append(issues, Issue{
+				Line:    i + 1,
+				Column:  tagLength + maxLineLength + 1,
+				Message: fmt.Sprintf("line is %d characters long, only %d are allowed", length, maxLineLength),
+			})
+		}
+		column := 0
+		for _, c := range line {
+			column++
+			if !isSwiftCharacter(c) {
+				issues = append(issues, Issue{
+					Line:    i + 1,
+					Column:  column,
+					Message: fmt.Sprintf("character %q is not in the SWIFT character set", c),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// position returns line and column of the character at offset in the joined value of the field
+func (f *field) position(offset int) (int, int) {
+	column := len(f.tag) + 3
+	for i, l := range f.lines {
+		if offset < len(l) || i == len(f.lines)-1 {
+			return f.line + i, column + offset
+		}
+		offset -= len(l)
+		column = 1
+	}
+	return f.line, column
+}
+
+// issue creates an issue at offset in the value of the field
+func (f *field) issue(offset int, format string, a ...interface{}) Issue {
+	line, column := f.position(offset)
+	return Issue{Line: line, Column: column, Message: fmt.Sprintf(format, a...)}
+}
+
+// validateStatement checks the order and the content of the fields of a single statement
+// and that the opening balance plus all statement lines equals the closing balance
+func validateStatement(fields []*field) []Issue {
+	var issues []Issue
+	var opening, closing *Balance
+	var closingField *field
+	var sum *money.Money
+	var lastTag string
+	seen := map[string]bool{}
+
+	if fields[0].tag != "20" {
+		issues = append(issues, fields[0].issue(0, "statement must start with :20:, got :%s:", fields[0].tag))
+	}
+
+	for _, f := range fields {
+		value := f.value()
+		switch f.tag {
+		case "20":
+			if !referencePattern.MatchString(value) {
+				issues = append(issues, f.issue(0, "reference must be 1 to 16 characters and must not start with /"))
+			}
+		case "25":
+			if value == "" || len(value) > 35 {
+				issues = append(issues, f.issue(0, "account identification must be 1 to 35 characters"))
+			}
+		case "28C":
+			if !statementNumberPattern.MatchString(value) {
+				issues = append(issues, f.issue(0, "statement number must match 5n[/5n]"))
+			}
+		case "60F", "60M":
+			b, err := parseBalance(value)
+			if err != nil {
+				issues = append(issues, f.issue(0, "invalid opening balance: %v", err))
+				break
+			}
+			opening = b
+			sum = money.New(0, b.Amount.Currency().Code)
+		case "61":
+			if len(f.lines) > 2 {
+				issues = append(issues, f.issue(0, "statement line must not have more than 2 lines"))
+			}
+			currency := "EUR"
+			if sum != nil {
+				currency = sum.Currency().Code
+			}
+			sales, err := parseSalesLine(f.lines, currency)
+			if err != nil {
+				issues = append(issues, f.issue(0, "invalid statement line: %v", err))
+				sum = nil
+				break
+			}
+			if sum != nil {
+				sum, _ = sum.Add(sales.Amount)
+			}
+		case "86":
+			if lastTag != "61" {
+				issues = append(issues, f.issue(0, ":86: must follow a :61: statement line"))
+			}
+			issues = append(issues, validateMultipurposeLine(f)...)
+		case "62F", "62M":
+			b, err := parseBalance(value)
+			if err != nil {
+				issues = append(issues, f.issue(0, "invalid closing balance: %v", err))
+				break
+			}
+			closing, closingField = b, f
+		}
+		seen[f.tag] = true
+		lastTag = f.tag
+	}
+
+	for _, required := range [][]string{{"20"}, {"25"}, {"28C"}, {"60F", "60M"}, {"62F", "62M"}} {
+		if !seen[required[0]] && (len(required) == 1 || !seen[required[1]]) {
+			issues = append(issues, fields[0].issue(0, "statement has no :%s: field", required[0]))
+		}
+	}
+
+	if opening != nil && closing != nil && sum != nil {
+		expected, err := opening.Amount.Add(sum)
+		if err != nil {
+			issues = append(issues, closingField.issue(0, "currency of opening balance and statement lines differ"))
+		} else if ok, err := expected.Equals(closing.Amount); err != nil || !ok {
+			issues = append(issues, closingField.issue(0,
+				"closing balance %s does not match opening balance plus statement lines %s",
+				closing.Amount.Display(), expected.Display(),
+			))
+		}
+	}
+	return issues
+}
+
+// validateMultipurposeLine checks the number of lines, the gvc code and the subfields of :86:
+func validateMultipurposeLine(f *field) []Issue {
+	var issues []Issue
+	if len(f.lines) > maxMultipurposeLines {
+		issues = append(issues, f.issue(0, "multipurpose line has %d lines, only %d are allowed", len(f.lines), maxMultipurposeLines))
+	}
+
+	value := f.value()
+	if len(value) < 3 || !gvcPattern.MatchString(value[:3]) {
+		return append(issues, f.issue(0, "multipurpose line must start with a 3 digit gvc code"))
+	}
+	if len(value) > 3 && value[3] != '?' {
+		return append(issues, f.issue(3, "gvc code must be followed by a subfield"))
+	}
+
+	purposeCount := 0
+	seen := map[int]bool{}
+	for _, sf := range splitSubfields(value) {
+		if seen[sf.control] {
+			issues = append(issues, f.issue(sf.offset, "subfield ?%02d is used more than once", sf.control))
+		}
+		seen[sf.control] = true
+
+		switch {
+		case sf.control == 0, sf.control == 32, sf.control == 33, isPurposeControl(sf.control):
+			if len(sf.value) > maxSubfieldLength {
+				issues = append(issues, f.issue(sf.offset, "subfield ?%02d is %d characters long, only %d are allowed", sf.control, len(sf.value), maxSubfieldLength))
+			}
+		case sf.control == 10, sf.control == 30, sf.control == 31, sf.control == 34:
+		default:
+			issues = append(issues, f.issue(sf.offset, "unknown subfield ?%02d", sf.control))
+		}
+		if sf.control >= 20 && sf.control <= 29 {
+			purposeCount++
+		}
+	}
+	if purposeCount > 10 {
+		issues = append(issues, f.issue(0, "multipurpose line has %d subfields ?20 to ?29, only 10 are allowed", purposeCount))
+	}
+	return issues
+}
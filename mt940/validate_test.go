@@ -0,0 +1,76 @@
+package mt940
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Issue
+	}{
+		{
+			name:  "valid statement",
+			input: testStatement,
+			want:  nil,
+		},
+		{
+			name:  "valid statement with lf line endings",
+			input: strings.ReplaceAll(testStatement, "\r\n", "\n"),
+			want:  nil,
+		},
+		{
+			name:  "line too long",
+			input: strings.Replace(testStatement, "?32Yabox", "?32"+strings.Repeat("a", 27)+"?33"+strings.Repeat("b", 27), 1),
+			want:  []Issue{{Line: 7, Column: 66, Message: "line is 74 characters long, only 65 are allowed"}},
+		},
+		{
+			name:  "invalid character",
+			input: strings.Replace(testStatement, "?32Yabox", "?32Yäbox", 1),
+			want:  []Issue{{Line: 7, Column: 19, Message: "character 'ä' is not in the SWIFT character set"}},
+		},
+		{
+			name:  "balance does not match",
+			input: strings.Replace(testStatement, ":62F:C200109EUR1188,32", ":62F:C200109EUR1188,33", 1),
+			want:  []Issue{{Line: 11, Column: 6, Message: "closing balance €1,188.33 does not match opening balance plus statement lines €1,188.32"}},
+		},
+		{
+			name:  "invalid date",
+			input: strings.Replace(testStatement, ":61:2001090109D1,62", ":61:2013090109D1,62", 1),
+			want: []Issue{
+				{Line: 8, Column: 5, Message: "invalid statement line: invalid value date: parsing time \"201309\": month out of range"},
+			},
+		},
+		{
+			name:  "invalid gvc code",
+			input: strings.Replace(testStatement, ":86:051?00", ":86:51?00", 1),
+			want:  []Issue{{Line: 6, Column: 5, Message: "multipurpose line must start with a 3 digit gvc code"}},
+		},
+		{
+			name:  "duplicate and too long subfield",
+			input: strings.Replace(testStatement, "?22KREF+NONREF?32Yabox", "?21KREF+NONREF?32Yabox", 1),
+			want:  []Issue{{Line: 7, Column: 1, Message: "subfield ?21 is used more than once"}},
+		},
+		{
+			name:  "missing fields",
+			input: ":20:CSVTOMT940\r\n:86:005?00Lastschrift\r\n",
+			want: []Issue{
+				{Line: 1, Column: 5, Message: "statement has no :25: field"},
+				{Line: 1, Column: 5, Message: "statement has no :28C: field"},
+				{Line: 1, Column: 5, Message: "statement has no :60F: field"},
+				{Line: 1, Column: 5, Message: "statement has no :62F: field"},
+				{Line: 2, Column: 5, Message: ":86: must follow a :61: statement line"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Validate(strings.NewReader(tt.input)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/formatter"
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+// dateLayout is the format of the booking date in the state file
+const dateLayout = "2006-01-02"
+
+// Account is the state of a single account after the last conversion
+type Account struct {
+	ClosingBalance  int64  `json:"closingBalance"`
+	Currency        string `json:"currency"`
+	LastBookingDate string `json:"lastBookingDate"`
+	StatementNumber int    `json:"statementNumber"`
+}
+
+// File holds the state of all accounts, the accounts are identified by their iban
+type File struct {
+	path     string
+	Accounts map[string]*Account `json:"accounts"`
+}
+
+// DefaultPath returns the path of the state file in the user config directory, e.g. ~/.config/csvtomt940/state.json
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find config directory: %w", err)
+	}
+	return filepath.Join(dir, "csvtomt940", "state.json"), nil
+}
+
+// Load reads the state file from path, if the file does not exist an empty state is returned
+func Load(path string) (*File, error) {
+	f := &File{path: path, Accounts: map[string]*Account{}}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read state file: %w", err)
+	}
+	if err := json.Unmarshal(content, f); err != nil {
+		return nil, fmt.Errorf("could not parse state file %s: %w", path, err)
+	}
+	if f.Accounts == nil {
+		f.Accounts = map[string]*Account{}
+	}
+	return f, nil
+}
+
+// Save writes the state to the path it was loaded from, missing directories are created
+func (f *File) Save() error {
+	content, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("could not create state directory: %w", err)
+	}
+	// write to a temporary file first, so an interrupted write does not destroy the old state
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return fmt.Errorf("could not write state file: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("could not write state file: %w", err)
+	}
+	return nil
+}
+
+// key returns the key that identifies an account in the state file, it is the normalized iban (or bank number and
+// account number) followed by the currency for the currency pockets of an account
+func key(account, currency string) string {
+	k := strings.ToUpper(strings.ReplaceAll(account, " ", ""))
+	if currency != "" {
+		k += "/" + currency
+	}
+	return k
+}
+
+// dataKey returns the key for the BankData, if it has no iban bank number and account number are used
+func dataKey(data *mt940.BankData) string {
+	account := data.BankNumber + "/" + data.AccountNumber
+	if data.IBAN != "" {
+		account = data.IBAN
+	}
+	return key(account, data.Currency)
+}
+
+// Lookup returns the state of the account with the given iban, currency is empty unless the account has currency pockets
+func (f *File) Lookup(iban, currency string) (*Account, bool) {
+	a, ok := f.Accounts[key(iban, currency)]
+	return a, ok
+}
+
+// Continue continues the statement chain of the previous run: the statement number is set to the next number
+// if it is not set yet, a new chain starts with 1. It returns warnings if the transactions overlap with the previous run
+// or if the opening balance does not match the last closing balance.
+func (f *File) Continue(data *mt940.BankData) []string {
+	account, ok := f.Accounts[dataKey(data)]
+	if !ok {
+		// the first statement of a new chain
+		if data.StatementNumber == 0 {
+			data.StatementNumber = 1
+		}
+		return nil
+	}
+	if data.StatementNumber == 0 {
+		data.StatementNumber = account.StatementNumber + 1
+	}
+	openingBalance, err := data.OpeningBalance()
+	if err != nil {
+		// nothing to continue without transactions and balances
+		return nil
+	}
+
+	var warnings []string
+	if len(data.Transactions) > 0 {
+		first := data.Transactions[0]
+		if lastDate, err := time.Parse(dateLayout, account.LastBookingDate); err == nil && first.Date().Before(lastDate) {
+			warnings = append(warnings, fmt.Sprintf(
+				"transactions start on %s, but the previous run already contained transactions until %s",
+				first.Date().Format(dateLayout), account.LastBookingDate,
+			))
+		}
+	}
+
+	opening := openingBalance.Amount
+	closing := money.New(account.ClosingBalance, account.Currency)
+	if ok, err := opening.Equals(closing); err != nil || !ok {
+		warnings = append(warnings, fmt.Sprintf(
+			"opening balance %s %s does not match closing balance %s %s of the previous run (booked until %s), transactions are missing or duplicated",
+			formatter.ConvertMoneyToString(opening), opening.Currency().Code,
+			formatter.ConvertMoneyToString(closing), closing.Currency().Code,
+			account.LastBookingDate,
+		))
+	}
+	return warnings
+}
+
+// Record saves closing balance, last booking date and last statement number of the written statements
+func (f *File) Record(data *mt940.BankData) {
+	closing, err := data.ClosingBalance()
+	if err != nil {
+		return
+	}
+	statements := data.Statements()
+	lastBookingDate := closing.Date
+	if len(data.Transactions) > 0 {
+		lastBookingDate = data.Transactions[len(data.Transactions)-1].Date()
+	}
+
+	f.Accounts[dataKey(data)] = &Account{
+		ClosingBalance:  closing.Amount.Amount(),
+		Currency:        closing.Amount.Currency().Code,
+		LastBookingDate: lastBookingDate.Format(dateLayout),
+		StatementNumber: statements[len(statements)-1].StatementNumber,
+	}
+}
@@ -0,0 +1,161 @@
+package state
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+	"github.com/Rhymond/go-money"
+)
+
+func testLine(day int, amount int64, saldo int64) *mt940.StatementLine {
+	date := time.Date(2020, time.January, day, 0, 0, 0, 0, time.UTC)
+	return &mt940.StatementLine{
+		Sales:   mt940.SalesLine{ValueDate: date, EntryDate: date, Amount: money.New(amount, "EUR")},
+		Balance: money.New(saldo, "EUR"),
+	}
+}
+
+func TestLoadAndSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "csvtomt940", "state.json")
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(f.Accounts) != 0 {
+		t.Fatalf("Load() of missing file returned accounts %v", f.Accounts)
+	}
+
+	f.Record(&mt940.BankData{
+		IBAN:         "DE32 5001 0517 1234 5678 95",
+		Split:        mt940.SplitDay,
+		Transactions: []mt940.Transaction{testLine(1, 100, 1100), testLine(2, -200, 900)},
+	})
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := &Account{ClosingBalance: 900, Currency: "EUR", LastBookingDate: "2020-01-02", StatementNumber: 2}
+	got, ok := loaded.Lookup("DE32500105171234567895", "")
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("Lookup() got = %#v, want %#v", got, want)
+	}
+}
+
+func TestFile_Continue(t *testing.T) {
+	tests := []struct {
+		name             string
+		transactions     []mt940.Transaction
+		opening          *mt940.Balance
+		wantNumber       int
+		wantWarningCount int
+	}{
+		{
+			name:         "continues chain",
+			transactions: []mt940.Transaction{testLine(3, 100, 1000)},
+			wantNumber:   6,
+		},
+		{
+			name:             "gap",
+			transactions:     []mt940.Transaction{testLine(3, 100, 1100)},
+			wantNumber:       6,
+			wantWarningCount: 1,
+		},
+		{
+			name:             "overlap",
+			transactions:     []mt940.Transaction{testLine(1, 100, 1000), testLine(3, 100, 1100)},
+			wantNumber:       6,
+			wantWarningCount: 1,
+		},
+		{
+			name:       "statement without transactions",
+			opening:    &mt940.Balance{Date: time.Date(2020, time.January, 3, 0, 0, 0, 0, time.UTC), Amount: money.New(900, "EUR")},
+			wantNumber: 6,
+		},
+		{
+			name:             "gap without transactions",
+			opening:          &mt940.Balance{Date: time.Date(2020, time.January, 3, 0, 0, 0, 0, time.UTC), Amount: money.New(1000, "EUR")},
+			wantNumber:       6,
+			wantWarningCount: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &File{Accounts: map[string]*Account{
+				"DE32500105171234567895": {ClosingBalance: 900, Currency: "EUR", LastBookingDate: "2020-01-02", StatementNumber: 5},
+			}}
+			data := &mt940.BankData{IBAN: "DE32500105171234567895", Opening: tt.opening, Transactions: tt.transactions}
+			warnings := f.Continue(data)
+			if len(warnings) != tt.wantWarningCount {
+				t.Errorf("Continue() got warnings %v, want %d", warnings, tt.wantWarningCount)
+			}
+			if data.StatementNumber != tt.wantNumber {
+				t.Errorf("Continue() statement number = %d, want %d", data.StatementNumber, tt.wantNumber)
+			}
+		})
+	}
+}
+
+func TestFile_Continue_NewAccount(t *testing.T) {
+	f := &File{Accounts: map[string]*Account{}}
+	data := &mt940.BankData{IBAN: "DE32500105171234567895", Transactions: []mt940.Transaction{testLine(3, 100, 1000)}}
+	if warnings := f.Continue(data); warnings != nil {
+		t.Errorf("Continue() got warnings %v", warnings)
+	}
+	if data.StatementNumber != 1 {
+		t.Errorf("Continue() statement number = %d, want 1", data.StatementNumber)
+	}
+}
+
+func TestFile_RecordAndLookup(t *testing.T) {
+	f := &File{Accounts: map[string]*Account{}}
+	f.Record(&mt940.BankData{IBAN: "DE32 5001 0517 1234 5678 95", Transactions: []mt940.Transaction{testLine(1, 100, 1100)}})
+	f.Record(&mt940.BankData{
+		IBAN:         "LT12 3250 0123 4567 8901",
+		Currency:     "USD",
+		Transactions: []mt940.Transaction{testLine(2, 100, 500)},
+	})
+
+	tests := []struct {
+		iban, currency string
+		wantBalance    int64
+		wantOk         bool
+	}{
+		{iban: "de32500105171234567895", wantBalance: 1100, wantOk: true},
+		{iban: "LT123250012345678901", currency: "USD", wantBalance: 500, wantOk: true},
+		{iban: "LT123250012345678901", wantOk: false},
+		{iban: "DE32500105171234567895", currency: "USD", wantOk: false},
+	}
+	for _, tt := range tests {
+		got, ok := f.Lookup(tt.iban, tt.currency)
+		if ok != tt.wantOk || (ok && got.ClosingBalance != tt.wantBalance) {
+			t.Errorf("Lookup(%s, %s) = %+v, %v, want %d, %v", tt.iban, tt.currency, got, ok, tt.wantBalance, tt.wantOk)
+		}
+	}
+}
+
+func Test_dataKey(t *testing.T) {
+	tests := []struct {
+		name string
+		data *mt940.BankData
+		want string
+	}{
+		{name: "iban", data: &mt940.BankData{IBAN: "de32 5001 0517 1234 5678 95", BankNumber: "50010517"}, want: "DE32500105171234567895"},
+		{name: "without iban", data: &mt940.BankData{BankNumber: "50010517", AccountNumber: "VISA"}, want: "50010517/VISA"},
+		{name: "currency pocket", data: &mt940.BankData{IBAN: "LT123250012345678901", Currency: "USD"}, want: "LT123250012345678901/USD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dataKey(tt.data); got != tt.want {
+				t.Errorf("dataKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/JHeimbach/csvtomt940/mt940"
+)
+
+// runValidate checks the given MT940 files and prints all issues, it returns the exit code of the program
+func runValidate(programName string, args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "USAGE:\n\t %s validate <statement.sta> [<statement.sta> ...]\n", programName)
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	exitCode := 0
+	for _, fileName := range fs.Args() {
+		staFile, err := os.Open(fileName)
+		if err != nil {
+			log.Printf("could not open file %s: %v", fileName, err)
+			exitCode = 1
+			continue
+		}
+		issues := mt940.Validate(staFile)
+		_ = staFile.Close()
+
+		for _, issue := range issues {
+			fmt.Printf("%s:%d:%d: %s\n", fileName, issue.Line, issue.Column, issue.Message)
+		}
+		if len(issues) > 0 {
+			exitCode = 1
+			continue
+		}
+		log.Printf("%s is valid", fileName)
+	}
+	return exitCode
+}